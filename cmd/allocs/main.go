@@ -0,0 +1,99 @@
+// Command allocs measures what the parser package's Arena saves the garbage collector.  It
+// parses the same lists of lists of numbers many times, with their syntax trees, first
+// allocating from the heap and then from one Arena reset after each parse, and prints the
+// allocations and collections each way:
+//
+//	go run ./cmd/allocs -parses 2000 -rows 200
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+func main() {
+	parses := flag.Int("parses", 1000, "the number of parses to make each way")
+	rows := flag.Int("rows", 100, "the number of rows in the input")
+	seed := flag.Int64("seed", 1, "seeds the input")
+	flag.Parse()
+
+	input := table(rand.New(rand.NewSource(*seed)), *rows)
+	grammar := rowsParser()
+	heap := measure(*parses, func() { parse(grammar, input) })
+	var arena parser.Arena
+	pooled := measure(*parses, func() {
+		parse(grammar, input, parser.WithArena(&arena))
+		arena.Reset()
+	})
+	fmt.Printf("%-6s %12s %12s %6s\n", "", "allocs/parse", "bytes/parse", "GCs")
+	for _, r := range []struct {
+		name string
+		m    measurement
+	}{{"heap", heap}, {"arena", pooled}} {
+		fmt.Printf("%-6s %12d %12d %6d\n", r.name, r.m.allocs/uint64(*parses), r.m.bytes/uint64(*parses), r.m.collections)
+	}
+}
+
+// rowsParser returns the parser for rows of comma-separated numbers, one row to a line, with
+// each row and number named in the syntax tree.
+func rowsParser() parser.Parser[[][]int] {
+	number := parser.Label(parser.Map(parser.TakeSome(unicode.IsDigit), func(digits string) int {
+		n, _ := strconv.Atoi(digits)
+		return n
+	}), "number")
+	row := parser.Label(parser.SepBy1(number, parser.Exactly(",")), "row")
+	return parser.EndBy(row, parser.Exactly("\n"))
+}
+
+func parse(grammar parser.Parser[[][]int], input string, options ...parser.Option) {
+	var tree parser.SyntaxNode
+	if _, err := parser.Parse(grammar, input, append(options, parser.WithSyntaxTree(&tree))...); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// A measurement is what a run of parses cost.
+type measurement struct {
+	allocs, bytes uint64
+	collections   uint32
+}
+
+// measure runs f n times, and returns the allocations and collections made meanwhile.
+func measure(n int, f func()) measurement {
+	f() // Once first, so that an Arena has grown to size.
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < n; i++ {
+		f()
+	}
+	runtime.ReadMemStats(&after)
+	return measurement{
+		allocs:      after.Mallocs - before.Mallocs,
+		bytes:       after.TotalAlloc - before.TotalAlloc,
+		collections: after.NumGC - before.NumGC,
+	}
+}
+
+// table returns rows lines of random numbers, each ending with a newline.
+func table(random *rand.Rand, rows int) string {
+	var b strings.Builder
+	for i := 0; i < rows; i++ {
+		for j, n := 0, 1+random.Intn(20); j < n; j++ {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.Itoa(random.Intn(100000)))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+// Command genapply writes the longer members of the parser package's ApplyN family, so that
+// they needn't be maintained by hand as the arities grammars need grow.  For each arity from 4
+// up to the maximum, it writes ApplyN and ApplySpannedN, and for each from 2 up, ValuesN, which
+// takes the values out of a sequence, and KeepN, which builds a sequence in one call.  Apply,
+// Apply2 and Apply3 and their spanned forms are in sequence.go, written by hand.
+//
+// It is run by go generate in the parser package:
+//
+//	//go:generate go run ../cmd/genapply -max 9 -o apply.go
+//
+// A grammar which needs longer sequences than that can have them written into its own
+// package, with -pkg naming the package and -min starting where the parser package stops.
+// The functions written there use only the parser package's exported API, taking sequences
+// apart with Seq.Split:
+//
+//	//go:generate go run github.com/jhbrown-veradept/gophercon22-parser-combnators/cmd/genapply -pkg mygrammar -min 10 -max 12 -o apply.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// letters are the names of the type parameters, in order, as in sequence.go; past them, the
+// type parameters are T10, T11 and so on.
+var letters = []string{"T", "U", "V", "W", "X", "Y", "Z", "R", "S"}
+
+// words are the names of the arities written out in doc comments.
+var words = []string{"", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine"}
+
+// arity describes the functions of one arity, for the template.
+type arity struct {
+	N      int
+	Word   string   // N as a word, or a number past nine.
+	A      string   // The article for Word, "a" or "an".
+	Params []string // The type parameters.
+	Seq    string   // The type of a sequence of N values.
+	Values []string // The expressions for the values of a sequence called seq, after Unpack.
+	Unpack []string // Statements taking seq apart into Values, outside the parser package.
+	Args   []string // The parameters of KeepN, one parser per type parameter.
+	Keep   string   // The expression KeepN returns, in terms of Args.
+}
+
+// newArity returns the arity n, with the parser package's names qualified by q, which is ""
+// inside the package and "parser." outside it.
+func newArity(n int, q string) arity {
+	a := arity{N: n, Word: fmt.Sprint(n), Seq: q + "Empty"}
+	if n < len(words) {
+		a.Word = words[n]
+	}
+	a.A = "a"
+	if strings.HasPrefix(a.Word, "eight") || strings.HasPrefix(a.Word, "8") || n == 11 || n == 18 {
+		a.A = "an"
+	}
+	for i := 0; i < n; i++ {
+		p := fmt.Sprintf("T%d", i+1)
+		if i < len(letters) {
+			p = letters[i]
+		}
+		a.Params = append(a.Params, p)
+		a.Seq = fmt.Sprintf("%sSeq[%s, %s]", q, a.Seq, p)
+		if q == "" {
+			a.Values = append(a.Values, "seq."+strings.Repeat("first.", n-1-i)+"second")
+		} else {
+			a.Values = append(a.Values, fmt.Sprintf("v%d", i+1))
+		}
+		arg := strings.ToLower(p)
+		a.Args = append(a.Args, fmt.Sprintf("%s %sParser[%s]", arg, q, p))
+		if i == 0 {
+			a.Keep = fmt.Sprintf("%sStartKeeping(%s)", q, arg)
+		} else {
+			a.Keep = fmt.Sprintf("%sAppendKeeping(%s, %s)", q, a.Keep, arg)
+		}
+	}
+	if q != "" {
+		rest := "seq"
+		for i := n; i >= 1; i-- {
+			next := fmt.Sprintf("rest%d", i-1)
+			if i == 1 {
+				next = "_"
+			}
+			a.Unpack = append(a.Unpack, fmt.Sprintf("%s, v%d := %s.Split()", next, i, rest))
+			rest = next
+		}
+	}
+	return a
+}
+
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"typeParams": func(params []string) string {
+		return strings.Join(params, " any, ") + " any"
+	},
+}
+
+var file = template.Must(template.New("file").Funcs(funcs).Parse(`// Code generated by genapply{{.Flags}}; DO NOT EDIT.
+
+package {{.Package}}
+{{if .Q}}
+import "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+{{end}}{{range .Apply}}
+// Apply{{.N}} returns a parser by transforming the output of the argument parser, which produces
+// {{.A}} {{.Word}}-element sequence.  The resulting parser transforms the {{.Word}} values from the sequence
+// into the final result value using the argument mapper function.
+func Apply{{.N}}[{{typeParams .Params}}, A any]({{$.Arg}} {{$.Q}}Parser[{{.Seq}}], mapper func({{join .Params ", "}}) A) {{$.Q}}Parser[A] {
+	return func(initial {{$.Q}}State) (A, {{$.Q}}State, error) {
+		seq, next, err := {{$.Arg}}(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		{{range .Unpack}}{{.}}
+		{{end}}return mapper({{join .Values ", "}}), next, nil
+	}
+}
+
+// ApplySpanned{{.N}} is like Apply{{.N}}, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned{{.N}}[{{typeParams .Params}}, A any]({{$.Arg}} {{$.Q}}Parser[{{.Seq}}], mapper func({{$.Q}}Span, {{join .Params ", "}}) A) {{$.Q}}Parser[A] {
+	return func(initial {{$.Q}}State) (A, {{$.Q}}State, error) {
+		seq, next, err := {{$.Arg}}(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := {{$.Q}}Span{Start: initial.Offset(), End: next.Offset()}
+		{{range .Unpack}}{{.}}
+		{{end}}return mapper(span, {{join .Values ", "}}), next, nil
+	}
+}
+{{end}}{{range .Values}}
+// Values{{.N}} returns the {{.Word}} values of {{.A}} {{.Word}}-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values{{.N}}[{{typeParams .Params}}](seq {{.Seq}}) ({{join .Params ", "}}) {
+	{{range .Unpack}}{{.}}
+	{{end}}return {{join .Values ", "}}
+}
+{{end}}{{range .Values}}
+// Keep{{.N}} returns a sequence Parser which runs the {{.Word}} argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply{{.N}}.
+func Keep{{.N}}[{{typeParams .Params}}]({{join .Args ", "}}) {{$.Q}}Parser[{{.Seq}}] {
+	return {{.Keep}}
+}
+{{end}}`))
+
+func main() {
+	min := flag.Int("min", 2, "the smallest arity to write functions for; ApplyN start at 4 regardless")
+	max := flag.Int("max", 9, "the largest arity to write functions for")
+	pkg := flag.String("pkg", "parser", "the package to write the functions in")
+	out := flag.String("o", "apply.go", "the file to write")
+	flag.Parse()
+	if *max < 4 || *max < *min {
+		log.Fatalf("genapply: -max %d is less than 4 or -min %d", *max, *min)
+	}
+	data := struct {
+		Flags   string // The flags which aren't the defaults, for the header.
+		Package string
+		Q       string // What qualifies the parser package's names: "" inside it, "parser." outside.
+		Arg     string // The name of ApplyN's parser argument, which mustn't hide the package outside it.
+		Apply   []arity
+		Values  []arity
+	}{Package: *pkg, Arg: "parser"}
+	if *pkg != "parser" {
+		data.Flags += " -pkg " + *pkg
+		data.Q, data.Arg = "parser.", "sequence"
+	}
+	if *min != 2 {
+		data.Flags += fmt.Sprintf(" -min %d", *min)
+	}
+	data.Flags += fmt.Sprintf(" -max %d", *max)
+	for n := *min; n <= *max; n++ {
+		if n < 2 {
+			continue
+		}
+		if n >= 4 {
+			data.Apply = append(data.Apply, newArity(n, data.Q))
+		}
+		data.Values = append(data.Values, newArity(n, data.Q))
+	}
+	var b bytes.Buffer
+	if err := file.Execute(&b, data); err != nil {
+		log.Fatalf("genapply: %v", err)
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("genapply: formatting the generated code: %v", err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatalf("genapply: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Command genentities writes the table of HTML5 named character references for the
+// formats/entity package, so that its HTML parser can look each name up exactly.  The names
+// are taken from the table in the source of the standard library's html package, which is not
+// exported; only the names terminated by ";" are kept, since those are the only ones HTML
+// parses.
+//
+// It is run by go generate in the formats/entity package:
+//
+//	//go:generate go run ../../cmd/genentities -o html5.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+)
+
+// entry matches an entry of the html package's tables whose name is terminated by ";", with
+// the name and the one or two runes it stands for.
+var entry = regexp.MustCompile(`"([A-Za-z0-9]+);":\s*(?:('[^']+')|\{('[^']+'), ('[^']+')\})`)
+
+func main() {
+	out := flag.String("o", "html5.go", "the file to write")
+	source := flag.String("source", filepath.Join(runtime.GOROOT(), "src", "html", "entity.go"), "the html package's table of entities")
+	flag.Parse()
+	text, err := os.ReadFile(*source)
+	if err != nil {
+		log.Fatalf("genentities: %v", err)
+	}
+	entities := make(map[string]string)
+	var names []string
+	for _, m := range entry.FindAllSubmatch(text, -1) {
+		n := string(m[1])
+		if _, ok := entities[n]; ok {
+			continue
+		}
+		var decoded string
+		for _, r := range m[2:] {
+			if len(r) == 0 {
+				continue
+			}
+			s, err := strconv.Unquote(string(r))
+			if err != nil {
+				log.Fatalf("genentities: the runes of %s: %v", n, err)
+			}
+			decoded += s
+		}
+		entities[n] = decoded
+		names = append(names, n)
+	}
+	if len(names) == 0 {
+		log.Fatalf("genentities: no entities found in %s", *source)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	b.WriteString("// Code generated by genentities; DO NOT EDIT.\n\npackage entity\n\n")
+	b.WriteString("// htmlEntities are the named references defined by HTML5, without their \"&\" and \";\",\n")
+	b.WriteString("// and the text each stands for.\n")
+	b.WriteString("var htmlEntities = map[string]string{\n")
+	for _, n := range names {
+		fmt.Fprintf(&b, "\t%q: %+q,\n", n, entities[n])
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("genentities: formatting the generated code: %v", err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatalf("genentities: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+// Command stress runs the parser/stress harness over the example grammar and over arithmetic
+// grammars built with each of the parser package's shared tables: Memo under each MemoPolicy,
+// the rule registry, a Grammar extending another, and a ParserRef.  It exits with status 1 if
+// any concurrent parse disagrees with the first.  Run it with the race detector:
+//
+//	go run -race ./cmd/stress -goroutines 32 -iterations 5000 -seed 7
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/example"
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/parser/stress"
+)
+
+func main() {
+	var config stress.Config
+	flag.IntVar(&config.Goroutines, "goroutines", 16, "the number of goroutines parsing at once")
+	flag.IntVar(&config.Iterations, "iterations", 1000, "the number of parses each goroutine makes")
+	flag.Int64Var(&config.Seed, "seed", 1, "seeds the inputs and their order")
+	inputs := flag.Int("inputs", 50, "the number of inputs to generate for each case")
+	flag.Parse()
+
+	random := rand.New(rand.NewSource(config.Seed))
+	sums := make([]string, *inputs)
+	configs := make([]string, *inputs)
+	for i := range sums {
+		sums[i] = sum(random, 3)
+		configs[i] = configuration(random)
+		if i%5 == 4 { // Some inputs which don't parse, so that errors are compared too.
+			sums[i] = sums[i][:random.Intn(len(sums[i]))]
+			configs[i] = configs[i][:random.Intn(len(configs[i]))]
+		}
+	}
+
+	parser.Register("stress.number", number)
+	cases := []stress.Case{
+		stress.NewCase("example", example.NewConfigParser().ConfigurationParser, configs),
+		stress.NewCase("memo/per-parse", memoized(), sums),
+		stress.NewCase("memo/lru", memoized(), sums, parser.WithMemo(parser.BoundedLRU(16))),
+		stress.NewCase("memo/shared", memoized(), sums, parser.WithMemo(parser.NewSharedMemo(8))),
+		stress.NewCase("registry", arithmetic(parser.Rule[int]("stress.number")), sums),
+		stress.NewCase("grammar", parser.Start[int](grammar(), "sum"), sums),
+	}
+	if err := stress.Run(config, cases...); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("stress: %d cases, %d goroutines, %d parses each: ok\n", len(cases), config.Goroutines, config.Iterations)
+}
+
+var number = parser.Map(parser.TakeSome(unicode.IsDigit), func(digits string) int {
+	n, _ := strconv.Atoi(digits)
+	return n
+})
+
+// op returns the parser for an infix operator token, which applies f.
+func op(token string, f func(int, int) int) parser.Parser[func(int, int) int] {
+	return parser.Map(parser.Exactly(token), func(parser.Empty) func(int, int) int { return f })
+}
+
+var (
+	plus  = op("+", func(a, b int) int { return a + b })
+	minus = op("-", func(a, b int) int { return a - b })
+	times = op("*", func(a, b int) int { return a * b })
+)
+
+// arithmetic returns the parser for sums and products of numbers, as parsed by the number
+// parser given, and parenthesized sums, built with a ParserRef.
+func arithmetic(number parser.Parser[int]) parser.Parser[int] {
+	ref := parser.Ref[int]()
+	parenthesized := parser.Apply(
+		parser.AppendSkipping(parser.AppendKeeping(parser.StartSkipping(parser.Exactly("(")), ref.Parser()), parser.Exactly(")")),
+		func(n int) int { return n })
+	var ops parser.OperatorTable[int]
+	ops.InfixLeft(1, plus)
+	ops.InfixLeft(1, minus)
+	ops.InfixLeft(2, times)
+	ref.Define(parser.Expression(parser.OneOf(number, parenthesized), &ops))
+	return ref.Parser()
+}
+
+// memoized returns an arithmetic parser whose numbers are parsed by a Memo rule.
+func memoized() parser.Parser[int] {
+	return arithmetic(parser.Memo(number))
+}
+
+// grammar returns a Grammar for sums which extends a base Grammar and overrides its term.
+func grammar() *parser.Grammar {
+	base := parser.NewGrammar("base")
+	parser.Define(base, "sum", parser.ChainLeft1(parser.Use[int]("term"), parser.OneOf(plus, minus)))
+	parser.Define(base, "term", number)
+	derived := parser.NewGrammar("derived", base)
+	parser.Override(derived, "term", parser.ChainLeft1(parser.OneOf(parser.Use[int]("base.term"), parser.Use[int]("group")), times))
+	parser.Define(derived, "group", parser.Apply(
+		parser.AppendSkipping(parser.AppendKeeping(parser.StartSkipping(parser.Exactly("(")), parser.Use[int]("sum")), parser.Exactly(")")),
+		func(n int) int { return n }))
+	return derived
+}
+
+// sum returns a random arithmetic expression, nested at most depth deep.
+func sum(random *rand.Rand, depth int) string {
+	var b strings.Builder
+	for i, n := 0, 1+random.Intn(4); i < n; i++ {
+		if i > 0 {
+			b.WriteByte("+-*"[random.Intn(3)])
+		}
+		if depth > 0 && random.Intn(4) == 0 {
+			b.WriteString("(" + sum(random, depth-1) + ")")
+		} else {
+			b.WriteString(strconv.Itoa(random.Intn(1000)))
+		}
+	}
+	return b.String()
+}
+
+// configuration returns a random configuration in the example's format.
+func configuration(random *rand.Rand) string {
+	bindings := make([]string, 1+random.Intn(5))
+	for i := range bindings {
+		value := strconv.Itoa(random.Intn(100))
+		if random.Intn(2) == 0 {
+			value = strconv.FormatBool(random.Intn(2) == 0)
+		}
+		bindings[i] = fmt.Sprintf("%c%d = %s", 'a'+rune(random.Intn(26)), i, value)
+	}
+	return "[ " + strings.Join(bindings, ", ") + " ]"
+}
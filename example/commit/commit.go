@@ -0,0 +1,210 @@
+// This package provides a Parser for commit messages written to the Conventional Commits
+// specification, such as
+//
+//	feat(parser)!: drop support for tabs in names
+//
+//	Names may now only contain letters and digits.
+//
+//	BREAKING CHANGE: configurations using tabs must be rewritten
+//	Reviewed-by: Alice
+//
+// Here is a grammar for the format:
+//
+//	message:      header (blank+ paragraph)* [blank+ trailers] blank*
+//
+//	header:       type ['(' scope ')'] ['!'] ':' ' '+ subject
+//
+//	type:         [a-zA-Z]+
+//
+//	scope:        [^()]+
+//
+//	subject:      .+
+//
+//	paragraph:    nonblank+
+//
+//	trailers:     trailer+                  -- only as the last paragraph
+//
+//	trailer:      token (': ' | ' #') value continuation*
+//
+//	token:        'BREAKING CHANGE' | [a-zA-Z0-9-]+
+//
+//	continuation: [ \t]+ .*
+//
+// Each grammar rule above works on whole lines, so lines are taken with Line and their
+// contents parsed with Nested.  The optional parts of the header are each written as OneOf
+// the part and a Succeed with its default.
+package commit
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Commit is a parsed commit message.
+type Commit struct {
+	Type     string    // e.g. "feat" or "fix", as written.
+	Scope    string    // Empty if there is none.
+	Breaking bool      // Whether the header has "!" or there is a BREAKING CHANGE trailer.
+	Subject  string    // The rest of the header line.
+	Body     string    // The paragraphs between the header and the trailers, separated by blank lines.
+	Trailers []Trailer // In input order.
+}
+
+// A Trailer is a "Token: value" or "Token #value" line at the end of a commit message.
+type Trailer struct {
+	Token string
+	Value string // With any continuation lines joined by newlines.
+}
+
+// Parsers holds the parsers for commit messages.  The sole exported field is the Parser
+// for a whole message; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	headerParser    Parser[Commit]
+	blankParser     Parser[Empty]
+	textParser      Parser[string]
+	paragraphParser Parser[string]
+	trailerParser   Parser[Trailer]
+	trailersParser  Parser[[]Trailer]
+
+	MessageParser Parser[Commit]
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func isTokenRune(r rune) bool {
+	return isLetter(r) || r >= '0' && r <= '9' || r == '-'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func anyRune(rune) bool {
+	return true
+}
+
+// many returns a parser for zero or more of parser, or one or more if atLeastOne is set.
+func many[T any](parser Parser[T], atLeastOne bool) Parser[[]T] {
+	return Loop(nil, func(ts []T) Parser[Step[[]T, []T]] {
+		more := Map(parser, func(t T) Step[[]T, []T] {
+			return Step[[]T, []T]{Accum: append(ts, t)}
+		})
+		if atLeastOne && ts == nil {
+			return more
+		}
+		return OneOf(more, Succeed(Step[[]T, []T]{Done: true, Value: ts}))
+	})
+}
+
+// NewParsers returns a Parsers structure whose MessageParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	{
+		scope := OneOf(
+			Apply(
+				AppendSkipping(AppendKeeping(StartSkipping(Exactly("(")),
+					TakeSome(func(r rune) bool { return r != '(' && r != ')' })), Exactly(")")),
+				func(scope string) string { return scope }),
+			Succeed(""),
+		)
+		breaking := OneOf(
+			Map(Exactly("!"), func(Empty) bool { return true }),
+			Succeed(false),
+		)
+		s := StartKeeping(TakeSome(isLetter))
+		s1 := AppendKeeping(s, scope)
+		s2 := AppendKeeping(s1, breaking)
+		prefix := Apply3(s2, func(kind string, scope string, breaking bool) Commit {
+			return Commit{Type: kind, Scope: scope, Breaking: breaking}
+		})
+		p.headerParser = Nested(Line, AndThen(prefix, func(c Commit) Parser[Commit] {
+			s := StartSkipping(Exactly(":"))
+			s1 := AppendSkipping(s, ConsumeSome(func(r rune) bool { return r == ' ' }))
+			s2 := AppendKeeping(s1, TakeSome(anyRune))
+			return Apply(s2, func(subject string) Commit {
+				c.Subject = subject
+				return c
+			})
+		}))
+	}
+
+	p.blankParser = Nested(Line, ConsumeWhile(isSpace))
+	p.textParser = AndThen(Line, func(line string) Parser[string] {
+		if strings.TrimSpace(line) == "" {
+			return Fail[string]
+		}
+		return Succeed(line)
+	})
+	p.paragraphParser = Map(many(p.textParser, true), func(lines []string) string {
+		return strings.Join(lines, "\n")
+	})
+
+	{
+		token := OneOf(
+			GetString(Exactly("BREAKING CHANGE")),
+			TakeSome(isTokenRune),
+		)
+		separator := OneOf(Exactly(": "), Exactly(" #"))
+		s := StartKeeping(token)
+		s1 := AppendSkipping(s, separator)
+		s2 := AppendKeeping(s1, TakeWhile(anyRune))
+		first := Nested(Line, Apply2(s2, func(token string, value string) Trailer {
+			return Trailer{Token: token, Value: value}
+		}))
+		continuation := Nested(Line, GetString(AppendSkipping(StartSkipping(ConsumeSome(isSpace)), ConsumeWhile(anyRune))))
+		s3 := StartKeeping(first)
+		s4 := AppendKeeping(s3, many(continuation, false))
+		p.trailerParser = Apply2(s4, func(t Trailer, more []string) Trailer {
+			for _, line := range more {
+				t.Value += "\n" + strings.TrimSpace(line)
+			}
+			return t
+		})
+	}
+	{
+		s := StartKeeping(many(p.trailerParser, true))
+		s1 := AppendSkipping(s, many(p.blankParser, false))
+		s2 := AppendSkipping(s1, EndOfInput)
+		p.trailersParser = Apply(s2, func(trailers []Trailer) []Trailer { return trailers })
+	}
+
+	{
+		type tail struct {
+			paragraphs []string
+			trailers   []Trailer
+		}
+		blanks := many(p.blankParser, true)
+		tails := Loop(tail{}, func(t tail) Parser[Step[tail, tail]] {
+			end := Map(AppendSkipping(StartSkipping(many(p.blankParser, false)), EndOfInput),
+				func(Empty) Step[tail, tail] { return Step[tail, tail]{Done: true, Value: t} })
+			trailers := Apply(AppendKeeping(StartSkipping(blanks), p.trailersParser),
+				func(trailers []Trailer) Step[tail, tail] {
+					t.trailers = trailers
+					return Step[tail, tail]{Done: true, Value: t}
+				})
+			paragraph := Apply(AppendKeeping(StartSkipping(blanks), p.paragraphParser),
+				func(paragraph string) Step[tail, tail] {
+					return Step[tail, tail]{Accum: tail{paragraphs: append(t.paragraphs, paragraph)}}
+				})
+			return OneOf(end, trailers, paragraph)
+		})
+		s := StartKeeping(p.headerParser)
+		s1 := AppendKeeping(s, tails)
+		p.MessageParser = Apply2(s1, func(c Commit, t tail) Commit {
+			c.Body = strings.Join(t.paragraphs, "\n\n")
+			c.Trailers = t.trailers
+			for _, trailer := range t.trailers {
+				if trailer.Token == "BREAKING CHANGE" || trailer.Token == "BREAKING-CHANGE" {
+					c.Breaking = true
+				}
+			}
+			return c
+		})
+	}
+
+	return p
+}
@@ -0,0 +1,184 @@
+// This package provides a Parser for a practical subset of Dockerfile syntax:
+//
+//	# Build stage
+//	FROM golang:1.19 AS build
+//	RUN go build \
+//	    -o /app .
+//	ENTRYPOINT ["/app", "--port", "8080"]
+//
+// Here is a grammar for the subset handled:
+//
+//	dockerfile:   (blank | comment | instruction)*
+//
+//	comment:      [ \t]* '#' .* '\n'
+//
+//	instruction:  logical-line, parsed as  keyword [ \t]+ arguments
+//
+//	logical-line: (.* '\\' '\n' (comment | blank)*)* .* '\n'
+//
+//	keyword:      [a-zA-Z]+
+//
+//	arguments:    json-form | shell-form
+//
+//	json-form:    '[' [string (',' string)*] ']'      -- JSON strings, with whitespace allowed
+//
+//	shell-form:   .*
+//
+// A line ending in a backslash continues onto the next line; comment and blank lines in the
+// middle of a continued instruction are dropped, as Docker does.  The joined logical line is then
+// parsed on its own with Nested.  Arguments that look like JSON but don't parse as a JSON array
+// of strings are taken in shell form, again as Docker does.  Parser directives, heredocs and
+// changing the escape character are not supported.
+package dockerfile
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// An Instruction is a single Dockerfile instruction.
+type Instruction struct {
+	Keyword string   // The instruction keyword, upper-cased, e.g. "RUN".
+	Args    []string // In JSON form, the array elements; in shell form, a single element, or none.
+	JSON    bool     // Whether the arguments were in JSON (exec) form.
+	Span    Span     // Where the instruction appears in the input, including continuation lines.
+}
+
+// Parsers holds the parsers for Dockerfiles.  The sole exported field is the Parser for a
+// whole Dockerfile; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	skippedParser     Parser[Empty]
+	logicalLineParser Parser[string]
+	stringParser      Parser[string]
+	jsonFormParser    Parser[[]string]
+	shellFormParser   Parser[[]string]
+	instructionParser Parser[Instruction]
+
+	DockerfileParser Parser[[]Instruction]
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func anyRune(rune) bool {
+	return true
+}
+
+// many returns a parser for zero or more of parser.
+func many[T any](parser Parser[T]) Parser[[]T] {
+	return Loop(nil, func(ts []T) Parser[Step[[]T, []T]] {
+		return OneOf(
+			Map(parser, func(t T) Step[[]T, []T] { return Step[[]T, []T]{Accum: append(ts, t)} }),
+			Succeed(Step[[]T, []T]{Done: true, Value: ts}),
+		)
+	})
+}
+
+// NewParsers returns a Parsers structure whose DockerfileParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	// A comment or blank line.
+	p.skippedParser = AndThen(Line, func(line string) Parser[Empty] {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed == "" || trimmed[0] == '#' {
+			return Succeed(Empty{})
+		}
+		return Fail[Empty]
+	})
+
+	{
+		// Inside a continuation, comment and blank lines are dropped, and the input may end.
+		continued := Apply(AppendKeeping(StartSkipping(many(p.skippedParser)), OneOf(Line, Succeed(""))),
+			func(line string) string { return line })
+		p.logicalLineParser = Loop[*string](nil, func(joined *string) Parser[Step[*string, string]] {
+			line, prefix := Line, ""
+			if joined != nil {
+				line, prefix = continued, *joined
+			}
+			return Map(line, func(l string) Step[*string, string] {
+				trimmed := strings.TrimRight(l, " \t")
+				if strings.HasSuffix(trimmed, "\\") {
+					next := prefix + strings.TrimSuffix(trimmed, "\\")
+					return Step[*string, string]{Accum: &next}
+				}
+				return Step[*string, string]{Done: true, Value: prefix + l}
+			})
+		})
+	}
+
+	p.stringParser = AndThen(GetString(Quoted('"', '\\')), func(literal string) Parser[string] {
+		var s string
+		if err := json.Unmarshal([]byte(literal), &s); err != nil {
+			return Fail[string]
+		}
+		return Succeed(s)
+	})
+
+	{
+		ws := ConsumeWhile(func(r rune) bool { return isSpace(r) || r == '\n' || r == '\r' })
+		s := StartSkipping(ws)
+		s1 := AppendSkipping(s, Exactly(","))
+		s2 := AppendSkipping(s1, ws)
+		s3 := AppendKeeping(s2, p.stringParser)
+		more := Apply(s3, func(s string) string { return s })
+		elements := OneOf(
+			AndThen(p.stringParser, func(first string) Parser[[]string] {
+				return Map(many(more), func(rest []string) []string { return append([]string{first}, rest...) })
+			}),
+			Succeed([]string{}),
+		)
+		s4 := StartSkipping(Exactly("["))
+		s5 := AppendSkipping(s4, ws)
+		s6 := AppendKeeping(s5, elements)
+		s7 := AppendSkipping(s6, ws)
+		s8 := AppendSkipping(s7, Exactly("]"))
+		array := Apply(s8, func(elements []string) []string { return elements })
+		p.jsonFormParser = Nested(Map(TakeWhile(anyRune), strings.TrimSpace), array)
+	}
+
+	p.shellFormParser = Map(TakeWhile(anyRune), func(text string) []string {
+		if text = strings.TrimSpace(text); text == "" {
+			return nil
+		}
+		return []string{text}
+	})
+
+	{
+		json := Map(p.jsonFormParser, func(args []string) Instruction { return Instruction{Args: args, JSON: true} })
+		shell := Map(p.shellFormParser, func(args []string) Instruction { return Instruction{Args: args} })
+		s := StartKeeping(TakeSome(isLetter))
+		s1 := AppendSkipping(s, ConsumeWhile(isSpace))
+		s2 := AppendKeeping(s1, OneOf(json, shell))
+		instruction := Apply2(s2, func(keyword string, i Instruction) Instruction {
+			i.Keyword = strings.ToUpper(keyword)
+			return i
+		})
+		p.instructionParser = Map(WithSpan(Nested(p.logicalLineParser, instruction)), func(i Spanned[Instruction]) Instruction {
+			i.Value.Span = i.Span
+			return i.Value
+		})
+	}
+
+	p.DockerfileParser = Loop[[]Instruction](nil, func(is []Instruction) Parser[Step[[]Instruction, []Instruction]] {
+		return OneOf(
+			Map(EndOfInput, func(Empty) Step[[]Instruction, []Instruction] {
+				return Step[[]Instruction, []Instruction]{Done: true, Value: is}
+			}),
+			Map(p.skippedParser, func(Empty) Step[[]Instruction, []Instruction] {
+				return Step[[]Instruction, []Instruction]{Accum: is}
+			}),
+			Map(p.instructionParser, func(i Instruction) Step[[]Instruction, []Instruction] {
+				return Step[[]Instruction, []Instruction]{Accum: append(is, i)}
+			}),
+		)
+	})
+
+	return p
+}
@@ -0,0 +1,183 @@
+// This package provides a Parser for dotenv files, the .env files of environment variables
+// read by Docker Compose, Foreman and many language libraries:
+//
+//	# Database settings
+//	export DB_HOST=localhost
+//	DB_PASS='s3cr#t'          # single quotes are literal
+//	GREETING="Hello,\n\"world\""
+//	CERT="-----BEGIN CERTIFICATE-----
+//	MIIB...
+//	-----END CERTIFICATE-----"
+//
+// Here is a grammar for the format:
+//
+//	file:       (blank | assignment)*
+//
+//	blank:      [ \t]* [comment] eol
+//
+//	assignment: [ \t]* ['export' [ \t]+] key [ \t]* '=' [ \t]* value [ \t]* [comment] eol
+//
+//	key:        [A-Za-z_][A-Za-z0-9_.-]*
+//
+//	value:      "'" [^']* "'" | '"' ([^"\\] | '\\' .)* '"' | unquoted
+//
+//	unquoted:   [^'"\n][^\n]*             -- up to a comment, with trailing spaces removed
+//
+//	comment:    '#' [^\n]*                -- after whitespace, or at the start of a line
+//
+//	eol:        '\r'? '\n' | end of input
+//
+// Dotenv libraries disagree about the corners of this format; here is what this grammar
+// decides.  Quoted values may span lines.  Single-quoted values are taken literally.  In
+// double-quoted values, "\n", "\r" and "\t" stand for control characters, and a backslash
+// before any other rune stands for that rune, so "\"" is a quote and "\\" a backslash.
+// Unquoted values are taken literally, except that a "#" after whitespace starts a comment, so
+// "a#b" is a value but "a #b" is "a".  Nothing is expanded: "$HOME" is five runes.  Keys may
+// repeat, and every assignment is returned, in input order.
+package dotenv
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Parsers holds the parsers for dotenv files.  The sole exported field is the Parser for a
+// whole file; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	keyParser        Parser[string]
+	singleParser     Parser[string]
+	doubleParser     Parser[string]
+	unquotedParser   Parser[string]
+	blankParser      Parser[Empty]
+	assignmentParser Parser[Field[string]]
+
+	FileParser Parser[[]Field[string]]
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func isKeyStart(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+}
+
+func isKeyRune(r rune) bool {
+	return isKeyStart(r) || r >= '0' && r <= '9' || r == '.' || r == '-'
+}
+
+func notNewline(r rune) bool {
+	return r != '\n'
+}
+
+// double parses a double-quoted value, decoding its escapes.
+func double(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return "", initial, ErrNoMatch
+	}
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '"':
+			return b.String(), initial.Consume(i + 1), nil
+		case c == '\\' && i+1 < len(rest):
+			i++
+			switch rest[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(rest[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", initial, ErrNoMatch
+}
+
+// unquoted parses an unquoted value, up to the end of the line or a "#" after whitespace, with
+// trailing whitespace removed.  It doesn't match a value starting with a quote, so that an
+// unterminated quoted value fails rather than being taken literally.
+func unquoted(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if strings.HasPrefix(rest, "'") || strings.HasPrefix(rest, `"`) {
+		return "", initial, ErrNoMatch
+	}
+	end := len(rest)
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		end = i
+	}
+	for i := 1; i < end; i++ {
+		if rest[i] == '#' && isSpace(rune(rest[i-1])) {
+			end = i
+			break
+		}
+	}
+	value := strings.TrimRight(rest[:end], " \t\r")
+	return value, initial.Consume(len(value)), nil
+}
+
+// NewParsers returns a Parsers structure whose FileParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	ws := ConsumeWhile(isSpace)
+	comment := AppendSkipping(StartSkipping(Exactly("#")), ConsumeWhile(notNewline))
+	eol := OneOf(Exactly("\r\n"), Exactly("\n"), EndOfInput)
+	// end parses the rest of a line after a value: whitespace, an optional comment, and eol.
+	end := AppendSkipping(AppendSkipping(StartSkipping(ws), OneOf(comment, Succeed(Empty{}))), eol)
+
+	p.keyParser = GetString(AppendSkipping(ConsumeIf(isKeyStart), ConsumeWhile(isKeyRune)))
+
+	{
+		s := StartSkipping(Exactly("'"))
+		s1 := AppendKeeping(s, TakeUntil(Exactly("'")))
+		s2 := AppendSkipping(s1, Exactly("'"))
+		p.singleParser = Apply(s2, func(value string) string { return value })
+	}
+	p.doubleParser = double
+	p.unquotedParser = unquoted
+
+	// A blank line must consume something, so that the file's loop always makes progress.
+	p.blankParser = AppendSkipping(StartSkipping(ws), OneOf(
+		AppendSkipping(StartSkipping(comment), eol),
+		OneOf(Exactly("\r\n"), Exactly("\n")),
+	))
+
+	{
+		export := OneOf(AppendSkipping(StartSkipping(Exactly("export")), ConsumeSome(isSpace)), Succeed(Empty{}))
+		s := StartSkipping(ws)
+		s1 := AppendSkipping(s, export)
+		s2 := AppendKeeping(s1, p.keyParser)
+		s3 := AppendSkipping(s2, ws)
+		s4 := AppendSkipping(s3, Exactly("="))
+		s5 := AppendSkipping(s4, ws)
+		s6 := AppendKeeping(s5, OneOf(p.singleParser, p.doubleParser, p.unquotedParser))
+		s7 := AppendSkipping(s6, end)
+		p.assignmentParser = Apply2(s7, func(key string, value string) Field[string] {
+			return Field[string]{Name: key, Value: value}
+		})
+	}
+
+	p.FileParser = Loop[[]Field[string]](nil, func(fields []Field[string]) Parser[Step[[]Field[string], []Field[string]]] {
+		return OneOf(
+			Map(EndOfInput, func(Empty) Step[[]Field[string], []Field[string]] {
+				return Step[[]Field[string], []Field[string]]{Done: true, Value: fields}
+			}),
+			Map(p.blankParser, func(Empty) Step[[]Field[string], []Field[string]] {
+				return Step[[]Field[string], []Field[string]]{Accum: fields}
+			}),
+			Map(p.assignmentParser, func(f Field[string]) Step[[]Field[string], []Field[string]] {
+				return Step[[]Field[string], []Field[string]]{Accum: append(fields, f)}
+			}),
+		)
+	})
+
+	return p
+}
@@ -0,0 +1,322 @@
+// This package provides a Parser for a small SQL-like filter language, the kind of thing
+// found after WHERE in a query or in the filter parameter of an API:
+//
+//	status = 'active' AND (age >= 18 OR guardian IS NOT NULL) AND NOT name LIKE 'test%'
+//
+// Here is a grammar for the language:
+//
+//	expression: or
+//
+//	or:         and ('OR' and)*
+//
+//	and:        not ('AND' not)*
+//
+//	not:        'NOT' not | primary
+//
+//	primary:    '(' expression ')' | comparison
+//
+//	comparison: column operator value | column 'IS' ['NOT'] 'NULL'
+//
+//	operator:   '=' | '!=' | '<>' | '<=' | '<' | '>=' | '>' | 'LIKE'
+//
+//	column:     [a-zA-Z_][a-zA-Z0-9_.]*     -- but not a keyword
+//
+//	value:      number | string | 'TRUE' | 'FALSE' | 'NULL'
+//
+//	number:     ['-'] [0-9]+ ['.' [0-9]+]
+//
+//	string:     "'" ([^'] | "''")* "'"
+//
+// Keywords are case-insensitive, and whitespace may appear between any two tokens.  The result
+// is a tree of Expr values, which Eval can apply to a row.
+package filter
+
+import (
+	"strconv"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Expr is a node of a parsed filter: And, Or, Not or Comparison.
+type Expr interface {
+	// Eval reports whether row satisfies the expression.  Columns missing from row are NULL.
+	Eval(row map[string]any) bool
+}
+
+// And is satisfied when both its operands are.
+type And struct{ Left, Right Expr }
+
+// Or is satisfied when either of its operands is.
+type Or struct{ Left, Right Expr }
+
+// Not is satisfied when its operand isn't.
+type Not struct{ Operand Expr }
+
+// Comparison compares a column with a value.  Value is a float64, string, bool, or nil for NULL.
+type Comparison struct {
+	Column   string
+	Operator string // One of "=", "!=", "<", "<=", ">", ">=", "LIKE", "IS" and "IS NOT"; "<>" is returned as "!=".
+	Value    any
+}
+
+func (e And) Eval(row map[string]any) bool { return e.Left.Eval(row) && e.Right.Eval(row) }
+func (e Or) Eval(row map[string]any) bool  { return e.Left.Eval(row) || e.Right.Eval(row) }
+func (e Not) Eval(row map[string]any) bool { return !e.Operand.Eval(row) }
+
+func (e Comparison) Eval(row map[string]any) bool {
+	v := row[e.Column]
+	switch e.Operator {
+	case "IS":
+		return v == nil
+	case "IS NOT":
+		return v != nil
+	case "LIKE":
+		s, ok1 := v.(string)
+		pattern, ok2 := e.Value.(string)
+		return ok1 && ok2 && like(s, pattern)
+	}
+	if v == nil || e.Value == nil {
+		// As in SQL, comparisons with NULL are never true.
+		return false
+	}
+	var c int
+	switch x := v.(type) {
+	case float64:
+		y, ok := e.Value.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case x < y:
+			c = -1
+		case x > y:
+			c = 1
+		}
+	case string:
+		y, ok := e.Value.(string)
+		if !ok {
+			return false
+		}
+		c = strings.Compare(x, y)
+	case bool:
+		y, ok := e.Value.(bool)
+		if !ok || e.Operator != "=" && e.Operator != "!=" {
+			return false
+		}
+		if x != y {
+			c = 1
+		}
+	default:
+		return false
+	}
+	switch e.Operator {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// like reports whether s matches a LIKE pattern, in which "%" matches any run of characters
+// and "_" any single character.
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if like(s[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		return s != "" && like(s[1:], pattern[1:])
+	}
+	return s != "" && s[0] == pattern[0] && like(s[1:], pattern[1:])
+}
+
+// Parsers holds the parsers for the filter language.  The sole exported field is the Parser
+// for a whole filter; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	columnParser     Parser[string]
+	valueParser      Parser[any]
+	comparisonParser Parser[Expr]
+	primaryParser    Parser[Expr]
+	notParser        Parser[Expr]
+	andParser        Parser[Expr]
+	orParser         Parser[Expr]
+
+	FilterParser Parser[Expr]
+}
+
+func isIdentStart(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+}
+
+func isIdentRune(r rune) bool {
+	return isIdentStart(r) || r >= '0' && r <= '9' || r == '.'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+var ws = ConsumeWhile(isSpace)
+
+// token parses literal after any whitespace.
+func token(literal string) Parser[Empty] {
+	return AppendSkipping(StartSkipping(ws), Exactly(literal))
+}
+
+// word parses an identifier-like run of runes after any whitespace.
+var word = Apply(AppendKeeping(StartSkipping(ws),
+	GetString(AppendSkipping(ConsumeIf(isIdentStart), ConsumeWhile(isIdentRune)))),
+	func(w string) string { return w })
+
+// keyword parses a whole word which equals one of the keywords, ignoring case, and returns
+// it upper-cased.  Unlike ExactlyAnyFold, it won't match the start of a longer word.
+func keyword(keywords ...string) Parser[string] {
+	return AndThen(word, func(w string) Parser[string] {
+		for _, k := range keywords {
+			if strings.EqualFold(w, k) {
+				return Succeed(k)
+			}
+		}
+		return Fail[string]
+	})
+}
+
+var reserved = []string{"AND", "OR", "NOT", "IS", "NULL", "LIKE", "TRUE", "FALSE"}
+
+// binary returns a parser for operand (op operand)*, combining the operands left to right with combine.
+func binary(operand Parser[Expr], op string, combine func(left, right Expr) Expr) Parser[Expr] {
+	return AndThen(operand, func(first Expr) Parser[Expr] {
+		return Loop(first, func(left Expr) Parser[Step[Expr, Expr]] {
+			s := StartSkipping(keyword(op))
+			s1 := AppendKeeping(s, operand)
+			return OneOf(
+				Apply(s1, func(right Expr) Step[Expr, Expr] {
+					return Step[Expr, Expr]{Accum: combine(left, right)}
+				}),
+				Succeed(Step[Expr, Expr]{Done: true, Value: left}),
+			)
+		})
+	})
+}
+
+// NewParsers returns a Parsers structure whose FilterParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	p.columnParser = AndThen(word, func(w string) Parser[string] {
+		for _, k := range reserved {
+			if strings.EqualFold(w, k) {
+				return Fail[string]
+			}
+		}
+		return Succeed(w)
+	})
+
+	{
+		number := AndThen(
+			Apply(AppendKeeping(StartSkipping(ws), GetString(AppendSkipping(AppendSkipping(
+				OneOf(Exactly("-"), Succeed(Empty{})),
+				ConsumeSome(isDigit)),
+				OneOf(AppendSkipping(StartSkipping(Exactly(".")), ConsumeSome(isDigit)), Succeed(Empty{})))),
+			), func(text string) string { return text }),
+			func(text string) Parser[any] {
+				f, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return Fail[any]
+				}
+				return Succeed[any](f)
+			})
+		// A doubled quote inside a string stands for one quote, so a string is a run of
+		// adjacent quoted pieces.
+		pieces := Loop(0, func(n int) Parser[Step[int, Empty]] {
+			piece := Map(Quoted('\'', 0), func(Empty) Step[int, Empty] { return Step[int, Empty]{Accum: n + 1} })
+			if n == 0 {
+				return piece
+			}
+			return OneOf(piece, Succeed(Step[int, Empty]{Done: true}))
+		})
+		str := Apply(AppendKeeping(StartSkipping(ws), GetString(pieces)), func(literal string) any {
+			return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+		})
+		constant := Map(keyword("TRUE", "FALSE", "NULL"), func(k string) any {
+			switch k {
+			case "TRUE":
+				return true
+			case "FALSE":
+				return false
+			}
+			return nil
+		})
+		p.valueParser = OneOf(number, str, constant)
+	}
+
+	{
+		operator := Map(OneOf(
+			Apply(AppendKeeping(StartSkipping(ws), ExactlyAnyFold("=", "!=", "<>", "<=", "<", ">=", ">")),
+				func(op string) string { return op }),
+			keyword("LIKE"),
+		), func(op string) string {
+			if op == "<>" {
+				return "!="
+			}
+			return op
+		})
+		s := StartKeeping(p.columnParser)
+		s1 := AppendKeeping(s, operator)
+		s2 := AppendKeeping(s1, p.valueParser)
+		compare := Apply3(s2, func(column string, op string, value any) Expr {
+			return Comparison{Column: column, Operator: op, Value: value}
+		})
+		isNot := OneOf(Map(keyword("NOT"), func(string) string { return "IS NOT" }), Succeed("IS"))
+		s3 := StartKeeping(p.columnParser)
+		s4 := AppendSkipping(s3, keyword("IS"))
+		s5 := AppendKeeping(s4, isNot)
+		s6 := AppendSkipping(s5, keyword("NULL"))
+		isNull := Apply2(s6, func(column string, op string) Expr {
+			return Comparison{Column: column, Operator: op}
+		})
+		p.comparisonParser = OneOf(compare, isNull)
+	}
+
+	// The grammar is recursive, so the rules refer to each other through p, whose
+	// fields are all set by the time any of them runs.
+	expression := func(initial State) (Expr, State, error) { return p.orParser(initial) }
+	not := func(initial State) (Expr, State, error) { return p.notParser(initial) }
+
+	{
+		s := StartSkipping(token("("))
+		s1 := AppendKeeping(s, Parser[Expr](expression))
+		s2 := AppendSkipping(s1, token(")"))
+		parenthesized := Apply(s2, func(e Expr) Expr { return e })
+		p.primaryParser = OneOf(parenthesized, p.comparisonParser)
+	}
+	p.notParser = OneOf(
+		Apply(AppendKeeping(StartSkipping(keyword("NOT")), Parser[Expr](not)), func(e Expr) Expr { return Not{Operand: e} }),
+		p.primaryParser,
+	)
+	p.andParser = binary(p.notParser, "AND", func(l, r Expr) Expr { return And{Left: l, Right: r} })
+	p.orParser = binary(p.andParser, "OR", func(l, r Expr) Expr { return Or{Left: l, Right: r} })
+
+	p.FilterParser = Apply(AppendSkipping(StartKeeping(p.orParser), ws), func(e Expr) Expr { return e })
+
+	return p
+}
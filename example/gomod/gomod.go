@@ -0,0 +1,317 @@
+// This package provides a Parser for go.mod files:
+//
+//	module example.com/hello
+//
+//	go 1.21
+//
+//	require (
+//		golang.org/x/text v0.14.0
+//		rsc.io/quote v1.5.2 // indirect
+//	)
+//
+//	replace golang.org/x/text => ../text
+//
+// Here is a grammar for the directives handled:
+//
+//	file:      (blank | directive)*
+//
+//	directive: 'module' path | 'go' goversion | 'toolchain' word
+//	         | 'require' group(path version)
+//	         | 'exclude' group(path version)
+//	         | 'replace' group(path [version] '=>' (localpath | path [version]))
+//	         | 'retract' group(version | '[' version ',' version ']')
+//
+//	group(x):  x eol | '(' eol (blank | x eol)* ')' eol
+//
+//	eol:       [comment] '\n'
+//
+//	comment:   '//' .*
+//
+//	word:      [^ \t\r\n()[\]{},"]+ | '"' string '"'    -- a quoted string may use Go escapes
+//
+// Every directive but module, go and toolchain has a block form, in which the keyword is
+// followed by a parenthesized sequence of lines, each holding what would follow the keyword
+// on a line of its own.  Keywords are matched as whole words by the keyword helper, so that a
+// module path beginning "go" isn't taken for the go directive.  Module paths and versions are
+// checked with the parsers of formats/gomodule, and versions must be canonical, as the go
+// command writes them.  A comment of "indirect" on a require line marks the requirement as
+// indirect, and a comment on a retract line is its rationale.
+package gomod
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/formats/gomodule"
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A File is a parsed go.mod file.  Repeated directives accumulate in input order.
+type File struct {
+	Module    string
+	Go        string // Empty if absent.
+	Toolchain string // Empty if absent.
+	Require   []Require
+	Exclude   []ModuleVersion
+	Replace   []Replace
+	Retract   []Retract
+}
+
+// A ModuleVersion is a module path and version, e.g. "golang.org/x/text v0.14.0".
+type ModuleVersion struct {
+	Path    string
+	Version string // Empty when a replace directive leaves it out.
+}
+
+// A Require is a requirement on a module version.
+type Require struct {
+	ModuleVersion
+	Indirect bool
+}
+
+// A Replace replaces a module, or just one version of it if Old.Version is set.  New is
+// either another module version, or a directory with an empty Version.
+type Replace struct {
+	Old, New ModuleVersion
+}
+
+// A Retract withdraws the versions from Low to High, inclusive; for a single version they are
+// the same.
+type Retract struct {
+	Low, High string
+	Rationale string // The trailing comment, if any.
+}
+
+// Parsers holds the parsers for go.mod files.  The sole exported field is the Parser for a
+// whole file; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	wordParser      Parser[string]
+	pathParser      Parser[string]
+	versionParser   Parser[string]
+	goVersionParser Parser[string]
+	requireParser   Parser[[]Require]
+	excludeParser   Parser[[]ModuleVersion]
+	replaceParser   Parser[[]Replace]
+	retractParser   Parser[[]Retract]
+	directiveParser Parser[func(*File)]
+
+	FileParser Parser[File]
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isLower(r rune) bool {
+	return r >= 'a' && r <= 'z'
+}
+
+var (
+	ws  = ConsumeWhile(isSpace)
+	ws1 = ConsumeSome(isSpace)
+
+	// comment parses a comment, returning its text with the "//" and surrounding spaces removed.
+	comment = Map(GetString(AppendSkipping(StartSkipping(Exactly("//")), ConsumeWhile(func(r rune) bool { return r != '\n' }))),
+		func(text string) string { return strings.TrimSpace(strings.TrimSuffix(text[2:], "\r")) })
+
+	newline = OneOf(Exactly("\r\n"), Exactly("\n"), EndOfInput)
+
+	// eol parses the end of a line, returning the text of any comment before it.
+	eol = func() Parser[string] {
+		s := StartSkipping(ws)
+		s1 := AppendKeeping(s, OneOf(comment, Succeed("")))
+		s2 := AppendSkipping(s1, newline)
+		return Apply(s2, func(text string) string { return text })
+	}()
+
+	// blank parses a line with nothing on it but perhaps a comment.  It always consumes
+	// something, so that it can be repeated.
+	blank = AppendSkipping(StartSkipping(ws), OneOf(
+		AppendSkipping(StartSkipping(Map(comment, func(string) Empty { return Empty{} })), newline),
+		OneOf(Exactly("\r\n"), Exactly("\n")),
+	))
+)
+
+// keyword parses the word k followed by whitespace or the end of a line, so that it won't
+// match the start of a longer word.
+func keyword(k string) Parser[Empty] {
+	s := StartSkipping(ws)
+	s1 := AppendSkipping(s, Exactly(k))
+	return AppendSkipping(s1, OneOf(ws1, peek(newline)))
+}
+
+// peek returns a parser which succeeds where parser does, but consumes nothing.
+func peek[T any](parser Parser[T]) Parser[Empty] {
+	return func(initial State) (Empty, State, error) {
+		_, _, err := parser(initial)
+		if err != nil {
+			return Empty{}, initial, err
+		}
+		return Empty{}, initial, nil
+	}
+}
+
+// group returns a parser for the rest of a directive whose keyword has been parsed: either a
+// single item on the same line, or a block of them.  Each item is finished with the text of
+// its line's comment.
+func group[T any](item Parser[T], finish func(T, string) T) Parser[[]T] {
+	line := Apply2(AppendKeeping(StartKeeping(item), eol), finish)
+	single := Map(line, func(t T) []T { return []T{t} })
+	items := Loop[[]T](nil, func(ts []T) Parser[Step[[]T, []T]] {
+		return OneOf(
+			Map(AppendSkipping(AppendSkipping(StartSkipping(ws), Exactly(")")), eol), func(Empty) Step[[]T, []T] {
+				return Step[[]T, []T]{Done: true, Value: ts}
+			}),
+			Map(blank, func(Empty) Step[[]T, []T] { return Step[[]T, []T]{Accum: ts} }),
+			Apply(AppendKeeping(StartSkipping(ws), line), func(t T) Step[[]T, []T] { return Step[[]T, []T]{Accum: append(ts, t)} }),
+		)
+	})
+	block := Apply(AppendKeeping(AppendSkipping(StartSkipping(Exactly("(")), eol), items), func(ts []T) []T { return ts })
+	return OneOf(block, single)
+}
+
+// quoted parses a Go interpreted string literal, returning its value.
+var quoted = AndThen(GetString(Quoted('"', '\\')), func(literal string) Parser[string] {
+	s, err := strconv.Unquote(literal)
+	if err != nil {
+		return Fail[string]
+	}
+	return Succeed(s)
+})
+
+// NewParsers returns a Parsers structure whose FileParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	p.wordParser = OneOf(
+		quoted,
+		AndThen(TakeSome(func(r rune) bool {
+			return !isSpace(r) && !strings.ContainsRune("\r\n()[]{},\"", r)
+		}), func(w string) Parser[string] {
+			if strings.HasPrefix(w, "//") {
+				return Fail[string]
+			}
+			return Succeed(w)
+		}),
+	)
+
+	p.pathParser = Nested(p.wordParser, Map(gomodule.Module, func(path gomodule.Path) string { return path.Path }))
+
+	p.versionParser = Nested(p.wordParser, AndThen(gomodule.Semver, func(v gomodule.Version) Parser[string] {
+		if v.Short {
+			return Fail[string]
+		}
+		return Succeed(v.String())
+	}))
+
+	{
+		// A Go version is "1.N", "1.N.P", or either followed by a prerelease such as "rc1".
+		number := ConsumeSome(isDigit)
+		s := StartSkipping(number)
+		s1 := AppendSkipping(s, Exactly("."))
+		s2 := AppendSkipping(s1, number)
+		s3 := AppendSkipping(s2, OneOf(AppendSkipping(StartSkipping(Exactly(".")), number), Succeed(Empty{})))
+		s4 := AppendSkipping(s3, OneOf(AppendSkipping(StartSkipping(ConsumeSome(isLower)), number), Succeed(Empty{})))
+		p.goVersionParser = Nested(p.wordParser, GetString(s4))
+	}
+
+	spaced := func(parser Parser[string]) Parser[string] {
+		return Apply(AppendKeeping(StartSkipping(ws), parser), func(s string) string { return s })
+	}
+
+	{
+		s := StartKeeping(p.pathParser)
+		s1 := AppendKeeping(s, spaced(p.versionParser))
+		moduleVersion := Apply2(s1, func(path string, version string) ModuleVersion {
+			return ModuleVersion{Path: path, Version: version}
+		})
+		p.requireParser = group(Map(moduleVersion, func(mv ModuleVersion) Require { return Require{ModuleVersion: mv} }),
+			func(r Require, comment string) Require {
+				r.Indirect = comment == "indirect" || strings.HasPrefix(comment, "indirect;")
+				return r
+			})
+		p.excludeParser = group(moduleVersion, func(mv ModuleVersion, _ string) ModuleVersion { return mv })
+
+		optionalVersion := OneOf(spaced(p.versionParser), Succeed(""))
+		s2 := StartKeeping(p.pathParser)
+		s3 := AppendKeeping(s2, optionalVersion)
+		replaced := Apply2(s3, func(path string, version string) ModuleVersion {
+			return ModuleVersion{Path: path, Version: version}
+		})
+		local := AndThen(spaced(p.wordParser), func(dir string) Parser[ModuleVersion] {
+			if dir == "." || dir == ".." || strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") || strings.HasPrefix(dir, "/") {
+				return Succeed(ModuleVersion{Path: dir})
+			}
+			return Fail[ModuleVersion]
+		})
+		s4 := StartKeeping(replaced)
+		s5 := AppendSkipping(s4, AppendSkipping(StartSkipping(ws), Exactly("=>")))
+		s6 := AppendKeeping(s5, OneOf(local, Apply(AppendKeeping(StartSkipping(ws), replaced), func(mv ModuleVersion) ModuleVersion { return mv })))
+		p.replaceParser = group(Apply2(s6, func(old ModuleVersion, new ModuleVersion) Replace { return Replace{Old: old, New: new} }),
+			func(r Replace, _ string) Replace { return r })
+	}
+
+	{
+		s := StartSkipping(Exactly("["))
+		s1 := AppendKeeping(s, spaced(p.versionParser))
+		s2 := AppendSkipping(s1, AppendSkipping(StartSkipping(ws), Exactly(",")))
+		s3 := AppendKeeping(s2, spaced(p.versionParser))
+		s4 := AppendSkipping(s3, AppendSkipping(StartSkipping(ws), Exactly("]")))
+		interval := Apply2(s4, func(low string, high string) Retract { return Retract{Low: low, High: high} })
+		single := Map(p.versionParser, func(v string) Retract { return Retract{Low: v, High: v} })
+		p.retractParser = group(OneOf(interval, single), func(r Retract, comment string) Retract {
+			r.Rationale = comment
+			return r
+		})
+	}
+
+	{
+		// Each directive returns a function which records it in the File.
+		line := func(parser Parser[string]) Parser[string] {
+			return Apply(AppendSkipping(StartKeeping(parser), eol), func(s string) string { return s })
+		}
+		directive := func(k string, body Parser[func(*File)]) Parser[func(*File)] {
+			return Apply(AppendKeeping(StartSkipping(keyword(k)), body), func(f func(*File)) func(*File) { return f })
+		}
+		p.directiveParser = OneOf(
+			directive("module", Map(line(p.pathParser), func(path string) func(*File) {
+				return func(f *File) { f.Module = path }
+			})),
+			directive("go", Map(line(p.goVersionParser), func(v string) func(*File) {
+				return func(f *File) { f.Go = v }
+			})),
+			directive("toolchain", Map(line(p.wordParser), func(name string) func(*File) {
+				return func(f *File) { f.Toolchain = name }
+			})),
+			directive("require", Map(p.requireParser, func(rs []Require) func(*File) {
+				return func(f *File) { f.Require = append(f.Require, rs...) }
+			})),
+			directive("exclude", Map(p.excludeParser, func(mvs []ModuleVersion) func(*File) {
+				return func(f *File) { f.Exclude = append(f.Exclude, mvs...) }
+			})),
+			directive("replace", Map(p.replaceParser, func(rs []Replace) func(*File) {
+				return func(f *File) { f.Replace = append(f.Replace, rs...) }
+			})),
+			directive("retract", Map(p.retractParser, func(rs []Retract) func(*File) {
+				return func(f *File) { f.Retract = append(f.Retract, rs...) }
+			})),
+		)
+	}
+
+	p.FileParser = Loop(File{}, func(f File) Parser[Step[File, File]] {
+		return OneOf(
+			Map(EndOfInput, func(Empty) Step[File, File] { return Step[File, File]{Done: true, Value: f} }),
+			Map(blank, func(Empty) Step[File, File] { return Step[File, File]{Accum: f} }),
+			Map(p.directiveParser, func(record func(*File)) Step[File, File] {
+				record(&f)
+				return Step[File, File]{Accum: f}
+			}),
+		)
+	})
+
+	return p
+}
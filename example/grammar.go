@@ -16,6 +16,12 @@
 //	bool: "true" | "false"
 //
 //	whitespace: [ \t\n]*
+//
+// ConfigurationParser returns the bindings in the order they appear in the input.  Earlier
+// versions of this example built them up in a linked list, and so returned them in reverse.
+//
+// The format is kept here as an example of building a grammar; for a supported version of it,
+// with dialects, streaming, and Marshal and Unmarshal, see the formats/configlang package.
 package example
 
 import (
@@ -102,17 +108,13 @@ func NewConfigParser() ConfigParsers {
 		p.trueParser,
 		p.falseParser)
 
-	p.intParser = AndThen(GetString(ConsumeSome(isDecimalDigit)),
-		func(digits string) Parser[int] {
-			if len(digits) > 1 && digits[0] == '0' {
-				return Fail[int]
-			}
-			v, err := strconv.Atoi(digits)
-			if err != nil {
-				return Fail[int]
-			}
-			return Succeed(v)
-		},
+	p.intParser = MapErr(
+		Filter(TakeSome(isDecimalDigit),
+			func(digits string) bool {
+				return len(digits) == 1 || digits[0] != '0'
+			},
+			"integer without leading zeros"),
+		strconv.Atoi,
 	)
 
 	p.valueParser = OneOf(
@@ -148,45 +150,18 @@ func NewConfigParser() ConfigParsers {
 			})
 	}
 	{
-		type BindingList struct {
-			binding Binding
-			next    *BindingList
-		}
-
-		p.bindingsParser = Loop(nil,
-			func(bindings *BindingList) Parser[Step[*BindingList, []Binding]] {
-				if bindings == nil {
-					return Map(p.bindingParser,
-						func(binding Binding) Step[*BindingList, []Binding] {
-							return Step[*BindingList, []Binding]{Accum: &BindingList{binding: binding}, Done: false}
-						},
-					)
-				}
-				s := StartSkipping(p.whitespaceParser)
-				s1 := AppendSkipping(s, Exactly(","))
-				s2 := AppendSkipping(s1, p.whitespaceParser)
-				s3 := AppendKeeping(s2, p.bindingParser)
-				extend := Apply(s3, func(b Binding) Step[*BindingList, []Binding] {
-					return Step[*BindingList, []Binding]{
-						Accum: &BindingList{binding: b, next: bindings},
-						Done:  false,
-					}
-				})
-
-				var bindingSlice []Binding
-				b := bindings
-				for {
-					if b == nil {
-						break
-					}
-					bindingSlice = append(bindingSlice, b.binding)
-					b = b.next
-				}
-				return OneOf(
-					extend,
-					Succeed(Step[*BindingList, []Binding]{Value: bindingSlice, Done: true}),
-				)
-
+		s := StartSkipping(p.whitespaceParser)
+		s1 := AppendSkipping(s, Exactly(","))
+		s2 := AppendSkipping(s1, p.whitespaceParser)
+		s3 := AppendKeeping(s2, p.bindingParser)
+		another := Apply(s3, func(b Binding) Binding { return b })
+
+		p.bindingsParser = AndThen(p.bindingParser,
+			func(first Binding) Parser[[]Binding] {
+				return Fold(another, []Binding{first},
+					func(bindings []Binding, b Binding) []Binding {
+						return append(bindings, b)
+					})
 			},
 		)
 	}
@@ -0,0 +1,202 @@
+// This package provides a Parser for a subset of inline Markdown, turning text such as
+//
+//	Use **`Parse`** to run a *parser*; see [the docs](https://example.com/docs).
+//
+// into a tree of Nodes.  Here is a grammar for the subset handled:
+//
+//	inlines(stop): (inline)*                -- up to, but not including, stop
+//
+//	inline:   code | link | strong | emphasis | text
+//
+//	code:     '`'{n} .* '`'{n}              -- the same number n of backticks each end
+//
+//	link:     '[' inlines(']') ']' '(' [^)]* ')'
+//
+//	strong:   '**' inlines('**') '**'  |  '__' inlines('__') '__'
+//
+//	emphasis: '*' inlines('*') '*'  |  '_' inlines('_') '_'
+//
+//	text:     [^`[\]*_]+ | .
+//
+// Markdown has no syntax errors: anything which doesn't parse as markup is text.  That is
+// the error recovery here.  Each kind of markup is tried in turn with OneOf, and if an opening
+// delimiter is never closed, the alternatives fail and the delimiter is taken as a single rune
+// of text by the last alternative.  Code spans and link destinations are scanned with TakeUntil.
+//
+// Backtracking is controlled by where the stop delimiter is checked: inside emphasis, the
+// closing "*" is looked for before any new markup is tried, so "*a* b *c*" is two emphases
+// rather than one containing " b ".  The exception is a doubled delimiter, so that strong
+// text can appear inside emphasis.  CommonMark's finer rules about which delimiters can open
+// and close emphasis, such as ignoring underscores inside words, are not implemented.  Pathological inputs with many unclosed delimiters can take
+// a long time to parse; use WithBudget when parsing untrusted input.
+package markdown
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Node is one element of parsed inline Markdown: Text, Code, Emphasis or Link.
+type Node interface {
+	isNode()
+}
+
+// Text is plain text.  Adjacent runs of text are always merged into one Text.
+type Text struct {
+	Value string
+}
+
+// Code is a code span, with its backticks removed.
+type Code struct {
+	Value string
+}
+
+// Emphasis is emphasized text: *em* or _em_, or **strong** or __strong__.
+type Emphasis struct {
+	Strong   bool
+	Children []Node
+}
+
+// Link is a link, [text](destination).
+type Link struct {
+	Children    []Node
+	Destination string
+}
+
+func (Text) isNode()     {}
+func (Code) isNode()     {}
+func (Emphasis) isNode() {}
+func (Link) isNode()     {}
+
+func isSpecial(r rune) bool {
+	return strings.ContainsRune("`[]*_", r)
+}
+
+// peek returns a parser which succeeds where parser does, but consumes nothing.
+func peek[T any](parser Parser[T]) Parser[Empty] {
+	return func(initial State) (Empty, State, error) {
+		_, _, err := parser(initial)
+		if err != nil {
+			return Empty{}, initial, err
+		}
+		return Empty{}, initial, nil
+	}
+}
+
+// appendNode appends n to nodes, merging it into the last node if both are Text.
+func appendNode(nodes []Node, n Node) []Node {
+	if t, ok := n.(Text); ok && len(nodes) > 0 {
+		if last, ok := nodes[len(nodes)-1].(Text); ok {
+			nodes[len(nodes)-1] = Text{Value: last.Value + t.Value}
+			return nodes
+		}
+	}
+	return append(nodes, n)
+}
+
+// Parsers holds the parsers for inline Markdown.  The sole exported field is the Parser
+// for a whole paragraph of text; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	codeParser Parser[Node]
+	linkParser Parser[Node]
+	textParser Parser[Node]
+	strong     map[string]Parser[Node] // Keyed by delimiter.
+	emphasis   map[string]Parser[Node]
+
+	InlineParser Parser[[]Node]
+}
+
+// inlines returns a parser for inline nodes up to stop, which is not consumed.  Parsers
+// tried before looking for stop are in first.  The parser fails if there are no nodes
+// or stop is never found.
+func (p *Parsers) inlines(stop Parser[Empty], first ...Parser[Node]) Parser[[]Node] {
+	return Loop[[]Node](nil, func(nodes []Node) Parser[Step[[]Node, []Node]] {
+		more := func(n Node) Step[[]Node, []Node] {
+			return Step[[]Node, []Node]{Accum: appendNode(nodes, n)}
+		}
+		var steps []Parser[Step[[]Node, []Node]]
+		for _, parser := range first {
+			steps = append(steps, Map(parser, more))
+		}
+		if nodes != nil {
+			steps = append(steps, Map(peek(stop), func(Empty) Step[[]Node, []Node] {
+				return Step[[]Node, []Node]{Done: true, Value: nodes}
+			}))
+		}
+		// The markup parsers are looked up when used, since they refer to one another.
+		for _, parser := range []func(State) (Node, State, error){
+			func(s State) (Node, State, error) { return p.codeParser(s) },
+			func(s State) (Node, State, error) { return p.linkParser(s) },
+			func(s State) (Node, State, error) { return p.strong["**"](s) },
+			func(s State) (Node, State, error) { return p.strong["__"](s) },
+			func(s State) (Node, State, error) { return p.emphasis["*"](s) },
+			func(s State) (Node, State, error) { return p.emphasis["_"](s) },
+			func(s State) (Node, State, error) { return p.textParser(s) },
+		} {
+			steps = append(steps, Map(Parser[Node](parser), more))
+		}
+		return OneOf(steps...)
+	})
+}
+
+// delimited returns a parser for open, inline nodes, and close.
+func (p *Parsers) delimited(open, close string, first ...Parser[Node]) Parser[[]Node] {
+	s := StartSkipping(Exactly(open))
+	s1 := AppendKeeping(s, p.inlines(Exactly(close), first...))
+	s2 := AppendSkipping(s1, Exactly(close))
+	return Apply(s2, func(nodes []Node) []Node { return nodes })
+}
+
+// NewParsers returns a Parsers structure whose InlineParser is ready to use.
+func NewParsers() Parsers {
+	p := &Parsers{
+		strong:   make(map[string]Parser[Node]),
+		emphasis: make(map[string]Parser[Node]),
+	}
+
+	p.codeParser = AndThen(TakeSome(func(r rune) bool { return r == '`' }), func(ticks string) Parser[Node] {
+		s := StartKeeping(TakeUntil(Exactly(ticks)))
+		s1 := AppendSkipping(s, Exactly(ticks))
+		return Apply(s1, func(code string) Node {
+			if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' {
+				code = code[1 : len(code)-1]
+			}
+			return Code{Value: code}
+		})
+	})
+
+	{
+		s := StartKeeping(p.delimited("[", "]"))
+		s1 := AppendSkipping(s, Exactly("("))
+		s2 := AppendKeeping(s1, TakeUntil(Exactly(")")))
+		s3 := AppendSkipping(s2, Exactly(")"))
+		p.linkParser = Apply2(s3, func(children []Node, destination string) Node {
+			return Link{Children: children, Destination: destination}
+		})
+	}
+
+	for _, d := range []string{"*", "_"} {
+		p.strong[d+d] = Map(p.delimited(d+d, d+d), func(children []Node) Node {
+			return Emphasis{Strong: true, Children: children}
+		})
+	}
+	for _, d := range []string{"*", "_"} {
+		p.emphasis[d] = Map(p.delimited(d, d, p.strong[d+d]), func(children []Node) Node {
+			return Emphasis{Children: children}
+		})
+	}
+
+	p.textParser = Map(OneOf(
+		TakeSome(func(r rune) bool { return !isSpecial(r) }),
+		// Recovery: a delimiter which didn't open any markup is just text.
+		GetString(ConsumeIf(func(rune) bool { return true })),
+	), func(text string) Node { return Text{Value: text} })
+
+	p.InlineParser = OneOf(
+		Apply(AppendSkipping(StartKeeping(p.inlines(EndOfInput)), EndOfInput), func(nodes []Node) []Node { return nodes }),
+		Map(EndOfInput, func(Empty) []Node { return nil }),
+	)
+
+	return *p
+}
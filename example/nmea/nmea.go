@@ -0,0 +1,155 @@
+// This package provides Parsers for NMEA 0183 sentences, the line-oriented format spoken
+// by GPS receivers and other marine electronics, such as
+//
+//	$GPGLL,4916.45,N,12311.12,W,225444,A*31
+//
+// Here is a grammar for the subset handled:
+//
+//	log:        sentence*                -- one per line
+//
+//	sentence:   '$' body '*' checksum
+//
+//	body:       talker type (',' field)*
+//
+//	talker:     [A-Z]{2}
+//
+//	type:       [A-Z]{3}
+//
+//	field:      [^,*\r\n]*
+//
+//	checksum:   [0-9A-F]{2}              -- XOR of the bytes of body
+//
+// Latitudes and longitudes are written as a fixed-width field of degrees (two digits for
+// latitude, three for longitude) run together with decimal minutes, followed by a field
+// holding the hemisphere, e.g. "4916.45,N" is 49° 16.45' north.
+package nmea
+
+import (
+	"strconv"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Sentence is a single NMEA sentence, with its checksum verified.
+type Sentence struct {
+	Talker string   // Identifies the kind of device, e.g. "GP" for GPS.
+	Type   string   // Identifies the sentence format, e.g. "GLL".
+	Fields []string // The comma-separated data fields, possibly empty.
+}
+
+// Parsers holds the parsers for NMEA sentences.  The exported fields are the parsers you
+// want to pass to Parse; the unexported ones are subcomponents.
+type Parsers struct {
+	talkerParser   Parser[string]
+	typeParser     Parser[string]
+	fieldParser    Parser[string]
+	bodyParser     Parser[Sentence]
+	checksumParser Parser[byte]
+
+	SentenceParser  Parser[Sentence]   // A single sentence, without a line terminator.
+	LogParser       Parser[[]Sentence] // A whole capture, one sentence per line.
+	LatitudeParser  Parser[float64]    // "ddmm.mmmm,N" or S, as degrees, negative for south.
+	LongitudeParser Parser[float64]    // "dddmm.mmmm,E" or W, as degrees, negative for west.
+}
+
+func isUpper(r rune) bool {
+	return r >= 'A' && r <= 'Z'
+}
+
+func isDecimalDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDecimalDigit(r) || r >= 'A' && r <= 'F'
+}
+
+func isFieldRune(r rune) bool {
+	return r != ',' && r != '*' && r != '\r' && r != '\n'
+}
+
+// checksum returns the XOR of the bytes of text, as NMEA defines it.
+func checksum(text string) byte {
+	var sum byte
+	for i := 0; i < len(text); i++ {
+		sum ^= text[i]
+	}
+	return sum
+}
+
+// NewParsers returns a Parsers structure whose exported fields are ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	p.talkerParser = Within(2, TakeSome(isUpper))
+	p.typeParser = Within(3, TakeSome(isUpper))
+	p.fieldParser = TakeWhile(isFieldRune)
+
+	{
+		fields := Loop(nil, func(fields []string) Parser[Step[[]string, []string]] {
+			s := StartSkipping(Exactly(","))
+			s1 := AppendKeeping(s, p.fieldParser)
+			extend := Apply(s1, func(field string) Step[[]string, []string] {
+				return Step[[]string, []string]{Accum: append(fields, field)}
+			})
+			return OneOf(
+				extend,
+				Succeed(Step[[]string, []string]{Done: true, Value: fields}),
+			)
+		})
+		s := StartKeeping(p.talkerParser)
+		s1 := AppendKeeping(s, p.typeParser)
+		s2 := AppendKeeping(s1, fields)
+		p.bodyParser = Apply3(s2, func(talker string, kind string, fields []string) Sentence {
+			return Sentence{Talker: talker, Type: kind, Fields: fields}
+		})
+	}
+
+	p.checksumParser = AndThen(
+		Apply(AppendKeeping(StartSkipping(Exactly("*")), Within(2, TakeSome(isHexDigit))),
+			func(digits string) string { return digits }),
+		func(digits string) Parser[byte] {
+			v, _ := strconv.ParseUint(digits, 16, 8)
+			return Succeed(byte(v))
+		})
+
+	{
+		s := StartSkipping(Exactly("$"))
+		s1 := AppendKeeping(s, Checksummed(p.bodyParser, p.checksumParser, func(body string, sum byte) bool {
+			return checksum(body) == sum
+		}))
+		p.SentenceParser = Apply(s1, func(sentence Sentence) Sentence { return sentence })
+	}
+
+	p.LogParser = LinesOf(p.SentenceParser)
+
+	p.LatitudeParser = coordinate(2, "N", "S")
+	p.LongitudeParser = coordinate(3, "E", "W")
+
+	return p
+}
+
+// coordinate returns a parser for a position field with the given number of degree digits,
+// followed by a hemisphere field which is either positive or negative.
+func coordinate(degreeDigits int, positive, negative string) Parser[float64] {
+	number := func(text string) Parser[float64] {
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Fail[float64]
+		}
+		return Succeed(v)
+	}
+	degrees := AndThen(Within(degreeDigits, TakeSome(isDecimalDigit)), number)
+	minutes := AndThen(TakeSome(func(r rune) bool { return isDecimalDigit(r) || r == '.' }), number)
+	sign := OneOf(
+		Map(Exactly(positive), func(Empty) float64 { return 1 }),
+		Map(Exactly(negative), func(Empty) float64 { return -1 }),
+	)
+	s := StartKeeping(degrees)
+	s1 := AppendKeeping(s, minutes)
+	s2 := AppendSkipping(s1, Exactly(","))
+	s3 := AppendKeeping(s2, sign)
+	return Apply3(s3, func(degrees float64, minutes float64, sign float64) float64 {
+		return sign * (degrees + minutes/60)
+	})
+}
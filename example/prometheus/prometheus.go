@@ -0,0 +1,320 @@
+// This package provides Parsers for the Prometheus text exposition format, which is what a
+// /metrics endpoint serves:
+//
+//	# HELP http_requests_total The total number of HTTP requests.
+//	# TYPE http_requests_total counter
+//	http_requests_total{method="post",code="200"} 1027 1395066363000
+//	http_requests_total{method="post",code="400"}    3 1395066363000
+//
+// Here is a grammar for the format, line by line:
+//
+//	exposition: line*
+//
+//	line:       help | type | comment | sample | blank
+//
+//	help:       '#' [ \t]+ 'HELP' [ \t]+ name [ \t]+ docstring
+//
+//	type:       '#' [ \t]+ 'TYPE' [ \t]+ name [ \t]+ metrictype
+//
+//	comment:    '#' .*
+//
+//	sample:     name ['{' labels [','] '}'] [ \t]+ value [[ \t]+ timestamp] [ \t]*
+//
+//	name:       [a-zA-Z_:][a-zA-Z0-9_:]*
+//
+//	labels:     as for labels.Prometheus
+//
+//	value:      a float, as for strconv.ParseFloat, including "NaN", "+Inf" and "-Inf"
+//
+//	timestamp:  ['-'] [0-9]+            -- milliseconds since the epoch
+//
+//	metrictype: 'counter' | 'gauge' | 'histogram' | 'summary' | 'untyped'
+//
+// In a docstring, "\\" stands for a backslash and "\n" for a newline.  Samples are gathered into
+// a Family with the HELP and TYPE lines before them; a histogram or summary family also takes the
+// samples whose names add "_bucket", "_sum" or "_count" to its name.
+//
+// Expositions can be large, so as well as ExpositionParser, which parses a whole exposition held
+// in a string, there is Stream, which reads one from an io.Reader a line at a time.
+package prometheus
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/formats/labels"
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Sample is a single value of a metric.
+type Sample struct {
+	Name      string
+	Labels    labels.Labels
+	Value     float64
+	Timestamp int64 // Milliseconds since the epoch; zero if absent.
+}
+
+// A Family is a metric's HELP and TYPE and its samples, in input order.
+type Family struct {
+	Name    string
+	Help    string // Empty if absent.
+	Type    string // "untyped" if absent.
+	Samples []Sample
+}
+
+// line is the parsed form of one line of an exposition; sample, help and typ are mutually
+// exclusive, and a line with none of them is blank or a comment.
+type line struct {
+	name   string
+	sample *Sample
+	help   *string
+	typ    *string
+}
+
+// Parsers holds the parsers for the exposition format.  The exported fields are the parsers you
+// want to pass to Parse; the unexported ones are subcomponents.
+type Parsers struct {
+	nameParser    Parser[string]
+	labelsParser  Parser[labels.Labels]
+	valueParser   Parser[float64]
+	helpParser    Parser[line]
+	typeParser    Parser[line]
+	commentParser Parser[line]
+	lineParser    Parser[line]
+
+	SampleParser     Parser[Sample]   // A single sample line, without a line terminator.
+	ExpositionParser Parser[[]Family] // A whole exposition.
+}
+
+func isNameStart(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' || r == ':'
+}
+
+func isNameRune(r rune) bool {
+	return isNameStart(r) || r >= '0' && r <= '9'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func notSpace(r rune) bool {
+	return !isSpace(r)
+}
+
+func anyRune(rune) bool {
+	return true
+}
+
+var types = []string{"counter", "gauge", "histogram", "summary", "untyped"}
+
+// NewParsers returns a Parsers structure whose exported fields are ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	ws := ConsumeWhile(isSpace)
+	ws1 := ConsumeSome(isSpace)
+
+	p.nameParser = GetString(AppendSkipping(ConsumeIf(isNameStart), ConsumeWhile(isNameRune)))
+
+	{
+		s := StartSkipping(Exactly("{"))
+		s1 := AppendKeeping(s, labels.New(labels.Prometheus))
+		s2 := AppendSkipping(s1, OneOf(Exactly(","), Succeed(Empty{})))
+		s3 := AppendSkipping(s2, ws)
+		s4 := AppendSkipping(s3, Exactly("}"))
+		p.labelsParser = Apply(s4, func(l labels.Labels) labels.Labels { return l })
+	}
+
+	p.valueParser = AndThen(TakeSome(notSpace), func(text string) Parser[float64] {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Fail[float64]
+		}
+		return Succeed(f)
+	})
+
+	{
+		timestamp := AndThen(
+			GetString(AppendSkipping(OneOf(Exactly("-"), Succeed(Empty{})), ConsumeSome(isDigit))),
+			func(text string) Parser[int64] {
+				ms, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return Fail[int64]
+				}
+				return Succeed(ms)
+			})
+		s := StartKeeping(p.nameParser)
+		s1 := AppendKeeping(s, OneOf(p.labelsParser, Succeed(labels.Labels(nil))))
+		series := Apply2(s1, func(name string, l labels.Labels) Sample { return Sample{Name: name, Labels: l} })
+		s2 := StartKeeping(series)
+		s3 := AppendSkipping(s2, ws1)
+		s4 := AppendKeeping(s3, p.valueParser)
+		s5 := AppendKeeping(s4, OneOf(Apply(AppendKeeping(StartSkipping(ws1), timestamp), func(ms int64) int64 { return ms }), Succeed(int64(0))))
+		s6 := AppendSkipping(s5, ws)
+		p.SampleParser = Apply3(s6, func(sample Sample, value float64, ms int64) Sample {
+			sample.Value, sample.Timestamp = value, ms
+			return sample
+		})
+	}
+
+	// keywordLine returns a parser for "# keyword name text", with text parsed by parser.
+	keywordLine := func(keyword string, parser Parser[string]) Parser[line] {
+		s := StartSkipping(Exactly("#"))
+		s1 := AppendSkipping(s, ws1)
+		s2 := AppendSkipping(s1, Exactly(keyword))
+		s3 := AppendSkipping(s2, ws1)
+		s4 := AppendKeeping(s3, p.nameParser)
+		s5 := AppendKeeping(s4, OneOf(Apply(AppendKeeping(StartSkipping(ws1), parser), func(text string) string { return text }), Succeed("")))
+		return Apply2(s5, func(name string, text string) line {
+			if keyword == "HELP" {
+				return line{name: name, help: &text}
+			}
+			return line{name: name, typ: &text}
+		})
+	}
+
+	docstring := Map(TakeWhile(anyRune), func(text string) string {
+		return strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(text)
+	})
+	p.helpParser = keywordLine("HELP", docstring)
+	metricType := AndThen(TakeSome(notSpace), func(text string) Parser[string] {
+		for _, t := range types {
+			if text == t {
+				return Succeed(t)
+			}
+		}
+		return Fail[string]
+	})
+	p.typeParser = Apply(AppendSkipping(StartKeeping(keywordLine("TYPE", metricType)), ws), func(l line) line { return l })
+
+	p.commentParser = Map(OneOf(
+		AppendSkipping(StartSkipping(Exactly("#")), ConsumeWhile(anyRune)),
+		AppendSkipping(StartSkipping(ws), EndOfInput),
+	), func(Empty) line { return line{} })
+
+	p.lineParser = OneOf(
+		Map(p.SampleParser, func(sample Sample) line { return line{name: sample.Name, sample: &sample} }),
+		p.helpParser,
+		p.typeParser,
+		p.commentParser,
+	)
+
+	p.ExpositionParser = Map(LinesOf(p.lineParser), func(lines []line) []Family {
+		var b builder
+		var families []Family
+		for _, l := range lines {
+			if f, ok := b.add(l); ok {
+				families = append(families, f)
+			}
+		}
+		if f, ok := b.flush(); ok {
+			families = append(families, f)
+		}
+		return families
+	})
+
+	return p
+}
+
+// Stream reads an exposition from r a line at a time, calling f with each Family as soon as it
+// is complete, which is when the next one starts or the input ends.  It stops at the first error
+// from r, a line that doesn't parse, or f, and returns it.  A line that doesn't parse gives a
+// *ParseError whose Line is the line's number in r, and whose Offset is within the line.
+func (p Parsers) Stream(r io.Reader, f func(Family) error) error {
+	var b builder
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		l, err := Parse(p.lineParser, scanner.Text())
+		if err != nil {
+			var parseErr *ParseError
+			if errors.As(err, &parseErr) {
+				parseErr.Line = lineNo
+			}
+			return err
+		}
+		if family, ok := b.add(l); ok {
+			if err := f(family); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if family, ok := b.flush(); ok {
+		return f(family)
+	}
+	return nil
+}
+
+// builder gathers lines into families.
+type builder struct {
+	current Family
+	started bool
+}
+
+// add adds l to the current family, or starts a new one with it.  When it starts a new one,
+// it returns the old one and true.
+func (b *builder) add(l line) (Family, bool) {
+	if l.sample == nil && l.help == nil && l.typ == nil {
+		return Family{}, false
+	}
+	var done Family
+	ok := false
+	if !b.started || !b.takes(l) {
+		done, ok = b.flush()
+		b.current = Family{Name: l.name, Type: "untyped"}
+		b.started = true
+	}
+	switch {
+	case l.sample != nil:
+		b.current.Samples = append(b.current.Samples, *l.sample)
+	case l.help != nil:
+		b.current.Help = *l.help
+	default:
+		b.current.Type = *l.typ
+	}
+	return done, ok
+}
+
+// takes reports whether l belongs to the current family.  HELP and TYPE lines only do before
+// any samples, and only once each.
+func (b *builder) takes(l line) bool {
+	f := &b.current
+	switch {
+	case l.help != nil:
+		return l.name == f.Name && f.Help == "" && f.Samples == nil
+	case l.typ != nil:
+		return l.name == f.Name && f.Type == "untyped" && f.Samples == nil
+	}
+	if l.name == f.Name {
+		return true
+	}
+	if f.Type != "histogram" && f.Type != "summary" {
+		return false
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if l.name == f.Name+suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// flush returns the current family and true, if there is one, and forgets it.
+func (b *builder) flush() (Family, bool) {
+	if !b.started {
+		return Family{}, false
+	}
+	f := b.current
+	b.current, b.started = Family{}, false
+	return f, true
+}
@@ -0,0 +1,188 @@
+// This package provides a Parser for robots.txt files, as described by RFC 9309:
+//
+//	# Keep everyone out of /private, but let the archiver in.
+//	User-agent: *
+//	Disallow: /private/
+//
+//	User-agent: archiver
+//	Allow: /
+//
+//	Sitemap: https://example.com/sitemap.xml
+//
+// Here is a grammar for the format, line by line:
+//
+//	file:      [BOM] line*          -- a leading U+FEFF is ignored
+//
+//	line:      blank | comment | directive | invalid
+//
+//	comment:   [ \t]* '#' .*
+//
+//	directive: [ \t]* key [ \t]* ':' [ \t]* value [ \t]* ['#' .*]
+//
+//	key:       [a-zA-Z-]+               -- case-insensitive
+//
+//	value:     [^#]*
+//
+// Real robots.txt files are messy, and crawlers are expected to be forgiving, so this parser
+// never fails: a line which isn't a directive is recorded in Robots.Invalid and otherwise
+// ignored, and directives other than the ones below are collected in Robots.Other.  The
+// recognized directives are user-agent, allow and disallow (RFC 9309), plus crawl-delay and
+// sitemap, which are widely used extensions.  The common misspelling "useragent" and the
+// forms "user agent" and "dissallow" are accepted too.
+package robots
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Robots is a parsed robots.txt file.
+type Robots struct {
+	Groups   []Group
+	Sitemaps []string
+	Other    []Directive // Directives this package doesn't interpret, in input order.
+	Invalid  []int       // Line numbers of lines which couldn't be parsed, or weren't in any group.
+}
+
+// A Group is the rules for one or more user agents.
+type Group struct {
+	UserAgents []string
+	Rules      []PathRule
+	CrawlDelay string // As written, since its units and syntax vary; empty if not given.
+}
+
+// A PathRule allows or disallows a path prefix.  Paths may contain the "*" and "$" wildcards of
+// RFC 9309, which are left for the caller to interpret.
+type PathRule struct {
+	Allow bool
+	Path  string
+}
+
+// A Directive is a single "key: value" line.
+type Directive struct {
+	Line  int
+	Key   string // Lower-cased.
+	Value string
+}
+
+// line is the parsed form of one line of a robots.txt file.
+type line struct {
+	directive Directive // Key is empty for a blank or comment line.
+	invalid   bool
+}
+
+// Parsers holds the parsers for robots.txt files.  The sole exported field is the Parser
+// for a whole file; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	directiveParser Parser[line]
+	ignoredParser   Parser[line]
+	invalidParser   Parser[line]
+	lineParser      Parser[line]
+
+	RobotsParser Parser[Robots]
+}
+
+func isKeyRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-' || r == ' '
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func anyRune(rune) bool {
+	return true
+}
+
+// aliases maps the accepted spellings of keys to the standard ones.
+var aliases = map[string]string{
+	"useragent":  "user-agent",
+	"user agent": "user-agent",
+	"dissallow":  "disallow",
+}
+
+// NewParsers returns a Parsers structure whose RobotsParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	ws := ConsumeWhile(isSpace)
+	comment := AppendSkipping(StartSkipping(Exactly("#")), ConsumeWhile(anyRune))
+
+	{
+		s := StartSkipping(ws)
+		s1 := AppendKeeping(s, TakeSome(isKeyRune))
+		s2 := AppendSkipping(s1, Exactly(":"))
+		s3 := AppendSkipping(s2, ws)
+		s4 := AppendKeeping(s3, TakeWhile(func(r rune) bool { return r != '#' }))
+		s5 := AppendSkipping(s4, OneOf(comment, Succeed(Empty{})))
+		p.directiveParser = Apply2(s5, func(key string, value string) line {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if alias, ok := aliases[key]; ok {
+				key = alias
+			}
+			return line{directive: Directive{Key: key, Value: strings.TrimSpace(value)}}
+		})
+	}
+
+	p.ignoredParser = Map(AppendSkipping(StartSkipping(ws), OneOf(comment, EndOfInput)), func(Empty) line {
+		return line{}
+	})
+
+	p.invalidParser = Map(ConsumeWhile(anyRune), func(Empty) line {
+		return line{invalid: true}
+	})
+
+	p.lineParser = OneOf(p.directiveParser, p.ignoredParser, p.invalidParser)
+
+	{
+		bom := OneOf(Exactly("\uFEFF"), Succeed(Empty{}))
+		s := StartSkipping(bom)
+		s1 := AppendKeeping(s, LinesOf(p.lineParser))
+		p.RobotsParser = Apply(s1, group)
+	}
+
+	return p
+}
+
+// group assembles the lines of a file into a Robots.  A user-agent line following a rule
+// starts a new group; consecutive user-agent lines share one.
+func group(lines []line) Robots {
+	var r Robots
+	var current *Group
+	inRules := false
+	for i, l := range lines {
+		d := l.directive
+		d.Line = i + 1
+		switch {
+		case l.invalid:
+			r.Invalid = append(r.Invalid, d.Line)
+		case d.Key == "":
+			// A blank or comment line.
+		case d.Key == "user-agent":
+			if current == nil || inRules {
+				r.Groups = append(r.Groups, Group{})
+				current = &r.Groups[len(r.Groups)-1]
+				inRules = false
+			}
+			current.UserAgents = append(current.UserAgents, d.Value)
+		case d.Key == "allow" || d.Key == "disallow" || d.Key == "crawl-delay":
+			if current == nil {
+				// A rule before any user-agent line applies to nobody.
+				r.Invalid = append(r.Invalid, d.Line)
+				continue
+			}
+			inRules = true
+			if d.Key == "crawl-delay" {
+				current.CrawlDelay = d.Value
+			} else {
+				current.Rules = append(current.Rules, PathRule{Allow: d.Key == "allow", Path: d.Value})
+			}
+		case d.Key == "sitemap":
+			r.Sitemaps = append(r.Sitemaps, d.Value)
+		default:
+			r.Other = append(r.Other, d)
+		}
+	}
+	return r
+}
@@ -0,0 +1,441 @@
+// This package provides a Parser for a small search-query language, the kind of thing typed
+// into the search box of an issue tracker or code host:
+//
+//	is:open label:"good first issue" (parser OR lexer) -draft stars:>=10
+//
+// Here is a grammar for the language:
+//
+//	query:      expression*
+//
+//	expression: unary (infix unary)*        -- by precedence, as below
+//
+//	infix:      'OR' | 'AND' | ''           -- '' is juxtaposition, an implicit AND
+//
+//	unary:      '-' unary | 'NOT' unary | '(' expression* ')' | term
+//
+//	term:       field ':' [op] value | value
+//
+//	field:      [A-Za-z_][A-Za-z0-9_.-]*
+//
+//	op:         '>=' | '<=' | '>' | '<'
+//
+//	value:      phrase | word
+//
+//	phrase:     '"' ([^"\\] | '\\' .)* '"'
+//
+//	word:       [^\s()"]+                   -- but not 'AND', 'OR' or 'NOT'
+//
+// Whitespace may appear between any two tokens, except after '-' and around the ':' of a term.
+// The keywords must be upper case, as in Lucene; "and" is just a word, and a quoted "AND" is a
+// phrase.  Negation binds tightest, then AND, whether written or implied, then OR, so
+// "a b OR c" means "(a AND b) OR c".  The expression rule is parsed by a Pratt parser, driven by
+// the binding powers of the infix operators in a table, which makes new operators easy to add.
+//
+// Queries are typed by people, so the parser never fails.  Instead it recovers from mistakes,
+// keeping as much of the query as it can make sense of, and reports each mistake as a Problem:
+//
+//   - an operator with a missing operand, as in "a OR" or "AND b", is dropped;
+//   - an unmatched ")" is dropped, and a missing one is assumed at the end of the query;
+//   - an unterminated phrase runs to the end of the query.
+package search
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Node is a node of a parsed query: And, Or, Not or Term.
+type Node interface {
+	// String returns the node as a fully parenthesized query.
+	String() string
+}
+
+// And matches what both its operands match.
+type And struct{ Left, Right Node }
+
+// Or matches what either of its operands matches.
+type Or struct{ Left, Right Node }
+
+// Not matches what its operand doesn't.
+type Not struct{ Operand Node }
+
+// Term is a single search term, such as "parser", "is:open" or "stars:>=10".
+type Term struct {
+	Field  string // Empty for a term without a field.
+	Op     string // One of ">=", "<=", ">" and "<", or empty.
+	Value  string // With a phrase's quotes and escapes removed.
+	Phrase bool   // Whether the value was quoted.
+	Span   Span   // The whole term, including any field.
+}
+
+func (n And) String() string { return "(" + n.Left.String() + " AND " + n.Right.String() + ")" }
+func (n Or) String() string  { return "(" + n.Left.String() + " OR " + n.Right.String() + ")" }
+func (n Not) String() string { return "-" + n.Operand.String() }
+
+func (t Term) String() string {
+	v := t.Value
+	if t.Phrase || v == "" {
+		v = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	}
+	if t.Field == "" {
+		return v
+	}
+	return t.Field + ":" + t.Op + v
+}
+
+// Int returns the term's value as an integer, and whether it is one.
+func (t Term) Int() (int64, bool) {
+	n, err := strconv.ParseInt(t.Value, 10, 64)
+	return n, err == nil
+}
+
+// Float returns the term's value as a number, and whether it is one.
+func (t Term) Float() (float64, bool) {
+	f, err := strconv.ParseFloat(t.Value, 64)
+	return f, err == nil
+}
+
+// Date returns the term's value as a date written YYYY-MM-DD, and whether it is one.
+func (t Term) Date() (time.Time, bool) {
+	d, err := time.Parse("2006-01-02", t.Value)
+	return d, err == nil
+}
+
+// Problem is a mistake in a query, which the parser recovered from.
+type Problem struct {
+	Span    Span
+	Message string
+}
+
+// Query is a parsed query.
+type Query struct {
+	Root     Node      // Nil if the query is empty, or nothing could be made of it.
+	Problems []Problem // In input order.
+}
+
+// Terms returns the terms of the query with the field, in input order, including negated ones.
+func (q Query) Terms(field string) []Term {
+	var terms []Term
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case And:
+			walk(n.Left)
+			walk(n.Right)
+		case Or:
+			walk(n.Left)
+			walk(n.Right)
+		case Not:
+			walk(n.Operand)
+		case Term:
+			if n.Field == field {
+				terms = append(terms, n)
+			}
+		}
+	}
+	walk(q.Root)
+	return terms
+}
+
+// invalid marks a mistake in the parsed tree.  Once parsing is done, clean replaces it with the
+// node to keep in its place, if any, and reports it as a Problem.
+type invalid struct {
+	span    Span
+	message string
+	node    Node
+}
+
+func (n invalid) String() string {
+	if n.node == nil {
+		return ""
+	}
+	return n.node.String()
+}
+
+// clean returns n with its invalid nodes removed, appending a Problem for each to problems.
+// The operator of a removed operand is removed too.
+func clean(n Node, problems *[]Problem) Node {
+	switch n := n.(type) {
+	case And:
+		left, right := clean(n.Left, problems), clean(n.Right, problems)
+		if left == nil || right == nil {
+			return either(left, right)
+		}
+		return And{Left: left, Right: right}
+	case Or:
+		left, right := clean(n.Left, problems), clean(n.Right, problems)
+		if left == nil || right == nil {
+			return either(left, right)
+		}
+		return Or{Left: left, Right: right}
+	case Not:
+		operand := clean(n.Operand, problems)
+		if operand == nil {
+			return nil
+		}
+		return Not{Operand: operand}
+	case invalid:
+		*problems = append(*problems, Problem{Span: n.span, Message: n.message})
+		if n.node == nil {
+			return nil
+		}
+		return clean(n.node, problems)
+	}
+	return n
+}
+
+// either returns whichever of left and right isn't nil, or nil.
+func either(left, right Node) Node {
+	if left == nil {
+		return right
+	}
+	return left
+}
+
+// Parsers holds the parsers for the search-query language.  The sole exported field is the
+// Parser for a whole query; the unexported fields contain subcomponent parsers.
+type Parsers struct {
+	fieldParser      Parser[string]
+	valueParser      Parser[value]
+	termParser       Parser[Node]
+	groupParser      Parser[Node]
+	unaryParser      Parser[Node]
+	expressionParser Parser[Node]
+
+	QueryParser Parser[Query]
+}
+
+// value is the value of a term.  An unterminated phrase is open.
+type value struct {
+	text         string
+	phrase, open bool
+}
+
+func isFieldStart(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+}
+
+func isFieldRune(r rune) bool {
+	return isFieldStart(r) || r >= '0' && r <= '9' || r == '.' || r == '-'
+}
+
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && r != '(' && r != ')' && r != '"'
+}
+
+var ws = ConsumeWhile(unicode.IsSpace)
+
+var word = TakeSome(isWordRune)
+
+// reserved parses a word which is one of the words.
+func reserved(words ...string) Parser[string] {
+	return AndThen(word, func(w string) Parser[string] {
+		for _, k := range words {
+			if w == k {
+				return Succeed(w)
+			}
+		}
+		return Fail[string]
+	})
+}
+
+// keyword parses the keyword k after any whitespace.
+func keyword(k string) Parser[string] {
+	return Apply(AppendKeeping(StartSkipping(ws), reserved(k)), func(k string) string { return k })
+}
+
+var keywords = []string{"AND", "OR", "NOT"}
+
+// phrase parses a quoted phrase, running to the end of the input if the closing quote is missing.
+func phrase(initial State) (value, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return value{}, initial, ErrNoMatch
+	}
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '"':
+			return value{text: b.String(), phrase: true}, initial.Consume(i + 1), nil
+		case c == '\\' && i+1 < len(rest):
+			i++
+			b.WriteByte(rest[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return value{text: b.String(), phrase: true, open: true}, initial.Consume(len(rest)), nil
+}
+
+// missing returns a parser which consumes nothing and gives an invalid node reporting message.
+func missing(message string) Parser[Node] {
+	return Map(WithSpan(Succeed(Empty{})), func(s Spanned[Empty]) Node {
+		return invalid{span: s.Span, message: message}
+	})
+}
+
+// infix is an infix operator of a Pratt parser.
+type infix struct {
+	power   int            // The binding power; operators with more bind more tightly.
+	op      Parser[string] // Parses the operator.
+	missing string         // Reports a missing right operand; if empty, the operator doesn't match without one.
+	combine func(left, right Node) Node
+}
+
+// pratt returns a Pratt parser for operands combined by infixes, left-associatively, where
+// only operators binding at least as tightly as power may appear outside parentheses.
+func pratt(operand Parser[Node], infixes []infix, power int) Parser[Node] {
+	return AndThen(operand, func(first Node) Parser[Node] {
+		return Loop(first, func(left Node) Parser[Step[Node, Node]] {
+			var steps []Parser[Step[Node, Node]]
+			for _, in := range infixes {
+				if in.power < power {
+					continue
+				}
+				in := in
+				right := pratt(operand, infixes, in.power+1)
+				if in.missing != "" {
+					right = OneOf(right, missing(in.missing))
+				}
+				s := StartSkipping(in.op)
+				s1 := AppendKeeping(s, right)
+				steps = append(steps, Apply(s1, func(right Node) Step[Node, Node] {
+					return Step[Node, Node]{Accum: in.combine(left, right)}
+				}))
+			}
+			steps = append(steps, Succeed(Step[Node, Node]{Done: true, Value: left}))
+			return OneOf(steps...)
+		})
+	})
+}
+
+// sequence returns a parser for a run of expressions, each implicitly ANDed with those before,
+// together with the stray operators, and at the top level the stray ")"s, that come between
+// them.  It gives nil if there is nothing but stray tokens, or nothing at all.
+func sequence(expression Parser[Node], top bool) Parser[Node] {
+	and := func(acc, n Node) Step[Node, Node] {
+		if acc == nil {
+			return Step[Node, Node]{Accum: n}
+		}
+		return Step[Node, Node]{Accum: And{Left: acc, Right: n}}
+	}
+	stray := func(token Parser[string], message func(string) string) Parser[Node] {
+		span := Apply(AppendKeeping(StartSkipping(ws), WithSpan(token)), func(s Spanned[string]) Spanned[string] { return s })
+		return Map(span, func(s Spanned[string]) Node {
+			return invalid{span: s.Span, message: message(s.Value)}
+		})
+	}
+	operator := stray(reserved("AND", "OR"), func(op string) string { return "missing operand before " + op })
+	paren := stray(Map(Exactly(")"), func(Empty) string { return ")" }), func(string) string { return `unmatched ")"` })
+	return Loop[Node](nil, func(acc Node) Parser[Step[Node, Node]] {
+		steps := []Parser[Step[Node, Node]]{
+			Map(expression, func(n Node) Step[Node, Node] { return and(acc, n) }),
+			Map(operator, func(n Node) Step[Node, Node] { return and(acc, n) }),
+		}
+		if top {
+			steps = append(steps, Map(paren, func(n Node) Step[Node, Node] { return and(acc, n) }))
+		}
+		steps = append(steps, Succeed(Step[Node, Node]{Done: true, Value: acc}))
+		return OneOf(steps...)
+	})
+}
+
+// NewParsers returns a Parsers structure whose QueryParser is ready to use.
+func NewParsers() Parsers {
+	var p Parsers
+
+	// The grammar is recursive, so the rules refer to each other through p, whose
+	// fields are all set by the time any of them runs.
+	unary := Parser[Node](func(initial State) (Node, State, error) { return p.unaryParser(initial) })
+	expression := Parser[Node](func(initial State) (Node, State, error) { return p.expressionParser(initial) })
+
+	p.fieldParser = GetString(AppendSkipping(ConsumeIf(isFieldStart), ConsumeWhile(isFieldRune)))
+
+	p.valueParser = OneOf(
+		Parser[value](phrase),
+		AndThen(word, func(w string) Parser[value] {
+			for _, k := range keywords {
+				if w == k {
+					return Fail[value]
+				}
+			}
+			return Succeed(value{text: w})
+		}),
+	)
+
+	{
+		op := OneOf(ExactlyAnyFold(">=", "<=", ">", "<"), Succeed(""))
+		s := StartKeeping(p.fieldParser)
+		s1 := AppendSkipping(s, Exactly(":"))
+		s2 := AppendKeeping(s1, op)
+		s3 := AppendKeeping(s2, p.valueParser)
+		type term struct {
+			Term
+			open bool
+		}
+		fielded := Apply3(s3, func(field string, op string, v value) term {
+			return term{Term{Field: field, Op: op, Value: v.text, Phrase: v.phrase}, v.open}
+		})
+		plain := Map(p.valueParser, func(v value) term {
+			return term{Term{Value: v.text, Phrase: v.phrase}, v.open}
+		})
+		p.termParser = Map(WithSpan(OneOf(fielded, plain)), func(t Spanned[term]) Node {
+			t.Value.Span = t.Span
+			if t.Value.open {
+				return invalid{span: t.Span, message: "unterminated phrase", node: t.Value.Term}
+			}
+			return t.Value.Term
+		})
+	}
+
+	{
+		closing := OneOf(Map(AppendSkipping(StartSkipping(ws), Exactly(")")), func(Empty) bool { return true }), Succeed(false))
+		s := StartSkipping(Exactly("("))
+		s1 := AppendKeeping(s, sequence(expression, false))
+		s2 := AppendKeeping(s1, closing)
+		p.groupParser = ApplySpanned2(s2, func(span Span, n Node, closed bool) Node {
+			switch {
+			case !closed:
+				return invalid{span: Span{Start: span.Start, End: span.Start + 1}, message: `missing ")"`, node: n}
+			case n == nil:
+				return invalid{span: span, message: "empty parentheses"}
+			}
+			return n
+		})
+	}
+
+	{
+		negated := func(n Node) Node { return Not{Operand: n} }
+		minus := Apply(AppendKeeping(StartSkipping(Exactly("-")), unary), negated)
+		operand := OneOf(Apply(AppendKeeping(StartSkipping(ws), unary), func(n Node) Node { return n }), missing("missing operand after NOT"))
+		not := Apply(AppendKeeping(StartSkipping(reserved("NOT")), operand), negated)
+		p.unaryParser = OneOf(minus, not, p.groupParser, p.termParser)
+	}
+
+	{
+		infixes := []infix{
+			{power: 1, op: keyword("OR"), missing: "missing operand after OR",
+				combine: func(left, right Node) Node { return Or{Left: left, Right: right} }},
+			{power: 2, op: keyword("AND"), missing: "missing operand after AND",
+				combine: func(left, right Node) Node { return And{Left: left, Right: right} }},
+			{power: 2, op: Succeed(""),
+				combine: func(left, right Node) Node { return And{Left: left, Right: right} }},
+		}
+		operand := Apply(AppendKeeping(StartSkipping(ws), unary), func(n Node) Node { return n })
+		p.expressionParser = pratt(operand, infixes, 0)
+	}
+
+	s := StartKeeping(sequence(expression, true))
+	s1 := AppendSkipping(s, ws)
+	p.QueryParser = Apply(s1, func(root Node) Query {
+		var q Query
+		q.Root = clean(root, &q.Problems)
+		return q
+	})
+
+	return p
+}
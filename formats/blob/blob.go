@@ -0,0 +1,122 @@
+// Package blob provides parsers which recognize binary data embedded in textual formats as
+// hexadecimal or base64, and decode it to []byte.
+package blob
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Problems reported in an *Error.
+var (
+	ErrIllegalCharacter = errors.New("illegal character")        // A character that can't appear at that point in the blob.
+	ErrBadPadding       = errors.New("bad padding")              // Missing, misplaced or superfluous "=" padding.
+	ErrOddLength        = errors.New("odd number of hex digits") // A hex blob that doesn't make whole bytes.
+)
+
+// Error is the error returned for a malformed blob.
+type Error struct {
+	Offset int   // Byte offset of the problem from the start of the input.
+	Err    error // One of ErrIllegalCharacter, ErrBadPadding and ErrOddLength.
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func isHexDigit(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+// Hex is a Parser[[]byte] for a run of hexadecimal digits, in either case, two per byte.
+// It fails with ErrNoMatch if there are no digits, and with an *Error if there is an odd number.
+var Hex Parser[[]byte] = func(initial State) ([]byte, State, error) {
+	digits, next, err := TakeSome(isHexDigit)(initial)
+	if err != nil {
+		return nil, initial, err
+	}
+	if len(digits)%2 != 0 {
+		return nil, initial, &Error{Offset: next.Offset(), Err: ErrOddLength}
+	}
+	b := make([]byte, len(digits)/2)
+	for i := range b {
+		b[i] = unhex(digits[2*i])<<4 | unhex(digits[2*i+1])
+	}
+	return b, next, nil
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+	return c - '0'
+}
+
+// Encoding selects one of the base64 variants of RFC 4648 for Base64.
+type Encoding int
+
+const (
+	Std    Encoding = iota // Standard alphabet, with "=" padding.
+	URL                    // URL- and filename-safe alphabet, with "=" padding.
+	RawStd                 // Standard alphabet, without padding.
+	RawURL                 // URL- and filename-safe alphabet, without padding.
+)
+
+// Base64 returns a Parser[[]byte] for a run of base64 text in the given encoding.  The run ends
+// at the first character outside the encoding's alphabet, after any padding.  The parser fails
+// with ErrNoMatch if the run is empty, and with an *Error if the run doesn't decode.  When the run
+// is cut short by a character from another base64 variant's alphabet, that character is reported as
+// illegal; otherwise a run of the wrong length is reported as bad padding.  Unused bits in the
+// final character must be zero, and a final character which breaks that rule is reported as illegal.
+func Base64(encoding Encoding) Parser[[]byte] {
+	alphabet := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	enc, foreign := base64.StdEncoding, "-_"
+	switch encoding {
+	case URL:
+		alphabet, enc, foreign = alphabet[:62]+"-_", base64.URLEncoding, "+/"
+	case RawStd:
+		enc, foreign = base64.RawStdEncoding, "-_="
+	case RawURL:
+		alphabet, enc, foreign = alphabet[:62]+"-_", base64.RawURLEncoding, "+/="
+	}
+	enc = enc.Strict()
+	padded := encoding == Std || encoding == URL
+	inAlphabet := func(r rune) bool {
+		return r < utf8.RuneSelf && strings.IndexByte(alphabet, byte(r)) >= 0
+	}
+	isPadding := func(r rune) bool {
+		return padded && r == '='
+	}
+	run := GetString(AppendSkipping(ConsumeSome(inAlphabet), ConsumeWhile(isPadding)))
+	return func(initial State) ([]byte, State, error) {
+		text, next, err := run(initial)
+		if err != nil {
+			return nil, initial, err
+		}
+		b, err := enc.DecodeString(text)
+		if err == nil {
+			return b, next, nil
+		}
+		n := len(strings.TrimRight(text, "="))
+		wellFormed := n%4 != 1 && (!padded || len(text)-n == (4-n%4)%4)
+		if wellFormed {
+			return nil, initial, &Error{Offset: initial.Offset() + n - 1, Err: ErrIllegalCharacter}
+		}
+		if r, _ := utf8.DecodeRuneInString(next.Remaining()); strings.ContainsRune(foreign, r) {
+			return nil, initial, &Error{Offset: next.Offset(), Err: ErrIllegalCharacter}
+		}
+		return nil, initial, &Error{Offset: initial.Offset() + n, Err: ErrBadPadding}
+	}
+}
@@ -0,0 +1,86 @@
+// Package cardnumber provides parsers for long numbers written in groups of digits, such as the
+// payment card number "4111 1111 1111 1111", which carry a check digit to catch typing mistakes.
+//
+// Here is the grammar the parsers follow:
+//
+//	number:     group (separator group)*   -- the same separator throughout
+//
+//	group:      digit+
+//
+//	separator:  ' ' | '-'
+//
+// The grammar only finds the digits; the check digit is then verified with Verify, by a
+// Checksum which can be swapped for another scheme's.  A number whose digits fit the grammar
+// but fail the checksum is almost certainly a typing mistake rather than some other kind of
+// text, so the parser fails with ErrChecksumMismatch rather than ErrNoMatch.
+package cardnumber
+
+import (
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Checksum reports whether a string of decimal digits carries the right check digit.
+type Checksum func(digits string) bool
+
+// Luhn is the Checksum of the Luhn algorithm, used by payment card numbers, IMEIs and many
+// national identity numbers: counting from the rightmost digit, which is the check digit,
+// every second digit is doubled, and the digits of the results must add up to a multiple of 10.
+func Luhn(digits string) bool {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+var group = TakeSome(func(r rune) bool { return r >= '0' && r <= '9' })
+
+// grouped holds the digits parsed so far and the separator between their groups, which is
+// empty until the second group.
+type grouped struct {
+	digits, separator string
+}
+
+// groups parses a number's groups of digits, and returns their digits run together.
+var groups = Loop(grouped{}, func(g grouped) Parser[Step[grouped, string]] {
+	if g.digits == "" {
+		return Map(group, func(d string) Step[grouped, string] { return Step[grouped, string]{Accum: grouped{digits: d}} })
+	}
+	next := func(separator string) Parser[Step[grouped, string]] {
+		s := StartSkipping(Exactly(separator))
+		s1 := AppendKeeping(s, group)
+		return Apply(s1, func(d string) Step[grouped, string] {
+			return Step[grouped, string]{Accum: grouped{digits: g.digits + d, separator: separator}}
+		})
+	}
+	done := Succeed(Step[grouped, string]{Done: true, Value: g.digits})
+	if g.separator != "" {
+		return OneOf(next(g.separator), done)
+	}
+	return OneOf(next(" "), next("-"), done)
+})
+
+// Digits returns a Parser[string] for a number of from min to max digits, in groups as described
+// in the package documentation, and returns its digits run together.  A number of the wrong
+// length fails with ErrNoMatch, and one which fails checksum with ErrChecksumMismatch.
+func Digits(min, max int, checksum Checksum) Parser[string] {
+	return Verify(groups, func(digits string) error {
+		if len(digits) < min || len(digits) > max {
+			return ErrNoMatch
+		}
+		if !checksum(digits) {
+			return ErrChecksumMismatch
+		}
+		return nil
+	})
+}
+
+// Card is a Parser[string] for a payment card number: from 12 to 19 digits, checked with Luhn.
+var Card = Digits(12, 19, Luhn)
@@ -0,0 +1,216 @@
+// Package configlang provides the configuration language of the example package as a supported
+// format, for tools which store their settings in it: a parser producing a syntax tree with
+// positions, dialect Options, a Stream function which reads bindings as they arrive, and
+// Marshal and Unmarshal for Go values.  A configuration is a bracketed list of bindings:
+//
+//	[ name = "gopher", port = 8080, debug = false, ]  # with Strings, TrailingComma and Comments
+//
+// Here is the grammar, with the parts each Option enables marked:
+//
+//	configuration: [space] '[' space bindings space ']' [space]   -- outer space with Comments
+//
+//	bindings:      binding (space ',' space binding)* [space ','] -- final ',' with TrailingComma
+//	             | ''                                             -- with Empty
+//
+//	binding:       name space '=' space value
+//
+//	name:          [a-zA-Z][0-9a-zA-Z]*
+//
+//	value:         int | bool | string                            -- string with Strings
+//
+//	int:           [0-9] | [1-9][0-9]+                            -- fitting in an int64
+//
+//	bool:          "true" | "false"
+//
+//	string:        a Go interpreted string literal, on one line
+//
+//	space:         ([ \t\n] | '#' [^\n]*)*                        -- comments with Comments
+//
+// With the zero Options the language is exactly the example package's.  It is stable: input
+// which parses with some Options will go on parsing to the same bindings with them, and new
+// syntax will only be added behind new Options, so that what a tool accepts changes only if it
+// asks.  The Value types are likewise only added to when a new Option makes a new kind of
+// value possible.
+package configlang
+
+import (
+	"strconv"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Binding is one "name = value" of a configuration.
+type Binding struct {
+	Name  string
+	Value Value
+	Span  Span // The binding's text, from the start of the name to the end of the value.
+}
+
+// A Value is the value of a Binding: an Int, a Bool, or with Options.Strings, a String.
+type Value interface {
+	isValue()
+	String() string // The value as it is written in a configuration.
+}
+
+// Int is an integer value.  Integers are written in decimal, without a sign or leading zeros.
+type Int int64
+
+// Bool is a boolean value, written true or false.
+type Bool bool
+
+// String is a string value, written as a Go string literal in double quotes.
+type String string
+
+func (Int) isValue()    {}
+func (Bool) isValue()   {}
+func (String) isValue() {}
+
+func (i Int) String() string    { return strconv.FormatInt(int64(i), 10) }
+func (b Bool) String() string   { return strconv.FormatBool(bool(b)) }
+func (s String) String() string { return strconv.Quote(string(s)) }
+
+// Options chooses a dialect of the language for New, each enabling some syntax which the
+// example's language lacks.  Every dialect parses the example's configurations the same way.
+type Options struct {
+	TrailingComma bool // Whether a ',' may follow the last binding.
+	Comments      bool // Whether '#' begins a comment running to the end of the line, and space may surround the brackets.
+	Strings       bool // Whether values may be strings.
+	Empty         bool // Whether there may be no bindings at all, as in "[]".
+}
+
+// All is the Options enabling every dialect feature, whose language includes every other
+// dialect's.  Unmarshal and Stream callers who want to accept whatever a tool might write use it.
+var All = Options{TrailingComma: true, Comments: true, Strings: true, Empty: true}
+
+// New returns a Parser[[]Binding] for a whole configuration in the dialect chosen by options.
+// The bindings are returned in input order.  A name may be bound more than once; Unmarshal
+// rejects that, but the parser leaves it to the caller.
+func New(options Options) Parser[[]Binding] {
+	return newParsers(options).configuration
+}
+
+// parsers holds the parsers for the parts of the grammar in one dialect, which Stream also runs
+// on their own.
+type parsers struct {
+	space         Parser[Empty]
+	binding       Parser[Binding]
+	configuration Parser[[]Binding]
+}
+
+func isAsciiLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func isDecimalDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isAlphaNum(r rune) bool {
+	return isAsciiLetter(r) || isDecimalDigit(r)
+}
+
+func isWhitespace(r rune) bool {
+	return r == ' ' || r == '\n' || r == '\t'
+}
+
+func newParsers(options Options) parsers {
+	var p parsers
+
+	p.space = ConsumeWhile(isWhitespace)
+	if options.Comments {
+		comment := AppendSkipping(StartSkipping(Exactly("#")), ConsumeWhile(func(r rune) bool { return r != '\n' }))
+		p.space = Fold(OneOf(ConsumeSome(isWhitespace), comment), Empty{}, func(e Empty, _ Empty) Empty { return e })
+	}
+
+	name := GetString(AppendSkipping(StartSkipping(ConsumeIf(isAsciiLetter)), ConsumeWhile(isAlphaNum)))
+
+	integer := MapErr(
+		Filter(TakeSome(isDecimalDigit),
+			func(digits string) bool { return len(digits) == 1 || digits[0] != '0' },
+			"integer without leading zeros"),
+		func(digits string) (Value, error) {
+			n, err := strconv.ParseInt(digits, 10, 64)
+			return Int(n), err
+		})
+	boolean := OneOf(
+		Map(Exactly("true"), func(Empty) Value { return Bool(true) }),
+		Map(Exactly("false"), func(Empty) Value { return Bool(false) }))
+	values := []Parser[Value]{boolean, integer}
+	if options.Strings {
+		values = append(values, MapErr(quoted, func(literal string) (Value, error) {
+			s, err := strconv.Unquote(literal)
+			return String(s), err
+		}))
+	}
+	value := Label(OneOf(values...), "value")
+
+	{
+		s := StartKeeping(name)
+		s1 := AppendSkipping(s, p.space)
+		s2 := AppendSkipping(s1, Exactly("="))
+		s3 := AppendSkipping(s2, p.space)
+		s4 := AppendKeeping(s3, value)
+		p.binding = ApplySpanned2(s4, func(span Span, name string, value Value) Binding {
+			return Binding{Name: name, Value: value, Span: span}
+		})
+	}
+
+	var bindings Parser[[]Binding]
+	{
+		s := StartSkipping(p.space)
+		s1 := AppendSkipping(s, Exactly(","))
+		s2 := AppendSkipping(s1, p.space)
+		s3 := AppendKeeping(s2, p.binding)
+		another := Apply(s3, func(b Binding) Binding { return b })
+
+		bindings = AndThen(p.binding, func(first Binding) Parser[[]Binding] {
+			return Fold(another, []Binding{first}, func(bindings []Binding, b Binding) []Binding {
+				return append(bindings, b)
+			})
+		})
+		if options.TrailingComma {
+			trailing := OneOf(AppendSkipping(StartSkipping(p.space), Exactly(",")), Succeed(Empty{}))
+			bindings = Apply(AppendSkipping(StartKeeping(bindings), trailing), func(b []Binding) []Binding { return b })
+		}
+		if options.Empty {
+			bindings = OneOf(bindings, Succeed([]Binding(nil)))
+		}
+	}
+
+	outer := Succeed(Empty{})
+	if options.Comments {
+		outer = p.space
+	}
+	{
+		s := StartSkipping(outer)
+		s1 := AppendSkipping(s, Exactly("["))
+		s2 := AppendSkipping(s1, p.space)
+		s3 := AppendKeeping(s2, bindings)
+		s4 := AppendSkipping(s3, p.space)
+		s5 := AppendSkipping(s4, Exactly("]"))
+		s6 := AppendSkipping(s5, outer)
+		p.configuration = Apply(s6, func(b []Binding) []Binding { return b })
+	}
+	return p
+}
+
+// quoted parses a string literal in double quotes, on one line, and returns it with its
+// quotes and escapes as they are, for strconv.Unquote.
+var quoted Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return "", initial, ErrNoMatch
+	}
+	for i := 1; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '\n':
+			return "", initial, ErrNoMatch
+		case '"':
+			return rest[:i+1], initial.Consume(i + 1), nil
+		}
+	}
+	return "", initial, ErrNoMatch
+}
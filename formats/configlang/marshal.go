@@ -0,0 +1,267 @@
+package configlang
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Errors returned by Marshal and Unmarshal.
+var (
+	ErrDuplicateName   = errors.New("name bound twice")        // When Unmarshal finds a name bound more than once.
+	ErrInvalidName     = errors.New("invalid name")            // When Marshal is given a name the language can't write.
+	ErrUnsupportedType = errors.New("unsupported type")        // When a value or field has no counterpart in the language.
+	ErrWrongType       = errors.New("value of the wrong type") // When a value can't be stored in its field.
+)
+
+var valueType = reflect.TypeOf((*Value)(nil)).Elem()
+
+// Marshal returns v written as a configuration, with its bindings on one line, such as
+// "[debug = false, port = 8080]".  v may be a []Binding, which is written in order; a map with
+// string keys, written in key order; or a struct, or pointer to one, whose exported fields are
+// written in order, named by the `config:"name"` tag if they have one, or else the field name,
+// and skipped if tagged `config:"-"`.  Values may be of integer, bool and string types, or a
+// Value.  Strings need Options.Strings to be read back, and an empty v needs Options.Empty.
+func Marshal(v any) ([]byte, error) {
+	bindings, err := bindingsOf(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, len(bindings))
+	for i, b := range bindings {
+		if !validName(b.Name) {
+			return nil, fmt.Errorf("%w %q", ErrInvalidName, b.Name)
+		}
+		items[i] = b.Name + " = " + b.Value.String()
+	}
+	return []byte("[" + strings.Join(items, ", ") + "]"), nil
+}
+
+// bindingsOf returns the bindings Marshal writes for v.
+func bindingsOf(v reflect.Value) ([]Binding, error) {
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("%w: nil", ErrUnsupportedType)
+	}
+	if bindings, ok := v.Interface().([]Binding); ok {
+		return bindings, nil
+	}
+	var bindings []Binding
+	add := func(name string, field reflect.Value) error {
+		value, err := valueOf(field)
+		if err != nil {
+			return &FieldError{Field: name, Err: err}
+		}
+		bindings = append(bindings, Binding{Name: name, Value: value})
+		return nil
+	}
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if err := add(k.String(), v.MapIndex(k)); err != nil {
+				return nil, err
+			}
+		}
+	case v.Kind() == reflect.Struct:
+		for _, f := range fields(v.Type()) {
+			if err := add(f.name, v.FieldByIndex(f.index)); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w %v", ErrUnsupportedType, v.Type())
+	}
+	return bindings, nil
+}
+
+// valueOf returns the Value for a field or map value.
+func valueOf(v reflect.Value) (Value, error) {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("%w: nil", ErrUnsupportedType)
+		}
+		v = v.Elem()
+	}
+	if value, ok := v.Interface().(Value); ok {
+		return value, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return nil, fmt.Errorf("%w: negative integer %d", ErrUnsupportedType, v.Int())
+		}
+		return Int(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer %d is too large", ErrUnsupportedType, v.Uint())
+		}
+		return Int(v.Uint()), nil
+	case reflect.Bool:
+		return Bool(v.Bool()), nil
+	case reflect.String:
+		return String(v.String()), nil
+	}
+	return nil, fmt.Errorf("%w %v", ErrUnsupportedType, v.Type())
+}
+
+// validName reports whether name can be written as the name of a binding.
+func validName(name string) bool {
+	for i, r := range name {
+		if i == 0 && !isAsciiLetter(r) || !isAlphaNum(r) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// A field is a struct field Marshal and Unmarshal bind, with its name in the configuration.
+type field struct {
+	name  string
+	index []int
+	exact bool // Whether the name must match exactly, as a tag's must, rather than ignoring case.
+}
+
+// fields returns the fields of the struct type t which Marshal and Unmarshal bind.
+func fields(t reflect.Type) []field {
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("config")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		if tag == "" {
+			fs = append(fs, field{name: f.Name, index: f.Index})
+		} else {
+			fs = append(fs, field{name: tag, index: f.Index, exact: true})
+		}
+	}
+	return fs
+}
+
+// Unmarshal parses data as a configuration in any dialect, as with the Options All, and
+// stores its bindings in the value v points to.  That may be a []Binding; a map with string
+// keys, whose values are Values or of a type they can be stored in; or a struct.  A binding is
+// stored in the struct field Marshal would write under its name, or else one whose name matches
+// it ignoring case; bindings without a field are ignored, and fields without a binding are left
+// as they are.  Ints may be stored in integer fields they fit in, Bools in bool fields, Strings
+// in string fields, and any Value in a field of type Value or any.
+//
+// Unmarshal fails with the *ParseError from parsing data, or a *FieldError wrapping
+// ErrDuplicateName if a name is bound twice, or ErrWrongType if a value can't be stored in its
+// field.
+func Unmarshal(data []byte, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("%w: Unmarshal needs a non-nil pointer, not %T", ErrUnsupportedType, v)
+	}
+	bindings, err := Parse(New(All), string(data))
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(bindings))
+	for _, b := range bindings {
+		if seen[b.Name] {
+			return &FieldError{Field: b.Name, Offset: b.Span.Start, Err: ErrDuplicateName}
+		}
+		seen[b.Name] = true
+	}
+	target = target.Elem()
+	if _, ok := target.Interface().([]Binding); ok {
+		target.Set(reflect.ValueOf(bindings))
+		return nil
+	}
+	switch {
+	case target.Kind() == reflect.Map && target.Type().Key().Kind() == reflect.String:
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for _, b := range bindings {
+			x := reflect.New(target.Type().Elem()).Elem()
+			if err := store(x, b); err != nil {
+				return err
+			}
+			target.SetMapIndex(reflect.ValueOf(b.Name).Convert(target.Type().Key()), x)
+		}
+	case target.Kind() == reflect.Struct:
+		fs := fields(target.Type())
+		for _, b := range bindings {
+			if f, ok := fieldFor(fs, b.Name); ok {
+				if err := store(target.FieldByIndex(f.index), b); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%w %v", ErrUnsupportedType, target.Type())
+	}
+	return nil
+}
+
+// fieldFor returns the field a binding called name is stored in: the one named name exactly,
+// or else the first untagged one whose name matches it ignoring case.
+func fieldFor(fs []field, name string) (field, bool) {
+	for _, f := range fs {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fs {
+		if !f.exact && strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// store sets x to the value of b, if it can be stored there.
+func store(x reflect.Value, b Binding) error {
+	wrong := func() error {
+		err := fmt.Errorf("%w: %s can't be stored in a %v", ErrWrongType, b.Value, x.Type())
+		return &FieldError{Field: b.Name, Offset: b.Span.Start, Err: err}
+	}
+	if x.Kind() == reflect.Interface {
+		if !valueType.AssignableTo(x.Type()) {
+			return wrong()
+		}
+		x.Set(reflect.ValueOf(b.Value))
+		return nil
+	}
+	switch value := b.Value.(type) {
+	case Int:
+		switch x.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if x.OverflowInt(int64(value)) {
+				return wrong()
+			}
+			x.SetInt(int64(value))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if x.OverflowUint(uint64(value)) {
+				return wrong()
+			}
+			x.SetUint(uint64(value))
+		default:
+			return wrong()
+		}
+	case Bool:
+		if x.Kind() != reflect.Bool {
+			return wrong()
+		}
+		x.SetBool(bool(value))
+	case String:
+		if x.Kind() != reflect.String {
+			return wrong()
+		}
+		x.SetString(string(value))
+	}
+	return nil
+}
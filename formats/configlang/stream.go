@@ -0,0 +1,162 @@
+package configlang
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Stream reads a configuration in the dialect chosen by options from r, calling f with each
+// binding as soon as the ',' or ']' after it has been read, so that a large configuration need
+// never be held in memory at once.  It stops at the first error from r, in the configuration,
+// or from f, and returns it.  An error in the configuration is a *ParseError, and it and the
+// Spans of the bindings give positions in the whole of r's input.  Since each binding is parsed
+// once its end has been read, an error may be reported at the binding, where New's parser
+// would report it later, after backtracking out of the list.
+func Stream(r io.Reader, options Options, f func(Binding) error) error {
+	p := newParsers(options)
+	outer := Succeed(Empty{})
+	if options.Comments {
+		outer = p.space
+	}
+	segment := Apply(AppendSkipping(AppendKeeping(StartSkipping(p.space), p.binding), p.space), func(b Binding) Binding { return b })
+	in := &segmenter{r: bufio.NewReader(r), options: options, at: position{line: 1, column: 1}}
+
+	text, stop, start, err := in.next("[")
+	if err != nil {
+		return err
+	}
+	if _, err := Parse(outer, text); err != nil {
+		return start.shift(err)
+	}
+	if stop == 0 {
+		return in.unexpectedEOF("'['")
+	}
+	for i := 0; ; i++ {
+		text, stop, start, err = in.next(",]")
+		if err != nil {
+			return err
+		}
+		if stop == 0 {
+			return in.unexpectedEOF("',' or ']'")
+		}
+		b, err := Parse(segment, text)
+		if err != nil {
+			_, spaceErr := Parse(p.space, text)
+			ended := stop == ']' && spaceErr == nil && (i == 0 && options.Empty || i > 0 && options.TrailingComma)
+			if !ended {
+				return start.shift(err)
+			}
+			break
+		}
+		b.Span = Span{Start: b.Span.Start + start.offset, End: b.Span.End + start.offset}
+		if err := f(b); err != nil {
+			return err
+		}
+		if stop == ']' {
+			break
+		}
+	}
+	text, _, start, err = in.next("")
+	if err != nil {
+		return err
+	}
+	if _, err := Parse(outer, text); err != nil {
+		return start.shift(err)
+	}
+	return nil
+}
+
+// A position is a place in the input, as a ParseError gives it.
+type position struct {
+	offset, line, column int
+}
+
+// shift moves the position of err, if it is a *ParseError from parsing text which began at p,
+// to be in the whole input instead.
+func (p position) shift(err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		if parseErr.Line == 1 {
+			parseErr.Column += p.column - 1
+		}
+		parseErr.Line += p.line - 1
+		parseErr.Offset += p.offset
+	}
+	var expected *ExpectedError
+	if errors.As(err, &expected) {
+		expected.Offset += p.offset
+	}
+	return err
+}
+
+// A segmenter splits a configuration into the text between its brackets and commas, skipping
+// over those in strings and comments, for Stream to parse a piece at a time.
+type segmenter struct {
+	r       *bufio.Reader
+	options Options
+	at      position // Where the next byte read is.
+}
+
+// next reads up to the first of the stops outside a string or comment, and returns the text
+// before it, which stop it was, and where the text began.  At the end of the input, stop is 0.
+func (s *segmenter) next(stops string) (string, byte, position, error) {
+	start := s.at
+	var text strings.Builder
+	inString, escaped, inComment := false, false, false
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return text.String(), 0, start, nil
+		}
+		if err != nil {
+			return "", 0, start, err
+		}
+		if !inString && !inComment && strings.IndexByte(stops, c) >= 0 {
+			s.advance(c)
+			return text.String(), c, start, nil
+		}
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case inString && (c == '"' || c == '\n'):
+			inString = false
+		case inComment && c == '\n':
+			inComment = false
+		case !inString && !inComment && c == '"' && s.options.Strings:
+			inString = true
+		case !inString && !inComment && c == '#' && s.options.Comments:
+			inComment = true
+		}
+		text.WriteByte(c)
+		s.advance(c)
+	}
+}
+
+// advance moves past the byte c, counting lines and the runes of columns.
+func (s *segmenter) advance(c byte) {
+	s.at.offset++
+	switch {
+	case c == '\n':
+		s.at.line++
+		s.at.column = 1
+	case c&0xC0 != 0x80: // Not a UTF-8 continuation byte, so the start of a rune.
+		s.at.column++
+	}
+}
+
+// unexpectedEOF returns the *ParseError for the input ending where what was expected.
+func (s *segmenter) unexpectedEOF(what string) error {
+	return &ParseError{
+		Offset: s.at.offset,
+		Line:   s.at.line,
+		Column: s.at.column,
+		Err:    fmt.Errorf("%w: expected %s", io.ErrUnexpectedEOF, what),
+	}
+}
@@ -0,0 +1,185 @@
+// Package contentline provides parsers for the content lines which make up iCalendar files, as
+// defined by RFC 5545, and vCards, as defined by RFC 6350, such as
+//
+//	DTSTART;TZID=Europe/London:20240514T090000
+//	item1.EMAIL;TYPE=work,pref:jo@example.com
+//
+// Here is the grammar the parsers follow, for a single unfolded line:
+//
+//	line:         [group '.'] name (';' param)* ':' value
+//
+//	param:        name '=' param-value (',' param-value)*
+//
+//	param-value:  '"' qsafe* '"' | safe*
+//
+//	name:         (letter | digit | '-')+
+//
+// where a safe character is anything but a control character, '"', ';', ':' or ',', and a qsafe
+// character is anything but a control character or '"'.  The group is a vCard feature, which
+// iCalendar doesn't use.  The value is the rest of the line, as written; see Property.Text.
+//
+// Long lines are folded, by breaking them and starting the continuation line with a space or a
+// tab.  Unfold undoes this, and ParseDocument unfolds a whole file as it parses its lines.
+package contentline
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Param is a property parameter, such as TYPE=work,pref.
+type Param struct {
+	Name   string   // Upper-cased.
+	Values []string // Without any quotes, in input order.
+}
+
+// A Property is a single content line.
+type Property struct {
+	Group  string  // The vCard group, such as "item1", or "" if there is none.
+	Name   string  // Upper-cased, such as "DTSTART".
+	Params []Param // In input order.
+	Value  string  // As written, with any escapes left in.
+}
+
+// Param returns the values of the named parameter, ignoring case, and whether there was one.
+func (p Property) Param(name string) ([]string, bool) {
+	name = strings.ToUpper(name)
+	for _, param := range p.Params {
+		if param.Name == name {
+			return param.Values, true
+		}
+	}
+	return nil, false
+}
+
+// Text returns the value with the escapes of the TEXT value type decoded: "\n" or "\N" for a
+// newline, and "\\", "\;" and "\," for the characters themselves.  Other backslashes are kept.
+func (p Property) Text() string {
+	if !strings.Contains(p.Value, `\`) {
+		return p.Value
+	}
+	var b strings.Builder
+	for i := 0; i < len(p.Value); i++ {
+		c := p.Value[i]
+		if c == '\\' && i+1 < len(p.Value) {
+			switch next := p.Value[i+1]; next {
+			case 'n', 'N':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\', ';', ',':
+				b.WriteByte(next)
+				i++
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Unfold returns text with its folded lines joined back together, by removing each line break,
+// "\r\n" or "\n", which is followed by a space or a tab, along with that space or tab.
+func Unfold(text string) string {
+	if !strings.Contains(text, "\n ") && !strings.Contains(text, "\n\t") {
+		return text
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 || i+1 == len(text) {
+			b.WriteString(text)
+			return b.String()
+		}
+		if text[i+1] != ' ' && text[i+1] != '\t' {
+			b.WriteString(text[:i+1])
+			text = text[i+1:]
+			continue
+		}
+		end := i
+		if end > 0 && text[end-1] == '\r' {
+			end--
+		}
+		b.WriteString(text[:end])
+		text = text[i+2:]
+	}
+}
+
+func isNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-'
+}
+
+func isControl(r rune) bool {
+	return r < ' ' && r != '\t' || r == 0x7f
+}
+
+var (
+	name = Map(TakeSome(isNameRune), strings.ToUpper)
+
+	safe  = TakeWhile(func(r rune) bool { return !isControl(r) && !strings.ContainsRune("\";:,", r) })
+	qsafe = TakeWhile(func(r rune) bool { return !isControl(r) && r != '"' })
+
+	paramValue = OneOf(
+		Apply(AppendSkipping(AppendKeeping(StartSkipping(Exactly(`"`)), qsafe), Exactly(`"`)), func(v string) string { return v }),
+		safe,
+	)
+)
+
+// paramValues parses a parameter's comma-separated values.
+var paramValues = Loop([]string(nil), func(values []string) Parser[Step[[]string, []string]] {
+	next := paramValue
+	if values != nil {
+		next = Apply(AppendKeeping(StartSkipping(Exactly(",")), paramValue), func(v string) string { return v })
+	}
+	return OneOf(
+		Map(next, func(v string) Step[[]string, []string] { return Step[[]string, []string]{Accum: append(values, v)} }),
+		Succeed(Step[[]string, []string]{Done: true, Value: values}),
+	)
+})
+
+// param parses ";" and a parameter.
+var param = func() Parser[Param] {
+	s := StartSkipping(Exactly(";"))
+	s1 := AppendKeeping(s, name)
+	s2 := AppendSkipping(s1, Exactly("="))
+	s3 := AppendKeeping(s2, paramValues)
+	return Apply2(s3, func(name string, values []string) Param { return Param{Name: name, Values: values} })
+}()
+
+// params parses a property's parameters.
+var params = Loop([]Param(nil), func(ps []Param) Parser[Step[[]Param, []Param]] {
+	return OneOf(
+		Map(param, func(p Param) Step[[]Param, []Param] { return Step[[]Param, []Param]{Accum: append(ps, p)} }),
+		Succeed(Step[[]Param, []Param]{Done: true, Value: ps}),
+	)
+})
+
+// groupAndName parses a property's name, with its group if it has one, as [group, name].
+var groupAndName = OneOf(
+	Apply2(AppendKeeping(AppendSkipping(StartKeeping(TakeSome(isNameRune)), Exactly(".")), name),
+		func(group, name string) [2]string { return [2]string{group, name} }),
+	Map(name, func(name string) [2]string { return [2]string{"", name} }),
+)
+
+// PropertyLine is a Parser[Property] for a single unfolded content line, without its line break.
+var PropertyLine = func() Parser[Property] {
+	s := StartKeeping(groupAndName)
+	s1 := AppendKeeping(s, params)
+	s2 := AppendSkipping(s1, Exactly(":"))
+	s3 := AppendKeeping(s2, RestOfLine)
+	return Apply3(s3, func(gn [2]string, params []Param, value string) Property {
+		return Property{Group: gn[0], Name: gn[1], Params: params, Value: value}
+	})
+}()
+
+// Document is a Parser[[]Property] for a whole unfolded iCalendar or vCard file, one Property
+// per line, such as "BEGIN:VCALENDAR" and "END:VCALENDAR"; components aren't grouped.  Use
+// ParseDocument to unfold the file as well.
+var Document = LinesOf(PropertyLine)
+
+// ParseDocument unfolds text and parses it with Document.  Positions in any error refer to text
+// as given, folds and all.
+func ParseDocument(text string) ([]Property, error) {
+	return Parse(Unfolded(Document, DropFoldSpace), text)
+}
@@ -0,0 +1,184 @@
+// Package csscolor provides parsers for CSS color literals, as found in stylesheets, config
+// files and themes: hex colors (`#f80`, `#ff8800`, `#ff880080`) and the rgb() and hsl()
+// functions in both their comma-separated (`rgb(255, 136, 0)`) and space-separated
+// (`hsl(32 100% 50% / 0.5)`) forms.  Colors are returned as an image/color.NRGBA.
+package csscolor
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+func isHexDigit(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n'
+}
+
+// Hex is a Parser[color.NRGBA] for "#" followed by 3, 4, 6 or 8 hexadecimal digits, giving
+// red, green, blue and optionally alpha; in the short forms each digit is doubled, so
+// "#f80" is "#ff8800".  Alpha is opaque if left out.
+var Hex = AndThen(
+	Apply(AppendKeeping(StartSkipping(Exactly("#")), TakeSome(isHexDigit)), func(d string) string { return d }),
+	func(digits string) Parser[color.NRGBA] {
+		switch len(digits) {
+		case 3, 4:
+			var long strings.Builder
+			for i := range digits {
+				long.WriteString(digits[i : i+1])
+				long.WriteString(digits[i : i+1])
+			}
+			digits = long.String()
+		case 6, 8:
+		default:
+			return Fail[color.NRGBA]
+		}
+		if len(digits) == 6 {
+			digits += "ff"
+		}
+		n, _ := strconv.ParseUint(digits, 16, 32)
+		return Succeed(color.NRGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)})
+	})
+
+// component is a number from a color function, and whether it was a percentage.
+type component struct {
+	value   float64
+	percent bool
+}
+
+// number parses a CSS number such as 12, -3, 0.5 or .5, followed by an optional "%".
+var number = func() Parser[component] {
+	digits := AppendSkipping(
+		StartSkipping(ConsumeWhile(isDigit)),
+		OneOf(AppendSkipping(StartSkipping(Exactly(".")), ConsumeSome(isDigit)), Succeed(Empty{})))
+	text := GetString(AppendSkipping(StartSkipping(OneOf(Exactly("-"), Exactly("+"), Succeed(Empty{}))), digits))
+	value := AndThen(text, func(text string) Parser[float64] {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Fail[float64]
+		}
+		return Succeed(f)
+	})
+	percent := OneOf(Map(Exactly("%"), func(Empty) bool { return true }), Succeed(false))
+	s := StartKeeping(value)
+	s1 := AppendKeeping(s, percent)
+	return Apply2(s1, func(value float64, percent bool) component {
+		return component{value: value, percent: percent}
+	})
+}()
+
+// hue parses a number with an optional "deg" unit.
+var hue = func() Parser[component] {
+	s := StartKeeping(number)
+	s1 := AppendSkipping(s, OneOf(ExactlyAnyFold("deg"), Succeed("")))
+	return Apply(s1, func(c component) component { return c })
+}()
+
+var (
+	ws    = ConsumeWhile(isSpace)
+	comma = AppendSkipping(AppendSkipping(StartSkipping(ws), Exactly(",")), ws)
+	slash = AppendSkipping(AppendSkipping(StartSkipping(ws), Exactly("/")), ws)
+)
+
+// arguments parses the parenthesized arguments of a color function: three components separated
+// by commas or by spaces, then optionally an alpha component after "," or "/".  Alpha is
+// returned as 1 if left out.
+func arguments(first Parser[component]) Parser[[4]component] {
+	separator := OneOf(comma, StartSkipping(ConsumeSome(isSpace)))
+	alpha := OneOf(
+		Apply(AppendKeeping(StartSkipping(OneOf(comma, slash)), number), func(c component) component { return c }),
+		Succeed(component{value: 1}),
+	)
+	s := StartSkipping(Exactly("("))
+	s1 := AppendSkipping(s, ws)
+	s2 := AppendKeeping(s1, first)
+	s3 := AppendSkipping(s2, separator)
+	s4 := AppendKeeping(s3, number)
+	s5 := AppendSkipping(s4, separator)
+	s6 := AppendKeeping(s5, number)
+	three := Apply3(s6, func(a, b, c component) [4]component { return [4]component{a, b, c} })
+	return AndThen(three, func(args [4]component) Parser[[4]component] {
+		s := StartKeeping(alpha)
+		s1 := AppendSkipping(s, ws)
+		s2 := AppendSkipping(s1, Exactly(")"))
+		return Apply(s2, func(a component) [4]component {
+			args[3] = a
+			return args
+		})
+	})
+}
+
+// clamp limits f to the range [0, max] and rounds it to the nearest integer.
+func clamp(f, max float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(f, max))))
+}
+
+// channel converts an rgb() color component, a number from 0 to 255 or a percentage, to a byte.
+func channel(c component) uint8 {
+	if c.percent {
+		return clamp(c.value*255/100, 255)
+	}
+	return clamp(c.value, 255)
+}
+
+// fraction converts a component which is a number from 0 to 1 or a percentage to a number from 0 to 1.
+func fraction(c component) float64 {
+	f := c.value
+	if c.percent {
+		f /= 100
+	}
+	return math.Max(0, math.Min(f, 1))
+}
+
+// RGB is a Parser[color.NRGBA] for the rgb() and rgba() functions, which are equivalent.  Red,
+// green and blue are numbers from 0 to 255 or percentages; alpha is a number from 0 to 1 or a
+// percentage.  Out of range values are clamped, as CSS requires.
+var RGB = func() Parser[color.NRGBA] {
+	s := StartSkipping(ExactlyAnyFold("rgba", "rgb"))
+	s1 := AppendKeeping(s, arguments(number))
+	return Apply(s1, func(args [4]component) color.NRGBA {
+		return color.NRGBA{R: channel(args[0]), G: channel(args[1]), B: channel(args[2]), A: clamp(fraction(args[3])*255, 255)}
+	})
+}()
+
+// HSL is a Parser[color.NRGBA] for the hsl() and hsla() functions, which are equivalent.  Hue is
+// in degrees, optionally followed by "deg"; saturation and lightness are percentages, or
+// numbers from 0 to 100 as CSS Color 4 allows; alpha is as for RGB.
+var HSL = func() Parser[color.NRGBA] {
+	s := StartSkipping(ExactlyAnyFold("hsla", "hsl"))
+	s1 := AppendKeeping(s, arguments(hue))
+	return Apply(s1, func(args [4]component) color.NRGBA {
+		h := math.Mod(args[0].value, 360)
+		if h < 0 {
+			h += 360
+		}
+		sat := math.Max(0, math.Min(args[1].value, 100)) / 100
+		light := math.Max(0, math.Min(args[2].value, 100)) / 100
+		r, g, b := hslToRGB(h, sat, light)
+		return color.NRGBA{R: clamp(r*255, 255), G: clamp(g*255, 255), B: clamp(b*255, 255), A: clamp(fraction(args[3])*255, 255)}
+	})
+}()
+
+// hslToRGB converts a hue in degrees and saturation and lightness from 0 to 1 to red, green and
+// blue from 0 to 1, using the algorithm given in CSS Color 4.
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	f := func(n float64) float64 {
+		k := math.Mod(n+h/30, 12)
+		a := s * math.Min(l, 1-l)
+		return l - a*math.Max(-1, math.Min(math.Min(k-3, 9-k), 1))
+	}
+	return f(0), f(8), f(4)
+}
+
+// Color is a Parser[color.NRGBA] for any of the color literals: Hex, RGB or HSL.
+var Color = OneOf(Hex, RGB, HSL)
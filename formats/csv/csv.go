@@ -0,0 +1,97 @@
+// Package csv provides parsers for comma-separated values as described by RFC 4180, and a
+// decoder which binds the records of a CSV file with a header to a slice of structs.
+//
+// Fields are separated by a delimiter rune, usually ',', and records by "\r\n" or "\n".  A field
+// may be quoted with '"', in which case it may contain delimiters, newlines, and quotes written
+// twice ("").  A final newline at the end of the input is optional.
+package csv
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+var newline = OneOf(Exactly("\r\n"), Exactly("\n"))
+
+// quoted parses a quoted field, returning its contents with doubled quotes undone.
+var quoted Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return "", initial, ErrNoMatch
+	}
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		if rest[i] != '"' {
+			b.WriteByte(rest[i])
+			continue
+		}
+		if i+1 < len(rest) && rest[i+1] == '"' {
+			b.WriteByte('"')
+			i++
+			continue
+		}
+		return b.String(), initial.Consume(i + 1), nil
+	}
+	return "", initial, ErrNoMatch
+}
+
+// Cell returns a Parser[string] for a single field, quoted or not, with fields separated by
+// delimiter.  An unquoted field runs up to the next delimiter or line terminator and may be empty.
+func Cell(delimiter rune) Parser[string] {
+	bare := TakeWhile(func(r rune) bool {
+		return r != delimiter && r != '\n' && r != '\r' && r != '"'
+	})
+	return OneOf(quoted, bare)
+}
+
+// Row returns a Parser[[]string] for a single record: one or more fields separated by
+// delimiter.  It doesn't consume the line terminator.
+func Row(delimiter rune) Parser[[]string] {
+	return record(Cell(delimiter), delimiter)
+}
+
+// record returns a parser for fields separated by delimiter.
+func record[T any](field Parser[T], delimiter rune) Parser[[]T] {
+	separator := Exactly(string(delimiter))
+	return Loop[[]T](nil, func(fields []T) Parser[Step[[]T, []T]] {
+		more := func(t T) Step[[]T, []T] {
+			return Step[[]T, []T]{Accum: append(fields, t)}
+		}
+		if fields == nil {
+			return Map(field, more)
+		}
+		s := StartSkipping(separator)
+		s1 := AppendKeeping(s, field)
+		return OneOf(
+			Apply(s1, more),
+			Succeed(Step[[]T, []T]{Done: true, Value: fields}),
+		)
+	})
+}
+
+// File returns a Parser[[][]string] for the rest of the input as records separated by line
+// terminators.  Records are returned as they are, so a header is just the first record.
+func File(delimiter rune) Parser[[][]string] {
+	return file(Row(delimiter))
+}
+
+// file returns a parser for records separated by newlines, with an optional final newline.
+func file[T any](record Parser[T]) Parser[[]T] {
+	return Loop[[]T](nil, func(records []T) Parser[Step[[]T, []T]] {
+		done := Map(AppendSkipping(StartSkipping(OneOf(newline, Succeed(Empty{}))), EndOfInput),
+			func(Empty) Step[[]T, []T] { return Step[[]T, []T]{Done: true, Value: records} })
+		next := record
+		if records != nil {
+			next = Apply(AppendKeeping(StartSkipping(newline), record), func(t T) T { return t })
+		}
+		// The end is checked first, so that an error in a record is reported rather than
+		// just ending the file early.
+		return OneOf(
+			done,
+			Map(next, func(t T) Step[[]T, []T] {
+				return Step[[]T, []T]{Accum: append(records, t)}
+			}),
+		)
+	})
+}
@@ -0,0 +1,208 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// ErrMissingColumn is returned by a Decode parser when the header lacks a column that was given
+// a CellParser.
+var ErrMissingColumn = errors.New("missing column")
+
+// A CellParser supplies the parser for the cells of one column to Decode; make one with Typed.
+type CellParser struct {
+	name   string
+	typ    reflect.Type
+	parser Parser[any]
+}
+
+// Typed returns a CellParser which parses the cells of the named column with parser.  The name is
+// a header name, or "#" and a 0-based index such as "#2".  The parser must consume the whole cell.
+func Typed[T any](name string, parser Parser[T]) CellParser {
+	return CellParser{
+		name:   name,
+		typ:    reflect.TypeOf((*T)(nil)).Elem(),
+		parser: Map(parser, func(t T) any { return t }),
+	}
+}
+
+// Time returns a Parser[time.Time] for a whole cell in the given layout, as for time.Parse.
+func Time(layout string) Parser[time.Time] {
+	return AndThen(TakeWhile(func(rune) bool { return true }), func(text string) Parser[time.Time] {
+		t, err := time.Parse(layout, text)
+		if err != nil {
+			return Fail[time.Time]
+		}
+		return Succeed(t)
+	})
+}
+
+// binding is a struct field and how to find and parse its cell.
+type binding struct {
+	index  []int       // The field, for reflect.Value.FieldByIndex.
+	column string      // A header name, or "#" and an index.
+	exact  bool        // Whether a header name must match column exactly, rather than ignoring case.
+	parser Parser[any] // Parses the cell to a value assignable to the field.
+}
+
+// Decode returns a Parser[[]S] for the rest of the input as a CSV file whose first record is
+// a header, decoding each following record into an S, which must be a struct type.
+//
+// Each exported field of S is bound to a column.  A field tagged `csv:"name"` is bound to the
+// column with that header; one tagged `csv:"#2"` to the third column whatever its header; and an
+// untagged field to the column whose header matches the field name, ignoring case.  A field tagged
+// `csv:"-"` is skipped, as is a field whose column isn't in the header.
+//
+// A cell is parsed by the CellParser given for its field's column, if any, or else by a default
+// chosen by the field's type: string fields take the cell as is, and fields of integer, floating
+// point and bool types are parsed as strconv would, with an empty cell giving the zero value.  Fields
+// of other types need a CellParser.  A cell that fails to parse fails the whole Decode with a
+// *FieldError naming the column; offsets, there and in errors from CellParsers, are positions in
+// the original input.  A CellParser whose column is missing from the header fails it with ErrMissingColumn.
+//
+// Decode panics if S isn't a struct, or a field has no parser that can be assigned to it.
+func Decode[S any](delimiter rune, columns ...CellParser) Parser[[]S] {
+	typ := reflect.TypeOf((*S)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("csv: Decode of non-struct type %v", typ))
+	}
+	var bindings []binding
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("csv")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		b := binding{index: f.Index, column: tag, exact: tag != ""}
+		if tag == "" {
+			b.column = f.Name
+		}
+		for _, c := range columns {
+			if c.name == b.column || !b.exact && strings.EqualFold(c.name, b.column) {
+				if !c.typ.AssignableTo(f.Type) {
+					panic(fmt.Sprintf("csv: column %q parses %v, which can't be assigned to field %s of type %v", c.name, c.typ, f.Name, f.Type))
+				}
+				b.parser = c.parser
+			}
+		}
+		if b.parser == nil {
+			if b.parser = defaultParser(f.Type); b.parser == nil {
+				panic(fmt.Sprintf("csv: field %s of type %v needs a CellParser", f.Name, f.Type))
+			}
+		}
+		bindings = append(bindings, b)
+	}
+	return AndThen(Row(delimiter), func(header []string) Parser[[]S] {
+		for _, c := range columns {
+			if columnIndex(header, c.name, true) < 0 {
+				return failWith[[]S](fmt.Errorf("%w %q", ErrMissingColumn, c.name))
+			}
+		}
+		// cells[i] binds column i, or has a nil parser if no field wants it.
+		cells := make([]binding, len(header))
+		for _, b := range bindings {
+			if i := columnIndex(header, b.column, b.exact); i >= 0 {
+				cells[i] = b
+			}
+		}
+		s := StartSkipping(OneOf(newline, EndOfInput))
+		s1 := AppendKeeping(s, file(row[S](header, cells, delimiter)))
+		return Apply(s1, func(rows []S) []S { return rows })
+	})
+}
+
+// columnIndex returns the index of column in header, or -1.  Without exact, a header name
+// matches ignoring case.
+func columnIndex(header []string, column string, exact bool) int {
+	if strings.HasPrefix(column, "#") {
+		if i, err := strconv.Atoi(column[1:]); err == nil && i >= 0 && i < len(header) {
+			return i
+		}
+		return -1
+	}
+	for i, h := range header {
+		if h == column || !exact && strings.EqualFold(h, column) {
+			return i
+		}
+	}
+	return -1
+}
+
+// row returns a parser for a record, decoding its cells into an S as cells directs.
+func row[S any](header []string, cells []binding, delimiter rune) Parser[S] {
+	cell := Cell(delimiter)
+	separator := Exactly(string(delimiter))
+	return func(initial State) (S, State, error) {
+		var s S
+		v := reflect.ValueOf(&s).Elem()
+		current := initial
+		for i := 0; ; i++ {
+			if i > 0 {
+				_, next, err := separator(current)
+				if err != nil {
+					return s, current, nil
+				}
+				current = next
+			}
+			if i >= len(cells) || cells[i].parser == nil {
+				_, next, err := cell(current)
+				if err != nil {
+					return s, initial, err
+				}
+				current = next
+				continue
+			}
+			x, next, err := Nested(cell, cells[i].parser)(current)
+			if err != nil {
+				var zero S
+				return zero, initial, &FieldError{Field: header[i], Offset: current.Offset(), Err: err}
+			}
+			v.FieldByIndex(cells[i].index).Set(reflect.ValueOf(x))
+			current = next
+		}
+	}
+}
+
+// failWith returns a parser which fails with err.
+func failWith[T any](err error) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		return zero, initial, err
+	}
+}
+
+// defaultParser returns the parser for a field of type t with no CellParser, or nil.
+func defaultParser(t reflect.Type) Parser[any] {
+	cell := TakeWhile(func(rune) bool { return true })
+	convert := func(f func(string) (any, error)) Parser[any] {
+		return AndThen(cell, func(text string) Parser[any] {
+			if text == "" {
+				return Succeed(reflect.Zero(t).Interface())
+			}
+			x, err := f(text)
+			if err != nil {
+				return Fail[any]
+			}
+			return Succeed(reflect.ValueOf(x).Convert(t).Interface())
+		})
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return Map(cell, func(text string) any { return reflect.ValueOf(text).Convert(t).Interface() })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return convert(func(s string) (any, error) { return strconv.ParseInt(s, 10, t.Bits()) })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return convert(func(s string) (any, error) { return strconv.ParseUint(s, 10, t.Bits()) })
+	case reflect.Float32, reflect.Float64:
+		return convert(func(s string) (any, error) { return strconv.ParseFloat(s, t.Bits()) })
+	case reflect.Bool:
+		return convert(func(s string) (any, error) { return strconv.ParseBool(s) })
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package entity provides parsers for the character references of HTML and XML: named
+// references such as "&amp;", and numeric ones such as "&#233;" and "&#x1F600;".  They are meant
+// to be used inside the text-content rules of markup-adjacent grammars, either one reference at a
+// time or through Text, which decodes a whole run of text.
+package entity
+
+//go:generate go run ../../cmd/genentities -o html5.go
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// xmlEntities are the five named references predefined by XML.
+var xmlEntities = map[string]rune{
+	"amp":  '&',
+	"lt":   '<',
+	"gt":   '>',
+	"quot": '"',
+	"apos": '\'',
+}
+
+func isDecimalDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDecimalDigit(r) || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+func isNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || isDecimalDigit(r)
+}
+
+// reference returns a Parser for "&" prefix, then body, then ";", returning what body matched.
+func reference(prefix string, body Parser[Empty]) Parser[string] {
+	s := StartSkipping(Exactly("&" + prefix))
+	s1 := AppendKeeping(s, GetString(body))
+	s2 := AppendSkipping(s1, Exactly(";"))
+	return Apply(s2, func(text string) string { return text })
+}
+
+// codePoint returns a Parser which parses digits in the given base and succeeds if they
+// name a rune that may appear in text: not NUL, not a surrogate half, and not beyond U+10FFFF.
+func codePoint(digits Parser[string], base int) Parser[rune] {
+	return AndThen(digits, func(text string) Parser[rune] {
+		n, err := strconv.ParseUint(text, base, 32)
+		if err != nil || n == 0 || !utf8.ValidRune(rune(n)) {
+			return Fail[rune]
+		}
+		return Succeed(rune(n))
+	})
+}
+
+// Numeric is a Parser[rune] for a numeric character reference, "&#" decimal digits ";" or
+// "&#x" hexadecimal digits ";", returning the rune it refers to.
+var Numeric = OneOf(
+	codePoint(reference("#x", ConsumeSome(isHexDigit)), 16),
+	codePoint(reference("#X", ConsumeSome(isHexDigit)), 16),
+	codePoint(reference("#", ConsumeSome(isDecimalDigit)), 10),
+)
+
+// XML is a Parser[rune] for an XML character reference: one of the five predefined named
+// references "&amp;", "&lt;", "&gt;", "&quot;" and "&apos;", or a numeric reference.
+var XML = OneOf(
+	AndThen(reference("", ConsumeSome(isNameRune)), func(name string) Parser[rune] {
+		if r, ok := xmlEntities[name]; ok {
+			return Succeed(r)
+		}
+		return Fail[rune]
+	}),
+	Numeric,
+)
+
+// HTML is a Parser[string] for an HTML character reference: any of the named references defined
+// by HTML5, or a numeric reference.  It returns a string rather than a rune because a few
+// named references stand for two runes.  Only references terminated by ";" are recognized, and
+// only by their exact names: "&ampx;" is not "&amp;" followed by "x;".
+var HTML = OneOf(
+	AndThen(reference("", ConsumeSome(isNameRune)), func(name string) Parser[string] {
+		if s, ok := htmlEntities[name]; ok {
+			return Succeed(s)
+		}
+		return Fail[string]
+	}),
+	Map(Numeric, func(r rune) string { return string(r) }),
+)
+
+// Text returns a Parser[string] which consumes text up to the first rune for which stop returns
+// true, or the end of the input, decoding each character reference it finds with the reference
+// parser, e.g. XML or HTML.  It fails if an "&" in the text doesn't begin a reference that the
+// reference parser accepts, since a bare "&" is not allowed in markup text.
+func Text[R rune | string](reference Parser[R], stop func(rune) bool) Parser[string] {
+	isPlain := func(r rune) bool { return r != '&' && !stop(r) }
+	piece := OneOf(
+		TakeSome(isPlain),
+		Map(reference, func(r R) string { return string(r) }),
+	)
+	atStop := func(initial State) (Empty, State, error) {
+		r, w := utf8.DecodeRuneInString(initial.Remaining())
+		if w == 0 || stop(r) {
+			return Empty{}, initial, nil
+		}
+		return Empty{}, initial, ErrNoMatch
+	}
+	return Loop(nil, func(pieces []string) Parser[Step[[]string, string]] {
+		return OneOf(
+			Map(piece, func(s string) Step[[]string, string] {
+				return Step[[]string, string]{Accum: append(pieces, s)}
+			}),
+			Map(Parser[Empty](atStop), func(Empty) Step[[]string, string] {
+				return Step[[]string, string]{Done: true, Value: strings.Join(pieces, "")}
+			}),
+		)
+	})
+}
+
+// Decoding returns a transform for Transformed which decodes the character references in the
+// input with the reference parser, e.g. XML or HTML, so that a grammar can be written against
+// the decoded text while its errors and spans still point into the text as written.  An "&"
+// which doesn't begin a reference the reference parser accepts is kept as it is.
+func Decoding[R rune | string](reference Parser[R]) func(string, *TextMap) error {
+	rest := TakeWhile(func(rune) bool { return true })
+	s := StartKeeping(Recognize(reference))
+	s1 := AppendSkipping(s, rest)
+	decode := Apply(s1, func(r Recognized[R]) Recognized[R] { return r })
+	return func(input string, m *TextMap) error {
+		for {
+			i := strings.IndexByte(input, '&')
+			if i < 0 {
+				m.Keep(input)
+				return nil
+			}
+			m.Keep(input[:i])
+			input = input[i:]
+			if r, err := Parse(decode, input); err == nil {
+				m.Replace(len(r.Text), string(r.Value))
+				input = input[len(r.Text):]
+			} else {
+				m.Keep("&")
+				input = input[1:]
+			}
+		}
+	}
+}
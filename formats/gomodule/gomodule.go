@@ -0,0 +1,367 @@
+// Package gomodule provides parsers for the names and versions of Go modules: module paths such
+// as "github.com/user/repo/v2", import paths, semantic versions such as "v1.4.0-rc.1", and
+// pseudo-versions such as "v0.0.0-20191109021931-daa7c04131f5".
+//
+// The validation rules are those of the go command, as implemented by golang.org/x/mod/module
+// and golang.org/x/mod/semver, and a path that breaks them fails with a *PathError giving the
+// same reason the go command would.  A path or version ends at the first rune that can't be
+// part of one, such as a space or quote, so these parsers can be used within a larger grammar.
+package gomodule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// PathError is the error returned for a module or import path which breaks the rules.
+type PathError struct {
+	Offset int    // Byte offset of the path from the start of the input.
+	Kind   string // "module" or "import".
+	Path   string
+	Reason string // As the go command gives it, e.g. "missing dot in first path element".
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("malformed %s path %q: %s", e.Kind, e.Path, e.Reason)
+}
+
+// A Path is a module path, split into a prefix and its major version suffix.
+type Path struct {
+	Path   string // The whole path.
+	Prefix string // The path without Major.
+	Major  string // "/v2" and so on, or ".v1" and so on for gopkg.in; empty for major versions 0 and 1.
+}
+
+// A Version is a semantic version, as Go uses them.
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string // Without the leading "-"; empty if none.
+	Build               string // Without the leading "+", e.g. "incompatible"; empty if none.
+	Short               bool   // Whether it was written in the shorthand "v1" or "v1.2" form.
+}
+
+// String returns v in canonical form, "vMAJOR.MINOR.PATCH" with any prerelease and build.
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// A PseudoVersion is a version the go command makes up for a commit with no tagged version.
+type PseudoVersion struct {
+	Version  Version
+	Base     string    // The tagged version the pseudo-version follows, e.g. "v1.2.3"; empty if none.
+	Time     time.Time // The commit time, in UTC.
+	Revision string    // The commit hash prefix, usually 12 hexadecimal digits.
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isLower(r rune) bool {
+	return r >= 'a' && r <= 'z'
+}
+
+func isAlnum(r rune) bool {
+	return isLower(r) || r >= 'A' && r <= 'Z' || isDigit(r)
+}
+
+// modPathOK reports whether r may appear in an element of a module path.
+func modPathOK(r rune) bool {
+	return isAlnum(r) || r == '-' || r == '.' || r == '_' || r == '~'
+}
+
+// importPathOK reports whether r may appear in an element of an import path.
+func importPathOK(r rune) bool {
+	return modPathOK(r) || r == '+'
+}
+
+// firstPathOK reports whether r may appear in the first element of a module path.
+func firstPathOK(r rune) bool {
+	return r == '-' || r == '.' || isLower(r) || isDigit(r)
+}
+
+var badWindowsNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// checkElem returns the reason elem isn't a valid path element, or "".
+func checkElem(elem string, module bool) string {
+	if elem == "" {
+		return "empty path element"
+	}
+	if strings.Count(elem, ".") == len(elem) {
+		return fmt.Sprintf("invalid path element %q", elem)
+	}
+	if elem[0] == '.' && module {
+		return "leading dot in path element"
+	}
+	if elem[len(elem)-1] == '.' {
+		return "trailing dot in path element"
+	}
+	short := elem
+	if i := strings.Index(short, "."); i >= 0 {
+		short = short[:i]
+	}
+	for _, bad := range badWindowsNames {
+		if strings.EqualFold(bad, short) {
+			return fmt.Sprintf("%q disallowed as path element component on Windows", short)
+		}
+	}
+	// Windows short names, such as "GOPHER~1", are rejected too.
+	if tilde := strings.LastIndexByte(short, '~'); tilde >= 0 && tilde < len(short)-1 {
+		if strings.Trim(short[tilde+1:], "0123456789") == "" {
+			return "trailing tilde and digits in path element"
+		}
+	}
+	return ""
+}
+
+// checkPath returns the reason path isn't a valid import path, or "".
+func checkPath(path string, module bool) string {
+	switch {
+	case path == "":
+		return "empty string"
+	case path[0] == '-':
+		return "leading dash"
+	case strings.Contains(path, "//"):
+		return "double slash"
+	case path[len(path)-1] == '/':
+		return "trailing slash"
+	}
+	for _, elem := range strings.Split(path, "/") {
+		if reason := checkElem(elem, module); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// checkModulePath returns the reason path isn't a valid module path, or "".
+func checkModulePath(path string) string {
+	if reason := checkPath(path, true); reason != "" {
+		return reason
+	}
+	first, _, _ := strings.Cut(path, "/")
+	if !strings.Contains(first, ".") {
+		return "missing dot in first path element"
+	}
+	for _, r := range first {
+		if !firstPathOK(r) {
+			return fmt.Sprintf("invalid char %q in first path element", r)
+		}
+	}
+	if _, _, ok := splitPathVersion(path); !ok {
+		return "invalid version"
+	}
+	return ""
+}
+
+// splitPathVersion splits path into a prefix and a major version suffix, reporting whether the
+// suffix, if any, is well formed.
+func splitPathVersion(path string) (prefix, major string, ok bool) {
+	if strings.HasPrefix(path, "gopkg.in/") {
+		// gopkg.in paths always end with ".vN", optionally followed by "-unstable".
+		base := strings.TrimSuffix(path, "-unstable")
+		i := len(base)
+		for i > 0 && isDigit(rune(base[i-1])) {
+			i--
+		}
+		if i <= 1 || i == len(base) || base[i-1] != 'v' || base[i-2] != '.' {
+			return path, "", false
+		}
+		prefix, major = path[:i-2], path[i-2:]
+		if strings.HasPrefix(major, ".v0") && major != ".v0" && major != ".v0-unstable" {
+			return path, "", false
+		}
+		return prefix, major, true
+	}
+	i := len(path)
+	dot := false
+	for i > 0 && (isDigit(rune(path[i-1])) || path[i-1] == '.') {
+		dot = dot || path[i-1] == '.'
+		i--
+	}
+	if i <= 1 || i == len(path) || path[i-1] != 'v' || path[i-2] != '/' {
+		return path, "", true
+	}
+	prefix, major = path[:i-2], path[i-2:]
+	if dot || len(major) <= 2 || major[2] == '0' || major == "/v1" {
+		return path, "", false
+	}
+	return prefix, major, true
+}
+
+// pathText returns a parser for a run of path runes, as allowed by elemOK, and slashes.
+func pathText(elemOK func(rune) bool) Parser[string] {
+	return TakeSome(func(r rune) bool { return elemOK(r) || r == '/' })
+}
+
+// Module is a Parser[Path] for a module path, as would follow "module" in a go.mod file.  The
+// first element must be a lower-case domain name containing a dot, and a major version suffix
+// must be "/v2" or later, or ".vN" for gopkg.in paths.
+var Module Parser[Path] = func(initial State) (Path, State, error) {
+	path, next, err := pathText(modPathOK)(initial)
+	if err != nil {
+		return Path{}, initial, err
+	}
+	if reason := checkModulePath(path); reason != "" {
+		return Path{}, initial, &PathError{Offset: initial.Offset(), Kind: "module", Path: path, Reason: reason}
+	}
+	prefix, major, _ := splitPathVersion(path)
+	return Path{Path: path, Prefix: prefix, Major: major}, next, nil
+}
+
+// Import is a Parser[string] for an import path.  Import paths follow the rules for the elements
+// of module paths, except that they may contain "+" and elements may begin with a dot, and have
+// no rules for their first element or major version.
+var Import Parser[string] = func(initial State) (string, State, error) {
+	path, next, err := pathText(importPathOK)(initial)
+	if err != nil {
+		return "", initial, err
+	}
+	if reason := checkPath(path, false); reason != "" {
+		return "", initial, &PathError{Offset: initial.Offset(), Kind: "import", Path: path, Reason: reason}
+	}
+	return path, next, nil
+}
+
+// numeric parses "0" or a decimal number without leading zeros.
+var numeric = AndThen(TakeSome(isDigit), func(digits string) Parser[int] {
+	n, err := strconv.Atoi(digits)
+	if err != nil || len(digits) > 1 && digits[0] == '0' {
+		return Fail[int]
+	}
+	return Succeed(n)
+})
+
+// identifiers returns a parser for dot-separated identifiers of letters, digits and "-".  With
+// noLeadingZeros, an identifier made only of digits mustn't start with "0" unless it is "0".
+func identifiers(noLeadingZeros bool) Parser[string] {
+	identifier := AndThen(TakeSome(func(r rune) bool { return isAlnum(r) || r == '-' }), func(id string) Parser[Empty] {
+		if noLeadingZeros && len(id) > 1 && id[0] == '0' && strings.Trim(id, "0123456789") == "" {
+			return Fail[Empty]
+		}
+		return Succeed(Empty{})
+	})
+	return GetString(Loop(0, func(n int) Parser[Step[int, Empty]] {
+		next := identifier
+		if n > 0 {
+			next = AppendSkipping(StartSkipping(Exactly(".")), identifier)
+		}
+		more := Map(next, func(Empty) Step[int, Empty] { return Step[int, Empty]{Accum: n + 1} })
+		if n == 0 {
+			return more
+		}
+		return OneOf(more, Succeed(Step[int, Empty]{Done: true}))
+	}))
+}
+
+// Semver is a Parser[Version] for a semantic version with the leading "v" Go requires, as
+// golang.org/x/mod/semver accepts them: "v1.2.3", "v1.2.3-pre.1+meta", or the shorthands "v1"
+// and "v1.2", which can't have a prerelease or build.
+var Semver = func() Parser[Version] {
+	dotted := func(p Parser[int]) Parser[int] {
+		return Apply(AppendKeeping(StartSkipping(Exactly(".")), p), func(n int) int { return n })
+	}
+	optional := func(prefix string, p Parser[string]) Parser[string] {
+		return OneOf(Apply(AppendKeeping(StartSkipping(Exactly(prefix)), p), func(s string) string { return s }), Succeed(""))
+	}
+	full := func() Parser[Version] {
+		s := StartKeeping(dotted(numeric))
+		s1 := AppendKeeping(s, dotted(numeric))
+		s2 := AppendKeeping(s1, optional("-", identifiers(true)))
+		return Apply3(s2, func(minor int, patch int, pre string) Version {
+			return Version{Minor: minor, Patch: patch, Prerelease: pre}
+		})
+	}()
+	full = AndThen(full, func(v Version) Parser[Version] {
+		return Map(optional("+", identifiers(false)), func(build string) Version {
+			v.Build = build
+			return v
+		})
+	})
+	rest := OneOf(
+		full,
+		Map(dotted(numeric), func(minor int) Version { return Version{Minor: minor, Short: true} }),
+		Succeed(Version{Short: true}),
+	)
+	s := StartSkipping(Exactly("v"))
+	s1 := AppendKeeping(s, numeric)
+	s2 := AppendKeeping(s1, rest)
+	versions := Apply2(s2, func(major int, v Version) Version {
+		v.Major = major
+		return v
+	})
+	// A version runs up to a rune which can't be part of one, so that "v1.2x" isn't taken as "v1.2".
+	end := OneOf(EndOfInput, ConsumeIf(func(r rune) bool { return !isAlnum(r) && r != '.' && r != '-' && r != '+' }))
+	return Apply(AppendSkipping(StartKeeping(versions), peek(end)), func(v Version) Version { return v })
+}()
+
+// peek returns a parser which succeeds where parser does, but consumes nothing.
+func peek[T any](parser Parser[T]) Parser[Empty] {
+	return func(initial State) (Empty, State, error) {
+		_, _, err := parser(initial)
+		if err != nil {
+			return Empty{}, initial, err
+		}
+		return Empty{}, initial, nil
+	}
+}
+
+// Pseudo is a Parser[PseudoVersion] for a pseudo-version, in one of the three forms the go
+// command makes:
+//
+//	vX.0.0-yyyymmddhhmmss-abcdefabcdef           -- no earlier tagged version
+//	vX.Y.Z-pre.0.yyyymmddhhmmss-abcdefabcdef     -- after the prerelease vX.Y.Z-pre
+//	vX.Y.(Z+1)-0.yyyymmddhhmmss-abcdefabcdef     -- after the release vX.Y.Z
+//
+// each optionally followed by "+incompatible".  The timestamp must be a valid UTC time.
+var Pseudo = AndThen(Semver, func(v Version) Parser[PseudoVersion] {
+	if v.Short {
+		return Fail[PseudoVersion]
+	}
+	// The prerelease ends with the timestamp and revision: "[...0.]yyyymmddhhmmss-revision".
+	pre := v.Prerelease
+	dash := strings.LastIndexByte(pre, '-')
+	if dash < 14 || !isAlnumString(pre[dash+1:]) {
+		return Fail[PseudoVersion]
+	}
+	stamp, revision, before := pre[dash-14:dash], pre[dash+1:], pre[:dash-14]
+	t, err := time.Parse("20060102150405", stamp)
+	if err != nil {
+		return Fail[PseudoVersion]
+	}
+	p := PseudoVersion{Version: v, Time: t, Revision: revision}
+	switch {
+	case before == "" && v.Minor == 0 && v.Patch == 0:
+	case before == "0." && v.Patch > 0:
+		p.Base = Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch - 1}.String()
+	case strings.HasSuffix(before, ".0.") && len(before) > 3:
+		p.Base = Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: strings.TrimSuffix(before, ".0.")}.String()
+	default:
+		return Fail[PseudoVersion]
+	}
+	return Succeed(p)
+})
+
+func isAlnumString(s string) bool {
+	for _, r := range s {
+		if !isAlnum(r) {
+			return false
+		}
+	}
+	return s != ""
+}
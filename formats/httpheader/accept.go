@@ -0,0 +1,197 @@
+package httpheader
+
+import (
+	"sort"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A MediaRange is one element of an Accept header, e.g. "text/html;level=1;q=0.7".
+type MediaRange struct {
+	Type    string          // Lower-cased; "*" matches any type.
+	Subtype string          // Lower-cased; "*" matches any subtype.
+	Params  []Field[string] // Parameters other than q, with lower-cased names, in input order.
+	Q       float64         // The quality value, from 0 to 1; 1 if not given.
+}
+
+// A Preference is one element of a header such as Accept-Language, Accept-Encoding or
+// Accept-Charset, e.g. "gzip;q=0.8".
+type Preference struct {
+	Value string // As written; "*" matches anything.
+	Q     float64
+}
+
+// Matches reports whether the range matches the media type mediaType, "type/subtype" with
+// optional parameters.  The range's own parameters, if any, must all appear among the type's.
+func (m MediaRange) Matches(mediaType string) bool {
+	typ, params, _ := strings.Cut(mediaType, ";")
+	t, sub, ok := strings.Cut(strings.ToLower(strings.TrimSpace(typ)), "/")
+	if !ok || m.Type != "*" && m.Type != t || m.Subtype != "*" && m.Subtype != sub {
+		return false
+	}
+	for _, p := range m.Params {
+		found := false
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, p.Name) && strings.Trim(value, `"`) == p.Value {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity orders ranges for sorting and matching: */* below type/* below type/subtype, and
+// ranges with more parameters above those with fewer.
+func (m MediaRange) specificity() int {
+	n := len(m.Params)
+	if m.Subtype != "*" {
+		n += 1000
+	}
+	if m.Type != "*" {
+		n += 1000
+	}
+	return n
+}
+
+// qvalue parses a quality value as RFC 9110 defines it: "0" or "1", optionally followed by a
+// "." and up to three digits, which must be zeros after "1".
+var qvalue Parser[float64] = func(initial State) (float64, State, error) {
+	rest := initial.Remaining()
+	if rest == "" || rest[0] != '0' && rest[0] != '1' {
+		return 0, initial, ErrNoMatch
+	}
+	// The value is worked out in thousandths, so that "0.7" gives exactly the float64 0.7.
+	millis := int(rest[0]-'0') * 1000
+	n := 1
+	if len(rest) > 1 && rest[1] == '.' {
+		n = 2
+		for scale := 100; n < len(rest) && n < 5 && isDigit(rune(rest[n])); scale /= 10 {
+			if millis == 1000 && rest[n] != '0' {
+				return 0, initial, ErrNoMatch
+			}
+			millis += int(rest[n]-'0') * scale
+			n++
+		}
+	}
+	return float64(millis) / 1000, initial.Consume(n), nil
+}
+
+// weight parses OWS ";" OWS "q=" qvalue.
+var weight = func() Parser[float64] {
+	s := StartSkipping(ows)
+	s1 := AppendSkipping(s, Exactly(";"))
+	s2 := AppendSkipping(s1, ows)
+	s3 := AppendSkipping(s2, ExactlyAnyFold("q="))
+	s4 := AppendKeeping(s3, qvalue)
+	return Apply(s4, func(q float64) float64 { return q })
+}()
+
+// mediaRange parses type "/" subtype, then parameters with an optional weight among them.
+// Parameters after the weight were accept-ext in RFC 7231 and are kept as parameters.
+var mediaRange = func() Parser[MediaRange] {
+	type item struct {
+		param  Field[string]
+		q      float64
+		weight bool
+	}
+	items := Loop[[]item](nil, func(items []item) Parser[Step[[]item, []item]] {
+		more := func(i item) Step[[]item, []item] { return Step[[]item, []item]{Accum: append(items, i)} }
+		return OneOf(
+			Map(weight, func(q float64) Step[[]item, []item] { return more(item{q: q, weight: true}) }),
+			Map(parameter, func(p Field[string]) Step[[]item, []item] { return more(item{param: p}) }),
+			Succeed(Step[[]item, []item]{Done: true, Value: items}),
+		)
+	})
+	s := StartKeeping(token)
+	s1 := AppendSkipping(s, Exactly("/"))
+	s2 := AppendKeeping(s1, token)
+	s3 := AppendKeeping(s2, items)
+	ranges := Apply3(s3, func(typ string, subtype string, items []item) MediaRange {
+		m := MediaRange{Type: strings.ToLower(typ), Subtype: strings.ToLower(subtype), Q: 1}
+		for _, i := range items {
+			if i.weight {
+				m.Q = i.q
+			} else {
+				m.Params = append(m.Params, i.param)
+			}
+		}
+		return m
+	})
+	return AndThen(ranges, func(m MediaRange) Parser[MediaRange] {
+		if m.Type == "*" && m.Subtype != "*" {
+			return Fail[MediaRange]
+		}
+		for _, p := range m.Params {
+			if p.Name == "q" {
+				// A malformed weight, which weight didn't accept.
+				return Fail[MediaRange]
+			}
+		}
+		return Succeed(m)
+	})
+}()
+
+// Accept is a Parser[[]MediaRange] for the value of an Accept header, returning the media ranges
+// in order of preference: highest quality first, more specific ranges before less specific ones
+// of the same quality, and otherwise in input order.  Ranges with a quality of 0, which mark
+// types as unacceptable, are included, last.  The value may be empty, giving no ranges.  Quality
+// values must be well formed, as must ranges: "*/html" is rejected.
+var Accept = Map(list(mediaRange), func(ranges []MediaRange) []MediaRange {
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Q != ranges[j].Q {
+			return ranges[i].Q > ranges[j].Q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+})
+
+// Weighted is a Parser[[]Preference] for the value of a header that lists tokens with quality
+// values, such as Accept-Language ("en-GB, en;q=0.8, *;q=0.1"), Accept-Encoding or
+// Accept-Charset.  Preferences are returned highest quality first, a "*" after anything else of
+// the same quality, and otherwise in input order.
+var Weighted = func() Parser[[]Preference] {
+	s := StartKeeping(token)
+	s1 := AppendKeeping(s, OneOf(weight, Succeed(1.0)))
+	preference := Apply2(s1, func(value string, q float64) Preference { return Preference{Value: value, Q: q} })
+	return Map(list(preference), func(prefs []Preference) []Preference {
+		sort.SliceStable(prefs, func(i, j int) bool {
+			if prefs[i].Q != prefs[j].Q {
+				return prefs[i].Q > prefs[j].Q
+			}
+			return prefs[i].Value != "*" && prefs[j].Value == "*"
+		})
+		return prefs
+	})
+}()
+
+// Negotiate chooses the best of offers, which are media types such as "application/json", for
+// a client whose Accept header parsed as accept.  As RFC 9110 describes, an offer's quality is
+// that of the most specific range matching it, so "text/*;q=0.5, text/plain" prefers text/plain
+// to text/html, and an offer whose most specific match has quality 0 is never chosen even if a
+// less specific range such as "*/*" allows it.  Ties go to the earlier offer.  If accept is
+// empty, as when the request had no Accept header, the first offer is chosen.  If no offer is
+// acceptable, Negotiate returns "" and false.
+func Negotiate(accept []MediaRange, offers ...string) (string, bool) {
+	if len(accept) == 0 && len(offers) > 0 {
+		return offers[0], true
+	}
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, m := range accept {
+			if m.Matches(offer) && m.specificity() > specificity {
+				q, specificity = m.Q, m.specificity()
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
@@ -0,0 +1,277 @@
+package httpheader
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Cookie is a cookie set by a Set-Cookie header, with its attributes.
+type Cookie struct {
+	Name       string
+	Value      string          // With any surrounding quotes removed.
+	Path       string          // Empty if absent.
+	Domain     string          // Lower-cased, without a leading "."; empty if absent.
+	Expires    time.Time       // The zero Time if absent.
+	MaxAge     int             // As in net/http: 0 if absent, negative for "Max-Age=0" or less.
+	Secure     bool            // Whether the Secure attribute was given.
+	HttpOnly   bool            // Whether the HttpOnly attribute was given.
+	SameSite   string          // "Strict", "Lax" or "None"; empty if absent.
+	Extensions []Field[string] // Other attributes, in input order, with their values; the value is empty if there was no "=".
+}
+
+// Strictness selects how closely a cookie parser keeps to the grammar.
+type Strictness int
+
+const (
+	// Strict accepts only what RFC 6265 section 4 allows servers to send.  Cookie names must be
+	// tokens, values may not contain spaces, commas or backslashes, pairs and attributes are
+	// separated by exactly "; ", and Expires must be an IMF-fixdate such as
+	// "Sun, 06 Nov 1994 08:49:37 GMT".  A malformed Expires, Max-Age, Domain or SameSite
+	// attribute makes the parser fail.
+	Strict Strictness = iota
+
+	// Lenient accepts what browsers do, following the algorithms of RFC 6265 section 5.  Names
+	// and values may contain anything but ";" and control characters, and whitespace around
+	// them is ignored, as are empty pairs.  A pair without "=" is a cookie with an empty name.
+	// Common older date formats are accepted for Expires, and a malformed Expires, Max-Age or
+	// SameSite attribute is ignored rather than failing the parse.
+	Lenient
+)
+
+// cookieDate is the layout of an IMF-fixdate, as time.Parse wants it.
+const cookieDate = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// lenientDates are the layouts accepted for Expires in Lenient mode, beyond cookieDate.
+var lenientDates = []string{
+	time.RFC1123,
+	"Mon, 02-Jan-2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC850,
+	"Mon, 02-Jan-06 15:04:05 MST",
+	time.ANSIC,
+}
+
+// isCookieOctet reports whether r may appear in a strict cookie value.
+func isCookieOctet(r rune) bool {
+	return r > ' ' && r < 0x7f && r != '"' && r != ',' && r != ';' && r != '\\'
+}
+
+func isControl(r rune) bool {
+	return r < ' ' && r != '\t' || r == 0x7f
+}
+
+// unquoteCookie strips one pair of double quotes surrounding value, if there are any.
+func unquoteCookie(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// cookiePair parses name "=" value as mode allows.
+func cookiePair(mode Strictness) Parser[Field[string]] {
+	if mode == Strict {
+		octets := ConsumeWhile(isCookieOctet)
+		value := GetString(OneOf(
+			AppendSkipping(AppendSkipping(StartSkipping(Exactly(`"`)), octets), Exactly(`"`)),
+			octets,
+		))
+		s := StartKeeping(token)
+		s1 := AppendSkipping(s, Exactly("="))
+		s2 := AppendKeeping(s1, value)
+		return Apply2(s2, func(name string, value string) Field[string] {
+			return Field[string]{Name: name, Value: unquoteCookie(value)}
+		})
+	}
+	return Map(pair(isControl), func(f attribute) Field[string] {
+		if !f.assigned {
+			return Field[string]{Value: unquoteCookie(f.name)}
+		}
+		return Field[string]{Name: f.name, Value: unquoteCookie(f.value)}
+	})
+}
+
+// attribute is a name, with a value if assigned.
+type attribute struct {
+	name, value string
+	assigned    bool
+}
+
+// pair parses name ["=" value], where the name and value are runs of anything but ";", "=" (in
+// the name) and runes for which excluded is true, each with surrounding whitespace trimmed.
+func pair(excluded func(rune) bool) Parser[attribute] {
+	name := TakeWhile(func(r rune) bool { return r != ';' && r != '=' && !excluded(r) })
+	value := TakeWhile(func(r rune) bool { return r != ';' && !excluded(r) })
+	s := StartKeeping(name)
+	s1 := AppendKeeping(s, OneOf(
+		Apply(AppendKeeping(StartSkipping(Exactly("=")), value), func(v string) *string { return &v }),
+		Succeed[*string](nil),
+	))
+	return Apply2(s1, func(name string, value *string) attribute {
+		a := attribute{name: strings.TrimSpace(name)}
+		if value != nil {
+			a.value, a.assigned = strings.TrimSpace(*value), true
+		}
+		return a
+	})
+}
+
+// separator parses what sits between pairs and attributes in mode.
+func separator(mode Strictness) Parser[Empty] {
+	if mode == Strict {
+		return Exactly("; ")
+	}
+	return semicolon
+}
+
+// separated returns a parser for first, then zero or more of rest each preceded by a separator.
+func separated[T any](first, rest Parser[T], mode Strictness) Parser[[]T] {
+	return AndThen(first, func(t T) Parser[[]T] {
+		return Loop([]T{t}, func(ts []T) Parser[Step[[]T, []T]] {
+			s := StartSkipping(separator(mode))
+			s1 := AppendKeeping(s, rest)
+			return OneOf(
+				Apply(s1, func(t T) Step[[]T, []T] { return Step[[]T, []T]{Accum: append(ts, t)} }),
+				Succeed(Step[[]T, []T]{Done: true, Value: ts}),
+			)
+		})
+	})
+}
+
+// Cookies returns a Parser[[]Field[string]] for the value of a Cookie header, such as
+// "SID=31d4d96e407aad42; lang=en-US", returning the cookies' names and values in input order.
+// In Lenient mode, empty pairs are dropped and the value may be empty, giving no cookies.
+func Cookies(mode Strictness) Parser[[]Field[string]] {
+	pairs := separated(cookiePair(mode), cookiePair(mode), mode)
+	if mode == Strict {
+		return pairs
+	}
+	return Map(pairs, func(fields []Field[string]) []Field[string] {
+		var kept []Field[string]
+		for _, f := range fields {
+			if f.Name != "" || f.Value != "" {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	})
+}
+
+// SetCookie returns a Parser[Cookie] for the value of a Set-Cookie header, such as
+// "SID=31d4d96e407aad42; Path=/; Secure; HttpOnly".  Attribute names are matched ignoring
+// case, and when an attribute is repeated the last one wins.  In Lenient mode, a cookie with an
+// empty name and value fails, as browsers ignore it.
+func SetCookie(mode Strictness) Parser[Cookie] {
+	excluded := isControl
+	if mode == Strict {
+		excluded = func(r rune) bool { return isControl(r) || r == '\t' }
+	}
+	s := StartKeeping(cookiePair(mode))
+	s1 := AppendKeeping(s, Loop[[]attribute](nil, func(attrs []attribute) Parser[Step[[]attribute, []attribute]] {
+		s := StartSkipping(separator(mode))
+		s1 := AppendKeeping(s, pair(excluded))
+		return OneOf(
+			Apply(s1, func(a attribute) Step[[]attribute, []attribute] {
+				return Step[[]attribute, []attribute]{Accum: append(attrs, a)}
+			}),
+			Succeed(Step[[]attribute, []attribute]{Done: true, Value: attrs}),
+		)
+	}))
+	cookies := Apply2(s1, func(f Field[string], attrs []attribute) *Cookie {
+		c := &Cookie{Name: f.Name, Value: f.Value}
+		if mode == Lenient && c.Name == "" && c.Value == "" {
+			return nil
+		}
+		for _, a := range attrs {
+			if !c.set(a, mode) && mode == Strict {
+				return nil
+			}
+		}
+		return c
+	})
+	return AndThen(cookies, func(c *Cookie) Parser[Cookie] {
+		if c == nil {
+			return Fail[Cookie]
+		}
+		return Succeed(*c)
+	})
+}
+
+// set applies the attribute a to c, and reports whether it was well formed.  An attribute that
+// isn't well formed is ignored.
+func (c *Cookie) set(a attribute, mode Strictness) bool {
+	switch strings.ToLower(a.name) {
+	case "expires":
+		layouts := []string{cookieDate}
+		if mode == Lenient {
+			layouts = append(layouts, lenientDates...)
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, a.value); err == nil {
+				c.Expires = t.UTC()
+				return true
+			}
+		}
+		return false
+	case "max-age":
+		n, err := strconv.Atoi(a.value)
+		if err != nil || mode == Strict && (n <= 0 || a.value[0] == '0' || a.value[0] == '+') {
+			return false
+		}
+		if n <= 0 {
+			n = -1
+		}
+		c.MaxAge = n
+	case "domain":
+		domain := strings.ToLower(strings.TrimPrefix(a.value, "."))
+		if mode == Strict && !isDomain(domain) {
+			return false
+		}
+		c.Domain = domain
+	case "path":
+		c.Path = a.value
+	case "secure":
+		c.Secure = true
+	case "httponly":
+		c.HttpOnly = true
+	case "samesite":
+		for _, s := range []string{"Strict", "Lax", "None"} {
+			if strings.EqualFold(a.value, s) {
+				c.SameSite = s
+				return true
+			}
+		}
+		return false
+	default:
+		if mode == Lenient && a.name == "" {
+			return true
+		}
+		if mode == Strict && a.name == "" {
+			return false
+		}
+		c.Extensions = append(c.Extensions, Field[string]{Name: a.name, Value: a.value})
+	}
+	return true
+}
+
+// isDomain reports whether domain is a sequence of labels of letters, digits and hyphens
+// separated by dots, with no label starting or ending with a hyphen.
+func isDomain(domain string) bool {
+	if domain == "" {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || isDigit(r) || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package httpheader
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/formats/percent"
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// ErrCharset is returned for an extended parameter value whose charset isn't UTF-8 or
+// ISO-8859-1, or whose decoded bytes aren't valid in its charset.
+var ErrCharset = errors.New("unsupported or invalid charset")
+
+// A Parameter is a parameter of a Content-Disposition header.
+type Parameter struct {
+	Name     string // Lower-cased, without the "*" of an extended parameter.
+	Value    string // Unquoted, and for an extended parameter decoded to UTF-8.
+	Extended bool   // Whether the parameter was written name*=charset'language'value, as in RFC 8187.
+	Charset  string // For an extended parameter, "UTF-8" or "ISO-8859-1"; otherwise empty.
+	Language string // For an extended parameter, the language tag, which may be empty.
+}
+
+// A Disposition is the value of a Content-Disposition header, e.g.
+// `attachment; filename="report.pdf"`.
+type Disposition struct {
+	Type   string // Lower-cased, e.g. "inline", "attachment" or "form-data".
+	Params []Parameter
+}
+
+// Get returns the value of the named parameter, and whether there was one.  As RFC 6266
+// recommends, an extended parameter is preferred over a plain one of the same name, so that
+// Get("filename") returns the value of filename* if both are present.
+func (d Disposition) Get(name string) (string, bool) {
+	name = strings.ToLower(name)
+	value, found := "", false
+	for _, p := range d.Params {
+		if p.Name == name {
+			if p.Extended {
+				return p.Value, true
+			}
+			if !found {
+				value, found = p.Value, true
+			}
+		}
+	}
+	return value, found
+}
+
+// isAttrChar reports whether r is an attr-char, one of the characters that may appear
+// unescaped in an extended value.
+func isAttrChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || isDigit(r) || strings.ContainsRune("!#$&+-.^_`|~", r)
+}
+
+func isLanguageRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || isDigit(r) || r == '-'
+}
+
+// failWith returns a parser which fails with err.
+func failWith[T any](err error) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		return zero, initial, err
+	}
+}
+
+// extValue parses charset "'" [language] "'" value-chars, decoding the value by its charset.
+var extValue = func() Parser[Parameter] {
+	// A token may contain "'", but the charset can't.
+	charset := TakeSome(func(r rune) bool { return isTokenRune(r) && r != '\'' })
+	s := StartKeeping(charset)
+	s1 := AppendSkipping(s, Exactly("'"))
+	s2 := AppendKeeping(s1, TakeWhile(isLanguageRune))
+	s3 := AppendSkipping(s2, Exactly("'"))
+	s4 := AppendKeeping(s3, percent.Decoded(isAttrChar))
+	raw := Apply3(s4, func(charset string, language string, value string) Parameter {
+		return Parameter{Extended: true, Charset: charset, Language: language, Value: value}
+	})
+	return AndThen(raw, func(p Parameter) Parser[Parameter] {
+		switch {
+		case strings.EqualFold(p.Charset, "UTF-8"):
+			if !utf8.ValidString(p.Value) {
+				return failWith[Parameter](ErrCharset)
+			}
+			p.Charset = "UTF-8"
+		case strings.EqualFold(p.Charset, "ISO-8859-1"):
+			// Each byte is the code point of the same number.
+			runes := make([]rune, len(p.Value))
+			for i := 0; i < len(p.Value); i++ {
+				runes[i] = rune(p.Value[i])
+			}
+			p.Value, p.Charset = string(runes), "ISO-8859-1"
+		default:
+			return failWith[Parameter](ErrCharset)
+		}
+		return Succeed(p)
+	})
+}()
+
+// dispositionParameter parses OWS ";" OWS, then name "=" value or name "*=" ext-value.
+var dispositionParameter = func() Parser[Parameter] {
+	extended := AndThen(TakeSome(func(r rune) bool { return isTokenRune(r) && r != '*' }), func(name string) Parser[Parameter] {
+		s := StartSkipping(Exactly("*="))
+		s1 := AppendKeeping(s, extValue)
+		return Apply(s1, func(p Parameter) Parameter {
+			p.Name = strings.ToLower(name)
+			return p
+		})
+	})
+	plain := AndThen(nameValue, func(f Field[string]) Parser[Parameter] {
+		if strings.HasSuffix(f.Name, "*") {
+			// A malformed extended parameter.
+			return Fail[Parameter]
+		}
+		return Succeed(Parameter{Name: f.Name, Value: f.Value})
+	})
+	s := StartSkipping(semicolon)
+	s1 := AppendKeeping(s, OneOf(extended, plain))
+	return Apply(s1, func(p Parameter) Parameter { return p })
+}()
+
+// ContentDisposition is a Parser[Disposition] for the value of a Content-Disposition header,
+// as defined by RFC 6266, including the extended parameters of RFC 8187 such as
+// "filename*=UTF-8”na%C3%AFve.txt".  Extended values in UTF-8 and ISO-8859-1 are decoded; any
+// other charset, or bytes invalid in the charset given, fail the parse with ErrCharset, and a
+// malformed escape fails it with a *percent.EscapeError.  Parameters are returned in input order.
+var ContentDisposition = func() Parser[Disposition] {
+	params := Loop[[]Parameter](nil, func(params []Parameter) Parser[Step[[]Parameter, []Parameter]] {
+		return OneOf(
+			Map(dispositionParameter, func(p Parameter) Step[[]Parameter, []Parameter] {
+				return Step[[]Parameter, []Parameter]{Accum: append(params, p)}
+			}),
+			Succeed(Step[[]Parameter, []Parameter]{Done: true, Value: params}),
+		)
+	})
+	s := StartSkipping(ows)
+	s1 := AppendKeeping(s, token)
+	s2 := AppendKeeping(s1, params)
+	s3 := AppendSkipping(s2, ows)
+	return Apply2(s3, func(typ string, params []Parameter) Disposition {
+		return Disposition{Type: strings.ToLower(typ), Params: params}
+	})
+}()
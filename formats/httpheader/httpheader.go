@@ -0,0 +1,92 @@
+// Package httpheader provides parsers for the values of HTTP header fields with a structure
+// of their own, such as Accept, as defined by RFC 9110 and the RFCs for the fields concerned.
+// Each parser takes a field value with the field name and colon already removed; a value
+// arriving on several lines should be joined with ", " first, as RFC 9110 allows for lists.
+package httpheader
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// isTokenRune reports whether r is a tchar, one of the characters allowed in a token.
+func isTokenRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || isDigit(r) || strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+var (
+	ows   = ConsumeWhile(isSpace)
+	token = TakeSome(isTokenRune)
+)
+
+// quotedString parses an HTTP quoted-string, returning its contents with quoted-pairs decoded.
+var quotedString Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return "", initial, ErrNoMatch
+	}
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '"':
+			return b.String(), initial.Consume(i + 1), nil
+		case c == '\\' && i+1 < len(rest):
+			i++
+			b.WriteByte(rest[i])
+		case c == '\r' || c == '\n':
+			return "", initial, ErrNoMatch
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", initial, ErrNoMatch
+}
+
+// semicolon parses OWS ";" OWS, which precedes each parameter.
+var semicolon = AppendSkipping(AppendSkipping(StartSkipping(ows), Exactly(";")), ows)
+
+// nameValue parses name "=" value, with the name lower-cased and a quoted value unquoted.
+var nameValue = func() Parser[Field[string]] {
+	s := StartKeeping(token)
+	s1 := AppendSkipping(s, Exactly("="))
+	s2 := AppendKeeping(s1, OneOf(token, quotedString))
+	return Apply2(s2, func(name string, value string) Field[string] {
+		return Field[string]{Name: strings.ToLower(name), Value: value}
+	})
+}()
+
+// parameter parses OWS ";" OWS name "=" value.
+var parameter = Apply(AppendKeeping(StartSkipping(semicolon), nameValue), func(f Field[string]) Field[string] { return f })
+
+// list returns a parser for a comma-separated list of zero or more elements, as RFC 9110's
+// #element rule, which allows empty elements and whitespace around the commas.
+func list[T any](element Parser[T]) Parser[[]T] {
+	type accum struct {
+		items     []T
+		separated bool // Whether an element may come next.
+	}
+	comma := AppendSkipping(StartSkipping(ows), Exactly(","))
+	return Loop(accum{separated: true}, func(a accum) Parser[Step[accum, []T]] {
+		steps := []Parser[Step[accum, []T]]{
+			Map(comma, func(Empty) Step[accum, []T] {
+				return Step[accum, []T]{Accum: accum{items: a.items, separated: true}}
+			}),
+		}
+		if a.separated {
+			steps = append(steps, Apply(AppendKeeping(StartSkipping(ows), element), func(t T) Step[accum, []T] {
+				return Step[accum, []T]{Accum: accum{items: append(a.items, t)}}
+			}))
+		}
+		done := Map(ows, func(Empty) Step[accum, []T] { return Step[accum, []T]{Done: true, Value: a.items} })
+		return OneOf(append(steps, done)...)
+	})
+}
@@ -0,0 +1,93 @@
+package httpheader
+
+import (
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A UserAgentToken is one element of a User-Agent or Server header: either a product, such as
+// "Firefox/126.0", or a comment, such as "(X11; Linux x86_64)".
+type UserAgentToken struct {
+	Product string // The product's name, or "" for a comment.
+	Version string // The product's version, or "" if it has none.
+	Comment string // A comment's text, without its parentheses, or "" for a product.
+}
+
+// Details returns the parts of a comment, which is usually a list separated by ";", each with
+// surrounding whitespace removed.
+func (t UserAgentToken) Details() []string {
+	if t.Comment == "" {
+		return nil
+	}
+	parts := strings.Split(t.Comment, ";")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+// comment parses a comment, which may contain nested comments and quoted-pairs, returning its
+// text with the quoted-pairs decoded.  A comment left open at the end of the input is taken to
+// end there.
+var comment Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, "(") {
+		return "", initial, ErrNoMatch
+	}
+	var b strings.Builder
+	depth := 1
+	i := 1
+	for ; i < len(rest) && depth > 0; i++ {
+		switch c := rest[i]; {
+		case c == '\\' && i+1 < len(rest):
+			i++
+			b.WriteByte(rest[i])
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				continue
+			}
+		}
+		b.WriteByte(rest[i])
+	}
+	return b.String(), initial.Consume(i), nil
+}
+
+// product parses a product name, with an optional "/" and version.  The version is taken to be
+// everything up to the next space or comment, since agents put all sorts in there.
+var product = func() Parser[UserAgentToken] {
+	version := GetString(ConsumeWhile(func(r rune) bool { return !isSpace(r) && r != '(' }))
+	s := StartKeeping(token)
+	s1 := AppendKeeping(s, OneOf(Apply(AppendKeeping(StartSkipping(Exactly("/")), version), func(v string) string { return v }), Succeed("")))
+	return Apply2(s1, func(name, version string) UserAgentToken { return UserAgentToken{Product: name, Version: version} })
+}()
+
+// junk parses anything else, up to the next space or comment.
+var junk = ConsumeSome(func(r rune) bool { return !isSpace(r) && r != '(' })
+
+// UserAgent is a Parser[[]UserAgentToken] for the value of a User-Agent or Server header, such
+// as "Mozilla/5.0 (X11; Linux x86_64; rv:126.0) Gecko/20100101 Firefox/126.0", returning its
+// products and comments in input order.
+//
+// Real User-Agent strings stray far from RFC 9110, so the parser is lenient: it never fails,
+// skipping over anything which is neither a product nor a comment, such as the stray commas
+// some agents emit, and taking an unclosed comment to run to the end of the value.
+var UserAgent = Loop([]UserAgentToken{}, func(tokens []UserAgentToken) Parser[Step[[]UserAgentToken, []UserAgentToken]] {
+	next := func(t UserAgentToken) Step[[]UserAgentToken, []UserAgentToken] {
+		return Step[[]UserAgentToken, []UserAgentToken]{Accum: append(tokens, t)}
+	}
+	skip := func(Empty) Step[[]UserAgentToken, []UserAgentToken] {
+		return Step[[]UserAgentToken, []UserAgentToken]{Accum: tokens}
+	}
+	return OneOf(
+		Map(ConsumeSome(isSpace), skip),
+		Map(comment, func(c string) Step[[]UserAgentToken, []UserAgentToken] { return next(UserAgentToken{Comment: c}) }),
+		Map(product, next),
+		Map(junk, skip),
+		Succeed(Step[[]UserAgentToken, []UserAgentToken]{Done: true, Value: tokens}),
+	)
+})
@@ -0,0 +1,200 @@
+// Package httpline provides parsers for the start lines of HTTP/1.x messages, as defined by
+// RFC 9112: request lines, status lines, and the size lines that begin each chunk of a
+// chunked body.  Each parser consumes its line terminator, "\r\n" or a bare "\n", if there
+// is one, so the same parsers serve for protocol streams and for lines taken from logs.
+package httpline
+
+import (
+	"strconv"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Version is an HTTP protocol version, such as 1.1.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// RequestLine is the first line of an HTTP request, e.g. "GET /index.html HTTP/1.1".
+type RequestLine struct {
+	Method  string
+	Target  string // The request target exactly as sent: origin, absolute, authority or asterisk form.
+	Version Version
+}
+
+// StatusLine is the first line of an HTTP response, e.g. "HTTP/1.1 404 Not Found".
+type StatusLine struct {
+	Version Version
+	Code    int
+	Reason  string // May be empty.
+}
+
+// ChunkHeader is the line that begins each chunk of a chunked body, e.g. "1a;name=value".
+type ChunkHeader struct {
+	Size       int64 // Zero for the last chunk.
+	Extensions []Extension
+}
+
+// Extension is a single chunk extension.  Quoted values are returned unquoted.
+type Extension struct {
+	Name  string
+	Value string // Empty if the extension has no value.
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDigit(r) || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+// isTokenRune reports whether r is a tchar, one of the characters allowed in a token.
+func isTokenRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || isDigit(r) || strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
+
+// isVisible reports whether r is a VCHAR or obs-text.
+func isVisible(r rune) bool {
+	return r > ' ' && r != 0x7f
+}
+
+func isReasonRune(r rune) bool {
+	return isVisible(r) || r == ' ' || r == '\t'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+var (
+	space      = Exactly(" ")
+	whitespace = ConsumeWhile(isSpace)
+	token      = TakeSome(isTokenRune)
+
+	// lineEnd accepts a line terminator, or the end of the input for lines without one.
+	lineEnd = OneOf(Exactly("\r\n"), Exactly("\n"), EndOfInput)
+
+	digit = Map(GetString(ConsumeIf(isDigit)), func(d string) int {
+		return int(d[0] - '0')
+	})
+)
+
+// HTTPVersion is a Parser[Version] for an HTTP-version, "HTTP/" digit "." digit.
+var HTTPVersion = func() Parser[Version] {
+	s := StartSkipping(Exactly("HTTP/"))
+	s1 := AppendKeeping(s, digit)
+	s2 := AppendSkipping(s1, Exactly("."))
+	s3 := AppendKeeping(s2, digit)
+	return Apply2(s3, func(major int, minor int) Version {
+		return Version{Major: major, Minor: minor}
+	})
+}()
+
+// Request is a Parser[RequestLine] for a request line: method SP request-target SP HTTP-version.
+var Request = func() Parser[RequestLine] {
+	s := StartKeeping(token)
+	s1 := AppendSkipping(s, space)
+	s2 := AppendKeeping(s1, TakeSome(isVisible))
+	s3 := AppendSkipping(s2, space)
+	s4 := AppendKeeping(s3, HTTPVersion)
+	s5 := AppendSkipping(s4, lineEnd)
+	return Apply3(s5, func(method string, target string, version Version) RequestLine {
+		return RequestLine{Method: method, Target: target, Version: version}
+	})
+}()
+
+// statusCode parses the three digit status code.
+var statusCode = Map(GetString(AppendSkipping(AppendSkipping(ConsumeIf(isDigit), ConsumeIf(isDigit)), ConsumeIf(isDigit))),
+	func(digits string) int {
+		code, _ := strconv.Atoi(digits)
+		return code
+	})
+
+// Status is a Parser[StatusLine] for a status line: HTTP-version SP status-code SP reason-phrase.
+// As RFC 9112 recommends, the space before an empty reason phrase may be left out.
+var Status = func() Parser[StatusLine] {
+	reason := OneOf(
+		Apply(AppendKeeping(StartSkipping(space), TakeWhile(isReasonRune)), func(r string) string { return r }),
+		Succeed(""),
+	)
+	s := StartKeeping(HTTPVersion)
+	s1 := AppendSkipping(s, space)
+	s2 := AppendKeeping(s1, statusCode)
+	s3 := AppendKeeping(s2, reason)
+	s4 := AppendSkipping(s3, lineEnd)
+	return Apply3(s4, func(version Version, code int, reason string) StatusLine {
+		return StatusLine{Version: version, Code: code, Reason: reason}
+	})
+}()
+
+// quotedString parses an HTTP quoted-string, returning its contents with quoted-pairs decoded.
+var quotedString Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return "", initial, ErrNoMatch
+	}
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '"':
+			return b.String(), initial.Consume(i + 1), nil
+		case c == '\\' && i+1 < len(rest):
+			i++
+			b.WriteByte(rest[i])
+		case c == '\r' || c == '\n':
+			return "", initial, ErrNoMatch
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", initial, ErrNoMatch
+}
+
+// extension parses BWS ";" BWS name [ BWS "=" BWS value ].
+var extension = func() Parser[Extension] {
+	value := OneOf(
+		Apply(AppendKeeping(StartSkipping(AppendSkipping(AppendSkipping(whitespace, Exactly("=")), whitespace)),
+			OneOf(token, quotedString)),
+			func(v string) string { return v }),
+		Succeed(""),
+	)
+	s := StartSkipping(whitespace)
+	s1 := AppendSkipping(s, Exactly(";"))
+	s2 := AppendSkipping(s1, whitespace)
+	s3 := AppendKeeping(s2, token)
+	s4 := AppendKeeping(s3, value)
+	return Apply2(s4, func(name string, value string) Extension {
+		return Extension{Name: name, Value: value}
+	})
+}()
+
+// chunkSize parses the hexadecimal size, failing if it doesn't fit in an int64.
+var chunkSize = AndThen(TakeSome(isHexDigit), func(digits string) Parser[int64] {
+	size, err := strconv.ParseInt(digits, 16, 64)
+	if err != nil {
+		return Fail[int64]
+	}
+	return Succeed(size)
+})
+
+// Chunk is a Parser[ChunkHeader] for a chunk-size line: hexadecimal size, then any chunk extensions.
+var Chunk = func() Parser[ChunkHeader] {
+	extensions := Loop[[]Extension](nil, func(exts []Extension) Parser[Step[[]Extension, []Extension]] {
+		return OneOf(
+			Map(extension, func(e Extension) Step[[]Extension, []Extension] {
+				return Step[[]Extension, []Extension]{Accum: append(exts, e)}
+			}),
+			Succeed(Step[[]Extension, []Extension]{Done: true, Value: exts}),
+		)
+	})
+	s := StartKeeping(chunkSize)
+	s1 := AppendKeeping(s, extensions)
+	s2 := AppendSkipping(s1, whitespace)
+	s3 := AppendSkipping(s2, lineEnd)
+	return Apply2(s3, func(size int64, exts []Extension) ChunkHeader {
+		return ChunkHeader{Size: size, Extensions: exts}
+	})
+}()
@@ -0,0 +1,462 @@
+// Package humandate provides a parser for dates and times written as people say them, such as
+// "tomorrow at 5pm", "next tuesday at noon", "3 days ago" and "march 5th, 2024", resolved to a
+// time.Time against a reference clock.
+//
+// Here is the grammar, in which words are matched ignoring case, and must be whole words:
+//
+//	expression: 'now' | offset [at time] | date [at time] | time [['on'] date]
+//
+//	at:         'at' | ','                   -- or nothing but a space
+//
+//	offset:     'in' quantity unit | quantity unit 'ago' | quantity unit 'from' 'now'
+//	          | ('next' | 'last') unit
+//
+//	quantity:   'a' | 'an' | number          -- in digits or words, as numword.Number parses it
+//
+//	unit:       'second' | 'minute' | 'hour' | 'day' | 'week' | 'fortnight' | 'month' | 'year'
+//	                                         -- or their plurals
+//
+//	date:       'today' | 'tomorrow' | 'yesterday' | 'the day after tomorrow'
+//	          | 'the day before yesterday' | ['this' | 'next' | 'last'] weekday
+//	          | month day [[','] year] | day ['of'] month [[','] year] | yyyy '-' mm '-' dd
+//
+//	day:        [1-9] | [0-2][0-9] | '3' [01]   -- or an ordinal, as numword.Ordinal parses it
+//
+//	time:       ('noon' | 'midnight' | hh [':' mm [':' ss]] ['am' | 'pm'] ) [zone]
+//
+//	zone:       'UTC' | 'GMT' | offset       -- as timezone.Numeric parses it
+//
+// A time without "am" or "pm" must have minutes, and is on the 24-hour clock, so "5" isn't a
+// time but "5pm" and "17:00" are.  Weekdays and months may be abbreviated to three letters, or
+// as "tues", "thur" and "thurs", "sept".
+//
+// Here is how the expressions are resolved, against the reference time now:
+//
+//   - A date is the start of that day, unless a time is given.  A month and day without a year
+//     are in the year of now.
+//   - A weekday, or "this" weekday, is the next such day, which is today if now is that day.
+//     "next" is the same but never today, and "last" is the last such day before today.
+//   - An offset is added to now: to the clock for seconds, minutes and hours, and to the
+//     calendar, as time.AddDate does, for the longer units.  If a time follows an offset of a
+//     day or more, it replaces the time of day.
+//   - A time alone is that time today.  A zone after a time gives the time in that zone, on
+//     the day the rest of the expression resolves to in the parser's location.
+package humandate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/formats/numword"
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/formats/timezone"
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Options configures a parser made with New.
+type Options struct {
+	Now      func() time.Time // The reference clock; time.Now if nil.  It is called once per parse.
+	Location *time.Location   // Where days begin and end; time.Local if nil.
+}
+
+// resolve gives the time an expression stands for, given the reference time, and whether
+// there is such a time; "february 30th" resolves to nothing.
+type resolve func(now time.Time) (time.Time, bool)
+
+// offset is an offset of a quantity of a unit.
+type offset struct {
+	n    int
+	unit string // As in the units table.
+}
+
+// daily reports whether the offset is of a day or more, and so may be followed by a time.
+func (o offset) daily() bool {
+	return o.unit != "second" && o.unit != "minute" && o.unit != "hour"
+}
+
+func (o offset) add(t time.Time) time.Time {
+	switch o.unit {
+	case "second":
+		return t.Add(time.Duration(o.n) * time.Second)
+	case "minute":
+		return t.Add(time.Duration(o.n) * time.Minute)
+	case "hour":
+		return t.Add(time.Duration(o.n) * time.Hour)
+	case "day":
+		return t.AddDate(0, 0, o.n)
+	case "week":
+		return t.AddDate(0, 0, 7*o.n)
+	case "fortnight":
+		return t.AddDate(0, 0, 14*o.n)
+	case "month":
+		return t.AddDate(0, o.n, 0)
+	}
+	return t.AddDate(o.n, 0, 0)
+}
+
+// offsetAt is an offset with the time of day given after it, if any.
+type offsetAt struct {
+	offset offset
+	clock  *clock
+}
+
+// clock is a time of day, with the zone it was given in, if any.
+type clock struct {
+	hour, minute, second int
+	zone                 *time.Location
+}
+
+// on returns r with its time of day replaced by c.
+func (c clock) on(r resolve) resolve {
+	return func(now time.Time) (time.Time, bool) {
+		t, ok := r(now)
+		if !ok {
+			return t, false
+		}
+		zone := c.zone
+		if zone == nil {
+			zone = t.Location()
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), c.hour, c.minute, c.second, 0, zone), true
+	}
+}
+
+// midnight returns the start of the day of t.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// days returns a resolve for the start of the day n days after the day of now.
+func days(n int) resolve {
+	return func(now time.Time) (time.Time, bool) { return midnight(now).AddDate(0, 0, n), true }
+}
+
+var units = map[string]string{
+	"second": "second", "seconds": "second", "minute": "minute", "minutes": "minute",
+	"hour": "hour", "hours": "hour", "day": "day", "days": "day", "week": "week", "weeks": "week",
+	"fortnight": "fortnight", "fortnights": "fortnight", "month": "month", "months": "month",
+	"year": "year", "years": "year",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January, "february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March, "april": time.April, "apr": time.April, "may": time.May,
+	"june": time.June, "jun": time.June, "july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August, "september": time.September, "sep": time.September,
+	"sept": time.September, "october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November, "december": time.December, "dec": time.December,
+}
+
+var (
+	space   = ConsumeSome(func(r rune) bool { return r == ' ' || r == '\t' })
+	letters = TakeSome(unicode.IsLetter)
+)
+
+// lookup parses a whole word which is in the table, ignoring case, and returns its value.
+func lookup[T any](table map[string]T) Parser[T] {
+	return AndThen(letters, func(w string) Parser[T] {
+		if t, ok := table[strings.ToLower(w)]; ok {
+			return Succeed(t)
+		}
+		return Fail[T]
+	})
+}
+
+// keyword parses the words, ignoring case, separated by spaces.
+func keyword(words ...string) Parser[Empty] {
+	word := func(w string) Parser[Empty] {
+		return AndThen(letters, func(got string) Parser[Empty] {
+			if !strings.EqualFold(got, w) {
+				return Fail[Empty]
+			}
+			return Succeed(Empty{})
+		})
+	}
+	p := word(words[0])
+	for _, w := range words[1:] {
+		p = AppendSkipping(AppendSkipping(StartSkipping(p), space), word(w))
+	}
+	return p
+}
+
+// then returns a parser for first, a space, and second, keeping the value of second.
+func then[T, U any](first Parser[T], second Parser[U]) Parser[U] {
+	s := StartSkipping(first)
+	s1 := AppendSkipping(s, space)
+	s2 := AppendKeeping(s1, second)
+	return Apply(s2, func(u U) U { return u })
+}
+
+// number parses at most digits decimal digits, and at least min of them, as an int.
+func number(min, digits int) Parser[int] {
+	return func(initial State) (int, State, error) {
+		rest := initial.Remaining()
+		i := 0
+		for i < len(rest) && i < digits && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i < min || i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			return 0, initial, ErrNoMatch
+		}
+		n, _ := strconv.Atoi(rest[:i])
+		return n, initial.Consume(i), nil
+	}
+}
+
+// New returns a Parser[time.Time] for a date expression, resolved against the reference time
+// given by options.
+func New(options Options) Parser[time.Time] {
+	now := options.Now
+	if now == nil {
+		now = time.Now
+	}
+	loc := options.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	quantity := OneOf(Map(lookup(map[string]int64{"a": 1, "an": 1}), func(n int64) int { return int(n) }),
+		AndThen(numword.Number, func(n int64) Parser[int] {
+			if n > 1e6 {
+				return Fail[int]
+			}
+			return Succeed(int(n))
+		}))
+	unit := lookup(units)
+
+	var offsetParser Parser[offset]
+	{
+		s := StartKeeping(quantity)
+		s1 := AppendSkipping(s, space)
+		s2 := AppendKeeping(s1, unit)
+		amount := Apply2(s2, func(n int, unit string) offset { return offset{n: n, unit: unit} })
+		negate := func(o offset) offset { return offset{n: -o.n, unit: o.unit} }
+		in := then(keyword("in"), amount)
+		s3 := StartKeeping(amount)
+		s4 := AppendSkipping(s3, space)
+		ago := Apply(AppendSkipping(s4, keyword("ago")), negate)
+		fromNow := Apply(AppendSkipping(s4, keyword("from", "now")), func(o offset) offset { return o })
+		direction := OneOf(
+			Map(keyword("next"), func(Empty) int { return 1 }),
+			Map(keyword("last"), func(Empty) int { return -1 }),
+		)
+		s5 := StartKeeping(direction)
+		s6 := AppendSkipping(s5, space)
+		s7 := AppendKeeping(s6, unit)
+		nextLast := Apply2(s7, func(n int, unit string) offset { return offset{n: n, unit: unit} })
+		offsetParser = OneOf(in, ago, fromNow, nextLast)
+	}
+
+	var dateParser Parser[resolve]
+	{
+		relative := OneOf(
+			Map(keyword("the", "day", "after", "tomorrow"), func(Empty) resolve { return days(2) }),
+			Map(keyword("the", "day", "before", "yesterday"), func(Empty) resolve { return days(-2) }),
+			Map(keyword("today"), func(Empty) resolve { return days(0) }),
+			Map(keyword("tomorrow"), func(Empty) resolve { return days(1) }),
+			Map(keyword("yesterday"), func(Empty) resolve { return days(-1) }),
+		)
+
+		which := OneOf(
+			Map(then(keyword("this"), lookup(weekdays)), func(w time.Weekday) resolve { return weekday(w, 0) }),
+			Map(then(keyword("next"), lookup(weekdays)), func(w time.Weekday) resolve { return weekday(w, 1) }),
+			Map(then(keyword("last"), lookup(weekdays)), func(w time.Weekday) resolve { return weekday(w, -1) }),
+			Map(lookup(weekdays), func(w time.Weekday) resolve { return weekday(w, 0) }),
+		)
+
+		day := OneOf(
+			AndThen(numword.Ordinal, func(n int64) Parser[int] {
+				if n < 1 || n > 31 {
+					return Fail[int]
+				}
+				return Succeed(int(n))
+			}),
+			AndThen(number(1, 2), func(n int) Parser[int] {
+				if n < 1 || n > 31 {
+					return Fail[int]
+				}
+				return Succeed(n)
+			}),
+		)
+		comma := AppendSkipping(StartSkipping(OneOf(Exactly(","), Succeed(Empty{}))), space)
+		year := OneOf(Apply(AppendKeeping(StartSkipping(comma), number(4, 4)), func(y int) int { return y }), Succeed(0))
+		s := StartKeeping(lookup(months))
+		s1 := AppendSkipping(s, space)
+		s2 := AppendKeeping(s1, day)
+		s3 := AppendKeeping(s2, year)
+		monthDay := Apply3(s3, func(m time.Month, d int, y int) resolve { return calendar(y, m, d) })
+		of := OneOf(AppendSkipping(AppendSkipping(StartSkipping(space), keyword("of")), space), AppendSkipping(StartSkipping(space), Succeed(Empty{})))
+		s4 := StartKeeping(day)
+		s5 := AppendSkipping(s4, of)
+		s6 := AppendKeeping(s5, lookup(months))
+		s7 := AppendKeeping(s6, year)
+		dayMonth := Apply3(s7, func(d int, m time.Month, y int) resolve { return calendar(y, m, d) })
+
+		s8 := StartKeeping(number(4, 4))
+		s9 := AppendSkipping(s8, Exactly("-"))
+		s10 := AppendKeeping(s9, number(2, 2))
+		s11 := AppendSkipping(s10, Exactly("-"))
+		s12 := AppendKeeping(s11, number(2, 2))
+		iso := Apply3(s12, func(y, m, d int) resolve { return calendar(y, time.Month(m), d) })
+
+		dateParser = OneOf(relative, which, monthDay, dayMonth, iso)
+	}
+
+	var timeParser Parser[clock]
+	{
+		named := OneOf(
+			Map(keyword("noon"), func(Empty) clock { return clock{hour: 12} }),
+			Map(keyword("midnight"), func(Empty) clock { return clock{} }),
+		)
+		colonTwo := Apply(AppendKeeping(StartSkipping(Exactly(":")), number(2, 2)), func(n int) int { return n })
+		minutes := OneOf(colonTwo, Succeed(-1))
+		seconds := OneOf(colonTwo, Succeed(0))
+		s := StartKeeping(number(1, 2))
+		s1 := AppendKeeping(s, minutes)
+		s2 := AppendKeeping(s1, seconds)
+		hms := Apply3(s2, func(h, m, s int) clock { return clock{hour: h, minute: m, second: s} })
+		meridiem := OneOf(
+			Map(keyword("am"), func(Empty) int { return 0 }),
+			Map(keyword("pm"), func(Empty) int { return 12 }),
+		)
+		s3 := StartKeeping(hms)
+		s4 := AppendSkipping(s3, OneOf(space, Succeed(Empty{})))
+		s5 := AppendKeeping(s4, meridiem)
+		twelve := AndThen(Apply2(s5, func(c clock, add int) clock {
+			if c.hour < 1 || c.hour > 12 {
+				return clock{hour: -1}
+			}
+			c.hour = c.hour%12 + add
+			if c.minute < 0 {
+				c.minute = 0
+			}
+			return c
+		}), valid)
+		twentyFour := AndThen(hms, func(c clock) Parser[clock] {
+			if c.minute < 0 {
+				return Fail[clock]
+			}
+			return valid(c)
+		})
+		zone := OneOf(
+			Map(OneOf(keyword("utc"), keyword("gmt")), func(Empty) *time.Location { return time.UTC }),
+			Map(timezone.Numeric, timezone.Offset.Location),
+		)
+		s6 := StartKeeping(OneOf(named, twelve, twentyFour))
+		s7 := AppendKeeping(s6, OneOf(
+			Apply(AppendKeeping(StartSkipping(OneOf(space, Succeed(Empty{}))), zone), func(z *time.Location) *time.Location { return z }),
+			Succeed[*time.Location](nil),
+		))
+		timeParser = Apply2(s7, func(c clock, zone *time.Location) clock {
+			c.zone = zone
+			return c
+		})
+	}
+
+	var expressionParser Parser[resolve]
+	{
+		at := OneOf(
+			AppendSkipping(AppendSkipping(StartSkipping(space), keyword("at")), space),
+			AppendSkipping(AppendSkipping(StartSkipping(Exactly(",")), space), Succeed(Empty{})),
+			AppendSkipping(StartSkipping(space), Succeed(Empty{})),
+		)
+		// atTime parses a time after a date or offset, if there is one.
+		atTime := OneOf(
+			Apply(AppendKeeping(StartSkipping(at), timeParser), func(c clock) *clock { return &c }),
+			Succeed[*clock](nil),
+		)
+
+		current := Map(keyword("now"), func(Empty) resolve {
+			return func(now time.Time) (time.Time, bool) { return now, true }
+		})
+
+		s := StartKeeping(offsetParser)
+		s1 := AppendKeeping(s, atTime)
+		shifted := AndThen(Apply2(s1, func(o offset, c *clock) offsetAt { return offsetAt{o, c} }),
+			func(oc offsetAt) Parser[resolve] {
+				shift := func(now time.Time) (time.Time, bool) { return oc.offset.add(now), true }
+				switch {
+				case oc.clock == nil:
+					return Succeed[resolve](shift)
+				case !oc.offset.daily():
+					return Fail[resolve]
+				}
+				return Succeed(oc.clock.on(shift))
+			})
+
+		s2 := StartKeeping(dateParser)
+		s3 := AppendKeeping(s2, atTime)
+		dated := Apply2(s3, func(r resolve, c *clock) resolve {
+			if c == nil {
+				return r
+			}
+			return c.on(r)
+		})
+
+		on := OneOf(
+			AppendSkipping(AppendSkipping(StartSkipping(space), keyword("on")), space),
+			AppendSkipping(StartSkipping(space), Succeed(Empty{})),
+		)
+		s4 := StartKeeping(timeParser)
+		s5 := AppendKeeping(s4, OneOf(Apply(AppendKeeping(StartSkipping(on), dateParser), func(r resolve) resolve { return r }), Succeed(days(0))))
+		timed := Apply2(s5, func(c clock, r resolve) resolve { return c.on(r) })
+
+		expressionParser = OneOf(current, shifted, dated, timed)
+	}
+
+	return AndThen(expressionParser, func(r resolve) Parser[time.Time] {
+		t, ok := r(now().In(loc))
+		if !ok {
+			return Fail[time.Time]
+		}
+		return Succeed(t)
+	})
+}
+
+// valid succeeds with c if it is a time of day, and fails otherwise.
+func valid(c clock) Parser[clock] {
+	if c.hour < 0 || c.hour > 23 || c.minute > 59 || c.second > 59 {
+		return Fail[clock]
+	}
+	return Succeed(c)
+}
+
+// weekday returns a resolve for the start of the next day which is w: the first on or after
+// today if direction is 0, after today if it is 1, and the last before today if it is -1.
+func weekday(w time.Weekday, direction int) resolve {
+	return func(now time.Time) (time.Time, bool) {
+		n := (int(w) - int(now.Weekday()) + 7) % 7
+		switch {
+		case direction > 0 && n == 0:
+			n = 7
+		case direction < 0:
+			n = (int(w) - int(now.Weekday()) - 7) % 7
+			if n == 0 {
+				n = -7
+			}
+		}
+		return midnight(now).AddDate(0, 0, n), true
+	}
+}
+
+// calendar returns a resolve for the start of the day d of month m in year y, or in the year of
+// the reference time if y is 0.  It resolves to nothing if there is no such day.
+func calendar(y int, m time.Month, d int) resolve {
+	return func(now time.Time) (time.Time, bool) {
+		year := y
+		if year == 0 {
+			year = now.Year()
+		}
+		t := time.Date(year, m, d, 0, 0, 0, 0, now.Location())
+		return t, t.Month() == m && t.Day() == d
+	}
+}
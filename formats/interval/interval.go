@@ -0,0 +1,306 @@
+// Package interval provides a parser for the range syntaxes used by query languages and
+// dependency constraints, producing a structured Interval:
+//
+//	1..5           both ends included
+//	3..  ..7       one end unbounded
+//	[1,10)  (0,1]  mathematical notation; an end may be left empty
+//	>=1.2 <2.0     comparator constraints, separated by spaces or commas
+//	=1.4  1.4      a single value
+//
+// The endpoint syntax is up to the caller: Number and VersionNumber are provided, and any
+// Parser[T] will do.
+package interval
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// ErrEmptyInterval is returned when an interval's lower end is above its upper end.
+var ErrEmptyInterval = errors.New("empty interval")
+
+// Bound is one end of an Interval.
+type Bound[T any] struct {
+	Value     T    // Meaningless if Unbounded.
+	Inclusive bool // Whether Value itself is in the interval.
+	Unbounded bool // Whether the interval extends forever in this direction.
+}
+
+// Interval is a contiguous range of values of type T.
+type Interval[T any] struct {
+	Lower Bound[T]
+	Upper Bound[T]
+}
+
+// Contains reports whether v lies in the interval, using compare to order values: it must
+// return a negative number, zero, or a positive number when a < b, a == b, or a > b.
+func (i Interval[T]) Contains(v T, compare func(a, b T) int) bool {
+	if !i.Lower.Unbounded {
+		c := compare(i.Lower.Value, v)
+		if c > 0 || c == 0 && !i.Lower.Inclusive {
+			return false
+		}
+	}
+	if !i.Upper.Unbounded {
+		c := compare(v, i.Upper.Value)
+		if c > 0 || c == 0 && !i.Upper.Inclusive {
+			return false
+		}
+	}
+	return true
+}
+
+// Syntax is a set of range notations accepted by a parser made with New.
+type Syntax int
+
+const (
+	DotDot      Syntax = 1 << iota // a..b, a.., ..b
+	Brackets                       // [a,b], (a,b), and mixtures
+	Comparators                    // >=a <b, =a
+	Single                         // a
+	AllSyntax   = DotDot | Brackets | Comparators | Single
+)
+
+// Spec configures a parser made with New.
+type Spec[T any] struct {
+	Value   Parser[T]        // Parses an endpoint.
+	Compare func(a, b T) int // If not nil, used to reject empty intervals with ErrEmptyInterval.
+	Syntax  Syntax           // Which notations to accept; zero means AllSyntax.
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+var ws = ConsumeWhile(isSpace)
+
+// token parses s with optional whitespace either side.
+func token(s string) Parser[Empty] {
+	return AppendSkipping(AppendSkipping(StartSkipping(ws), Exactly(s)), ws)
+}
+
+func unbounded[T any]() Bound[T] {
+	return Bound[T]{Unbounded: true}
+}
+
+func inclusive[T any](v T) Bound[T] {
+	return Bound[T]{Value: v, Inclusive: true}
+}
+
+// optional parses an endpoint value or nothing, giving an unbounded Bound for nothing.
+func optional[T any](value Parser[T], include bool) Parser[Bound[T]] {
+	return OneOf(
+		Map(value, func(v T) Bound[T] { return Bound[T]{Value: v, Inclusive: include} }),
+		Succeed(unbounded[T]()),
+	)
+}
+
+// New returns a Parser[Interval[T]] for the notations selected by spec.
+func New[T any](spec Spec[T]) Parser[Interval[T]] {
+	syntax := spec.Syntax
+	if syntax == 0 {
+		syntax = AllSyntax
+	}
+	var forms []Parser[Interval[T]]
+	if syntax&Brackets != 0 {
+		forms = append(forms, brackets(spec.Value))
+	}
+	if syntax&Comparators != 0 {
+		forms = append(forms, comparators(spec.Value))
+	}
+	if syntax&DotDot != 0 {
+		forms = append(forms, dotDot(spec.Value))
+	}
+	if syntax&Single != 0 {
+		forms = append(forms, Map(spec.Value, func(v T) Interval[T] {
+			return Interval[T]{Lower: inclusive(v), Upper: inclusive(v)}
+		}))
+	}
+	parser := OneOf(forms...)
+	if spec.Compare == nil {
+		return parser
+	}
+	return AndThen(parser, func(i Interval[T]) Parser[Interval[T]] {
+		if !i.Lower.Unbounded && !i.Upper.Unbounded {
+			c := spec.Compare(i.Lower.Value, i.Upper.Value)
+			if c > 0 || c == 0 && !(i.Lower.Inclusive && i.Upper.Inclusive) {
+				return func(initial State) (Interval[T], State, error) {
+					return Interval[T]{}, initial, ErrEmptyInterval
+				}
+			}
+		}
+		return Succeed(i)
+	})
+}
+
+// brackets parses [a,b] and its variants.
+func brackets[T any](value Parser[T]) Parser[Interval[T]] {
+	open := OneOf(
+		Map(Exactly("["), func(Empty) bool { return true }),
+		Map(Exactly("("), func(Empty) bool { return false }),
+	)
+	close := OneOf(
+		Map(Exactly("]"), func(Empty) bool { return true }),
+		Map(Exactly(")"), func(Empty) bool { return false }),
+	)
+	return AndThen(open, func(lowerInclusive bool) Parser[Interval[T]] {
+		s := StartSkipping(ws)
+		s1 := AppendKeeping(s, optional(value, lowerInclusive))
+		s2 := AppendSkipping(s1, token(","))
+		s3 := AppendKeeping(s2, optional(value, true))
+		s4 := AppendSkipping(s3, ws)
+		s5 := AppendKeeping(s4, close)
+		return Apply3(s5, func(lower Bound[T], upper Bound[T], upperInclusive bool) Interval[T] {
+			if lower.Unbounded {
+				lower.Inclusive = false
+			}
+			upper.Inclusive = upperInclusive && !upper.Unbounded
+			return Interval[T]{Lower: lower, Upper: upper}
+		})
+	})
+}
+
+// dotDot parses a..b, a.. and ..b.
+func dotDot[T any](value Parser[T]) Parser[Interval[T]] {
+	fromLower := AndThen(value, func(v T) Parser[Interval[T]] {
+		s := StartSkipping(token(".."))
+		s1 := AppendKeeping(s, optional(value, true))
+		return Apply(s1, func(upper Bound[T]) Interval[T] {
+			return Interval[T]{Lower: inclusive(v), Upper: upper}
+		})
+	})
+	s := StartSkipping(token(".."))
+	s1 := AppendKeeping(s, value)
+	toUpper := Apply(s1, func(v T) Interval[T] {
+		return Interval[T]{Lower: unbounded[T](), Upper: inclusive(v)}
+	})
+	return OneOf(fromLower, toUpper)
+}
+
+// comparator is one ">=1.2" style constraint.
+type comparator[T any] struct {
+	op    string
+	value T
+}
+
+// comparators parses one or two constraints such as ">=1.2 <2.0".
+func comparators[T any](value Parser[T]) Parser[Interval[T]] {
+	constraint := AndThen(ExactlyAnyFold(">=", ">", "<=", "<", "="), func(op string) Parser[comparator[T]] {
+		return Apply(AppendKeeping(StartSkipping(ws), value), func(v T) comparator[T] {
+			return comparator[T]{op: op, value: v}
+		})
+	})
+	separator := OneOf(token(","), StartSkipping(ConsumeSome(isSpace)))
+	return Loop(Interval[T]{Lower: unbounded[T](), Upper: unbounded[T]()},
+		func(i Interval[T]) Parser[Step[Interval[T], Interval[T]]] {
+			started := !i.Lower.Unbounded || !i.Upper.Unbounded
+			next := constraint
+			if started {
+				next = Apply(AppendKeeping(StartSkipping(separator), constraint), func(c comparator[T]) comparator[T] { return c })
+			}
+			extend := AndThen(next, func(c comparator[T]) Parser[Step[Interval[T], Interval[T]]] {
+				lower := strings.HasPrefix(c.op, ">") || c.op == "="
+				upper := strings.HasPrefix(c.op, "<") || c.op == "="
+				if lower && !i.Lower.Unbounded || upper && !i.Upper.Unbounded {
+					// Two constraints on the same end.
+					return Fail[Step[Interval[T], Interval[T]]]
+				}
+				bound := Bound[T]{Value: c.value, Inclusive: strings.HasSuffix(c.op, "=")}
+				if lower {
+					i.Lower = bound
+				}
+				if upper {
+					i.Upper = bound
+				}
+				return Succeed(Step[Interval[T], Interval[T]]{Accum: i})
+			})
+			if !started {
+				return extend
+			}
+			return OneOf(extend, Succeed(Step[Interval[T], Interval[T]]{Done: true, Value: i}))
+		})
+}
+
+// Number is a Parser[float64] for an optionally signed decimal number such as 3, -1.5 or 0.25.
+// It never treats a "." as part of the number unless a digit follows, so "1..5" reads as 1 and 5.
+var Number = AndThen(
+	GetString(AppendSkipping(
+		AppendSkipping(
+			StartSkipping(OneOf(Exactly("-"), Exactly("+"), Succeed(Empty{}))),
+			ConsumeSome(isDigit)),
+		OneOf(AppendSkipping(StartSkipping(Exactly(".")), ConsumeSome(isDigit)), Succeed(Empty{})))),
+	func(text string) Parser[float64] {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Fail[float64]
+		}
+		return Succeed(f)
+	})
+
+// CompareNumbers orders float64s, for use as Spec.Compare and with Interval.Contains.
+func CompareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Version is a dotted version number such as 1.2 or 2.0.13, with an optional leading "v".
+type Version []int
+
+// VersionNumber is a Parser[Version] for a dotted version number.  Like Number, it only takes
+// a "." which is followed by a digit, so "1.2..2.0" reads as 1.2 and 2.0.
+var VersionNumber Parser[Version] = func(initial State) (Version, State, error) {
+	rest := initial.Remaining()
+	i := 0
+	if strings.HasPrefix(rest, "v") {
+		i++
+	}
+	var v Version
+	for {
+		j := i
+		for j < len(rest) && isDigit(rune(rest[j])) {
+			j++
+		}
+		if j == i {
+			return nil, initial, ErrNoMatch
+		}
+		n, err := strconv.Atoi(rest[i:j])
+		if err != nil {
+			return nil, initial, ErrNoMatch
+		}
+		v = append(v, n)
+		if j+1 >= len(rest) || rest[j] != '.' || !isDigit(rune(rest[j+1])) {
+			return v, initial.Consume(j), nil
+		}
+		i = j + 1
+	}
+}
+
+// CompareVersions orders Versions component by component, treating missing components as
+// zero, so 1.2 and 1.2.0 are equal.
+func CompareVersions(a, b Version) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return CompareNumbers(float64(x), float64(y))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+// Package labels provides a configurable parser for the key=value label strings found all
+// over operational tooling: Prometheus label sets (`job="api",env="prod"`), Kubernetes
+// selectors (`app=web,tier=frontend`), and logfmt lines (`level=info msg="hello world"`).
+package labels
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Labels is an ordered list of keys and values, in input order.
+type Labels []Field[string]
+
+// Get returns the value of the first label with the key, and whether there was one.
+func (l Labels) Get(key string) (string, bool) {
+	for _, f := range l {
+		if f.Name == key {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Options configures the syntax accepted by New.
+type Options struct {
+	Separator  string          // Between pairs, e.g. "," or " ".
+	Assign     string          // Between a key and its value, e.g. "=".
+	Quote      rune            // Opens and closes a quoted value; 0 means values can't be quoted.
+	Escape     rune            // Inside a quoted value, takes the next rune literally; 0 means no escapes.
+	MustQuote  bool            // Whether every value must be quoted.
+	Spaces     bool            // Whether spaces and tabs may surround Separator and Assign.
+	KeyRune    func(rune) bool // Which runes may appear in a key; nil means letters, digits and "_./-".
+	Duplicates DuplicatePolicy // What to do when a key repeats.
+}
+
+// Prometheus is the syntax of labels in a Prometheus selector or exposition line, with the
+// surrounding braces removed: `a="1", b="two"`.  Values must be quoted.
+var Prometheus = Options{
+	Separator: ",",
+	Assign:    "=",
+	Quote:     '"',
+	Escape:    '\\',
+	MustQuote: true,
+	Spaces:    true,
+	KeyRune: func(r rune) bool {
+		return r == '_' || r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+	},
+}
+
+// Selector is the syntax of a Kubernetes equality-based label selector: `app=web,tier=frontend`.
+var Selector = Options{
+	Separator: ",",
+	Assign:    "=",
+	Spaces:    true,
+	KeyRune: func(r rune) bool {
+		return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) || strings.ContainsRune("_./-", r)
+	},
+	Duplicates: KeepAll,
+}
+
+// Logfmt is the syntax of a logfmt line: `level=info msg="hello world" n=3`.
+var Logfmt = Options{
+	Separator:  " ",
+	Assign:     "=",
+	Quote:      '"',
+	Escape:     '\\',
+	Duplicates: KeepAll,
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+func isKeyRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_./-", r)
+}
+
+// New returns a Parser[Labels] for zero or more key/value pairs in the syntax described by options.
+// With Spaces set, leading and trailing spaces are allowed too.  A value is either quoted, in which
+// case escapes are decoded, or a possibly empty run of runes other than quotes, spaces and the separator.
+func New(options Options) Parser[Labels] {
+	keyRune := options.KeyRune
+	if keyRune == nil {
+		keyRune = isKeyRune
+	}
+	padded := func(token string) Parser[Empty] {
+		if !options.Spaces {
+			return Exactly(token)
+		}
+		ws := ConsumeWhile(isSpace)
+		return AppendSkipping(AppendSkipping(StartSkipping(ws), Exactly(token)), ws)
+	}
+	separator := padded(options.Separator)
+	if strings.TrimSpace(options.Separator) == "" {
+		// A separator made of spaces absorbs runs of spaces.
+		separator = ConsumeSome(isSpace)
+	}
+	bare := TakeSome(func(r rune) bool {
+		return r != options.Quote && !isSpace(r) && !strings.ContainsRune(options.Separator, r)
+	})
+	value := OneOf(bare, Succeed(""))
+	if options.Quote != 0 {
+		quoted := Map(GetString(Quoted(options.Quote, options.Escape)), func(text string) string {
+			return unquote(text, options.Escape)
+		})
+		value = OneOf(quoted, value)
+		if options.MustQuote {
+			value = quoted
+		}
+	}
+	record := Record(RecordSpec[string]{
+		Name:       TakeSome(keyRune),
+		Assign:     padded(options.Assign),
+		Separator:  separator,
+		Fallback:   value,
+		Unknown:    KeepUnknown,
+		Duplicates: options.Duplicates,
+	})
+	labels := Map(record, func(fields []Field[string]) Labels { return Labels(fields) })
+	if !options.Spaces {
+		return labels
+	}
+	ws := ConsumeWhile(isSpace)
+	s := StartSkipping(ws)
+	s1 := AppendKeeping(s, labels)
+	s2 := AppendSkipping(s1, ws)
+	return Apply(s2, func(l Labels) Labels { return l })
+}
+
+// unquote strips the quotes from text, which was matched by Quoted, and decodes its escapes.
+func unquote(text string, escape rune) string {
+	_, w := utf8.DecodeRuneInString(text)
+	text = text[w : len(text)-w]
+	if escape == 0 || !strings.ContainsRune(text, escape) {
+		return text
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			switch r {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteRune(r)
+			}
+			escaped = false
+		case r == escape:
+			escaped = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
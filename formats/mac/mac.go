@@ -0,0 +1,114 @@
+// Package mac provides parsers for hardware addresses: 48-bit MAC addresses (EUI-48), 64-bit
+// EUI-64 identifiers, and the 24-bit organizationally unique identifiers (OUIs) which begin them.
+//
+// Here is the grammar the parsers follow, where N is the number of bytes in the address:
+//
+//	address:  octet (':' octet){N-1} | octet ('-' octet){N-1} | quad ('.' quad){N/2-1}
+//
+//	octet:    hex hex
+//
+//	quad:     hex hex hex hex
+//
+// So "00:1a:2b:3c:4d:5e", "00-1A-2B-3C-4D-5E" and "001a.2b3c.4d5e" are all the same MAC address.
+// Hexadecimal digits may be in either case.  An OUI has no dot notation.
+package mac
+
+import (
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+func unhex(b byte) (byte, bool) {
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0', true
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10, true
+	case b >= 'A' && b <= 'F':
+		return b - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// hexBytes returns a parser for 2*n hexadecimal digits, giving the n bytes they stand for.
+func hexBytes(n int) Parser[[]byte] {
+	return func(initial State) ([]byte, State, error) {
+		rest := initial.Remaining()
+		if len(rest) < 2*n {
+			return nil, initial, ErrNoMatch
+		}
+		b := make([]byte, n)
+		for i := range b {
+			hi, ok1 := unhex(rest[2*i])
+			lo, ok2 := unhex(rest[2*i+1])
+			if !ok1 || !ok2 {
+				return nil, initial, ErrNoMatch
+			}
+			b[i] = hi<<4 | lo
+		}
+		return b, initial.Consume(2 * n), nil
+	}
+}
+
+// separated returns a parser for count groups of size bytes each, separated by separator,
+// giving all their bytes in order.
+func separated(count, size int, separator string) Parser[[]byte] {
+	group := hexBytes(size)
+	next := Apply(AppendKeeping(StartSkipping(Exactly(separator)), group), func(b []byte) []byte { return b })
+	return Loop([]byte(nil), func(address []byte) Parser[Step[[]byte, []byte]] {
+		if len(address) == count*size {
+			return Succeed(Step[[]byte, []byte]{Done: true, Value: address})
+		}
+		p := next
+		if address == nil {
+			p = group
+		}
+		return Map(p, func(b []byte) Step[[]byte, []byte] { return Step[[]byte, []byte]{Accum: append(address, b...)} })
+	})
+}
+
+// address returns a parser for an address of n bytes, in any of the notations, which must be
+// a whole address: it mustn't be followed by another hexadecimal digit, alone or after a
+// separator, so that MAC48 doesn't match the start of an EUI-64.
+func address(n int, dots bool) Parser[[]byte] {
+	notations := []Parser[[]byte]{separated(n, 1, ":"), separated(n, 1, "-")}
+	if dots {
+		notations = append(notations, separated(n/2, 2, "."))
+	}
+	parser := OneOf(notations...)
+	return func(initial State) ([]byte, State, error) {
+		b, next, err := parser(initial)
+		if err != nil {
+			return nil, initial, err
+		}
+		rest := next.Remaining()
+		if len(rest) > 0 && (rest[0] == ':' || rest[0] == '-' || rest[0] == '.') {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			if _, ok := unhex(rest[0]); ok {
+				return nil, initial, ErrNoMatch
+			}
+		}
+		return b, next, nil
+	}
+}
+
+// MAC48 is a Parser[[6]byte] for a 48-bit MAC address, such as "00:1a:2b:3c:4d:5e".
+var MAC48 = Map(address(6, true), func(b []byte) (a [6]byte) {
+	copy(a[:], b)
+	return a
+})
+
+// EUI64 is a Parser[[8]byte] for a 64-bit extended unique identifier, such as
+// "00:1a:2b:ff:fe:3c:4d:5e" or "001a.2bff.fe3c.4d5e".
+var EUI64 = Map(address(8, true), func(b []byte) (a [8]byte) {
+	copy(a[:], b)
+	return a
+})
+
+// OUI is a Parser[[3]byte] for an organizationally unique identifier, the prefix of an address
+// which identifies its manufacturer, such as "00:1A:2B" or "00-1A-2B".
+var OUI = Map(address(3, false), func(b []byte) (a [3]byte) {
+	copy(a[:], b)
+	return a
+})
@@ -0,0 +1,98 @@
+// Package multipart provides parsers for the delimiter lines and header blocks of MIME multipart
+// bodies, as defined by RFC 2046 and used by mail and by HTTP form uploads, and a Reader which
+// uses them to go through a body part by part as it streams in, without holding any more of it
+// in memory than a line at a time.
+//
+// Here is the grammar the parsers follow:
+//
+//	delimiter:     '--' boundary ['--'] padding     -- with '--' at the end, the close delimiter
+//
+//	padding:       (' ' | '\t')*
+//
+//	header-block:  field* line-break
+//
+//	field:         name ':' text (line-break (' ' | '\t') text)* line-break
+//
+//	line-break:    '\r\n' | '\n'
+//
+// A delimiter is a whole line, given without its line break.  A field's name is any printable
+// ASCII other than ':'; its value is unfolded, as RFC 5322 has it, by removing the line breaks
+// which go before continuation lines, and has surrounding whitespace removed.
+package multipart
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// ErrHeaderTooLarge is returned by Reader.NextPart for a header block longer than MaxHeaderBytes.
+var ErrHeaderTooLarge = errors.New("multipart: header block too large")
+
+// ErrMissingBoundary is returned by Reader.NextPart when the input ends without the delimiter
+// which should have come next.
+var ErrMissingBoundary = errors.New("multipart: missing boundary")
+
+// MaxHeaderBytes is the most a Reader will read of the header block of any one part.
+const MaxHeaderBytes = 64 << 10
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// Delimiter returns a Parser[bool] for a delimiter line with the given boundary, which succeeds
+// with true for the close delimiter, which ends the body, and false for any other.
+func Delimiter(boundary string) Parser[bool] {
+	s := StartSkipping(Exactly("--" + boundary))
+	s1 := AppendKeeping(s, OneOf(Map(Exactly("--"), func(Empty) bool { return true }), Succeed(false)))
+	s2 := AppendSkipping(s1, ConsumeWhile(isSpace))
+	return Apply(s2, func(close bool) bool { return close })
+}
+
+var (
+	lineBreak = OneOf(Exactly("\r\n"), Exactly("\n"))
+	text      = TakeWhile(func(r rune) bool { return r != '\r' && r != '\n' })
+	name      = TakeSome(func(r rune) bool { return r > ' ' && r < 0x7f && r != ':' })
+
+	// continuation parses a line break and the continuation line after it, and returns the
+	// continuation line, including its leading whitespace.
+	continuation = Apply(AppendKeeping(StartSkipping(lineBreak), GetString(AppendSkipping(StartSkipping(ConsumeSome(isSpace)), text))),
+		func(line string) string { return line })
+)
+
+// value parses a field's text and its continuation lines, and returns them unfolded.
+var value = AndThen(text, func(first string) Parser[string] {
+	return Loop(first, func(v string) Parser[Step[string, string]] {
+		return OneOf(
+			Map(continuation, func(line string) Step[string, string] { return Step[string, string]{Accum: v + line} }),
+			Succeed(Step[string, string]{Done: true, Value: strings.TrimSpace(v)}),
+		)
+	})
+})
+
+// field parses one header field.
+var field = func() Parser[Field[string]] {
+	s := StartKeeping(name)
+	s1 := AppendSkipping(s, Exactly(":"))
+	s2 := AppendKeeping(s1, value)
+	s3 := AppendSkipping(s2, lineBreak)
+	return Apply2(s3, func(name, value string) Field[string] {
+		return Field[string]{Name: strings.ToLower(name), Value: value}
+	})
+}()
+
+// HeaderBlock is a Parser[[]Field[string]] for the header block at the start of a part, up to
+// and including the empty line which ends it, returning its fields in input order.  Field names
+// are lower-cased.
+var HeaderBlock = func() Parser[[]Field[string]] {
+	fields := Loop([]Field[string]{}, func(fields []Field[string]) Parser[Step[[]Field[string], []Field[string]]] {
+		return OneOf(
+			Map(field, func(f Field[string]) Step[[]Field[string], []Field[string]] {
+				return Step[[]Field[string], []Field[string]]{Accum: append(fields, f)}
+			}),
+			Succeed(Step[[]Field[string], []Field[string]]{Done: true, Value: fields}),
+		)
+	})
+	return Apply(AppendSkipping(StartKeeping(fields), lineBreak), func(fields []Field[string]) []Field[string] { return fields })
+}()
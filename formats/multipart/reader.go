@@ -0,0 +1,185 @@
+package multipart
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Reader goes through the parts of a multipart body as it is read from an io.Reader.  The
+// preamble before the first delimiter and the epilogue after the close delimiter are skipped.
+type Reader struct {
+	r         *bufio.Reader
+	delimiter Parser[bool]
+	part      *Part // The part most recently returned by NextPart.
+	started   bool  // Whether the first delimiter has been read.
+	done      bool  // Whether the close delimiter has been read.
+}
+
+// NewReader returns a Reader for the multipart body read from r, whose parts are separated by
+// delimiters with the given boundary, as found in the boundary parameter of its Content-Type.
+func NewReader(r io.Reader, boundary string) *Reader {
+	return &Reader{r: bufio.NewReader(r), delimiter: Delimiter(boundary)}
+}
+
+// A Part is a single part of a multipart body: its header, and its content, which is read from
+// the Part itself.  The content is read from the body as the Part is read, a line at a time.
+type Part struct {
+	Header []Field[string] // With lower-cased names, in input order.
+
+	mr      *Reader
+	pending []byte // Content read from the body which hasn't been returned yet.
+	held    []byte // The last line's line break, which is content only if more content follows.
+	midLine bool   // Whether the last read stopped partway through a line too long to buffer.
+	eof     bool   // Whether the delimiter after the part has been read.
+}
+
+// Get returns the value of the first header field with the given name, ignoring case, and
+// whether there was one.
+func (p *Part) Get(name string) (string, bool) {
+	name = strings.ToLower(name)
+	for _, f := range p.Header {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Read reads the part's content, returning io.EOF at the delimiter which ends it.  The line break
+// before the delimiter belongs to the delimiter, not the content.  If the body ends before the
+// delimiter, Read returns ErrMissingBoundary.
+func (p *Part) Read(b []byte) (int, error) {
+	for len(p.pending) == 0 {
+		if p.eof {
+			return 0, io.EOF
+		}
+		if err := p.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+// fill reads the next line of the body, which is either content or the delimiter ending the part.
+func (p *Part) fill() error {
+	atLineStart := !p.midLine
+	content, lineBreak, partial, err := p.mr.readLine()
+	if err == io.EOF {
+		return ErrMissingBoundary
+	}
+	if err != nil {
+		return err
+	}
+	if atLineStart && !partial {
+		if close, ok := p.mr.isDelimiter(content); ok {
+			p.eof, p.mr.done = true, close
+			return nil
+		}
+	}
+	pending := make([]byte, 0, len(p.held)+len(content))
+	p.pending = append(append(pending, p.held...), content...)
+	p.held = append([]byte(nil), lineBreak...)
+	p.midLine = partial
+	return nil
+}
+
+// NextPart returns the next part of the body, skipping whatever is left of the last one, or
+// io.EOF after the last part.  A part's header block which doesn't parse gives the *ParseError
+// from HeaderBlock, for the block on its own.
+func (r *Reader) NextPart() (*Part, error) {
+	if r.part != nil && !r.part.eof {
+		if _, err := io.Copy(io.Discard, r.part); err != nil {
+			return nil, err
+		}
+	}
+	if !r.started {
+		if err := r.skipPreamble(); err != nil {
+			return nil, err
+		}
+		r.started = true
+	}
+	if r.done {
+		return nil, io.EOF
+	}
+	var header strings.Builder
+	for {
+		content, lineBreak, partial, err := r.readLine()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		header.Write(content)
+		header.Write(lineBreak)
+		if header.Len() > MaxHeaderBytes {
+			return nil, ErrHeaderTooLarge
+		}
+		if len(content) == 0 && !partial {
+			break
+		}
+	}
+	fields, err := Parse(HeaderBlock, header.String())
+	if err != nil {
+		return nil, err
+	}
+	r.part = &Part{Header: fields, mr: r}
+	return r.part, nil
+}
+
+// skipPreamble reads up to and including the first delimiter.
+func (r *Reader) skipPreamble() error {
+	midLine := false
+	for {
+		content, _, partial, err := r.readLine()
+		if err == io.EOF {
+			return ErrMissingBoundary
+		}
+		if err != nil {
+			return err
+		}
+		if !midLine && !partial {
+			if close, ok := r.isDelimiter(content); ok {
+				r.done = close
+				return nil
+			}
+		}
+		midLine = partial
+	}
+}
+
+// isDelimiter reports whether line is a delimiter, and if so whether it is the close delimiter.
+func (r *Reader) isDelimiter(line []byte) (close bool, ok bool) {
+	if !bytes.HasPrefix(line, []byte("--")) {
+		return false, false
+	}
+	close, err := Parse(r.delimiter, string(line))
+	return close, err == nil
+}
+
+// readLine reads the next line of the body, returning its content and line break separately.
+// They are only valid until the next read.  A line too long for the buffer comes back in pieces,
+// each but the last with partial set and no line break.  At the end of the body, readLine returns
+// io.EOF, unless there is an unterminated last line to return first.
+func (r *Reader) readLine() (content, lineBreak []byte, partial bool, err error) {
+	line, err := r.r.ReadSlice('\n')
+	switch {
+	case err == bufio.ErrBufferFull:
+		return line, nil, true, nil
+	case err == io.EOF && len(line) > 0:
+		return line, nil, false, nil
+	case err != nil:
+		return nil, nil, false, err
+	}
+	end := len(line) - 1
+	if end > 0 && line[end-1] == '\r' {
+		end--
+	}
+	return line[:end], line[end:], false, nil
+}
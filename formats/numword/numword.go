@@ -0,0 +1,227 @@
+// Package numword provides parsers for numbers written as English words, such as "forty-two"
+// and "one thousand two hundred and five", and for ordinals, such as "twenty-first" and "3rd",
+// for inputs that are meant to be read by people, such as "the second tuesday" in a command
+// line date expression.
+//
+// Here is the grammar the parsers follow, where each word is matched ignoring case, and must be
+// a whole word:
+//
+//	cardinal:  'zero' | (chunk scale)* [chunk]     -- at least one chunk, scales descending
+//
+//	chunk:     units 'hundred' ['and' below100] | units 'hundred' | below100
+//
+//	below100:  tens ('-' | ' ') units | tens | teens | units
+//
+//	scale:     'thousand' | 'million' | 'billion' | 'trillion'
+//
+// Chunks after the first may be preceded by 'and', as in "one thousand and one".  An ordinal is
+// written in the same way as a cardinal, except that its last word is in ordinal form: "first"
+// for "one", "twelfth" for "twelve", "twentieth" for "twenty", "hundredth" for "hundred", and
+// so on.  Words are separated by spaces, except that a hyphen may join tens and units.
+//
+// The parsers are kept out of the formats that use them, so that only the programs which want
+// English pay for the tables.
+package numword
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A form is one way of writing the words of a number: the cardinal form, or the ordinal form
+// which the last word of an ordinal takes.
+type form struct {
+	ordinal                                   bool
+	zero, units, teens, tens, hundred, scales map[string]int64
+}
+
+var cardinal = form{
+	zero: map[string]int64{"zero": 0},
+	units: map[string]int64{
+		"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9,
+	},
+	teens: map[string]int64{
+		"ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14,
+		"fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	},
+	tens: map[string]int64{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	},
+	hundred: map[string]int64{"hundred": 100},
+	scales:  map[string]int64{"thousand": 1e3, "million": 1e6, "billion": 1e9, "trillion": 1e12},
+}
+
+var ordinal = form{
+	ordinal: true,
+	zero:    map[string]int64{"zeroth": 0},
+	units: map[string]int64{
+		"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9,
+	},
+	teens: map[string]int64{
+		"tenth": 10, "eleventh": 11, "twelfth": 12, "thirteenth": 13, "fourteenth": 14,
+		"fifteenth": 15, "sixteenth": 16, "seventeenth": 17, "eighteenth": 18, "nineteenth": 19,
+	},
+	tens: map[string]int64{
+		"twentieth": 20, "thirtieth": 30, "fortieth": 40, "fiftieth": 50, "sixtieth": 60, "seventieth": 70,
+		"eightieth": 80, "ninetieth": 90,
+	},
+	hundred: map[string]int64{"hundredth": 100},
+	scales:  map[string]int64{"thousandth": 1e3, "millionth": 1e6, "billionth": 1e9, "trillionth": 1e12},
+}
+
+var (
+	letters = TakeSome(unicode.IsLetter)
+	space   = ConsumeSome(func(r rune) bool { return r == ' ' || r == '\t' })
+	// and parses the spaces before a chunk after the first, with an optional "and".
+	and = AppendSkipping(StartSkipping(space), OneOf(AppendSkipping(StartSkipping(word(map[string]int64{"and": 0})), space), Succeed(Empty{})))
+)
+
+// word parses a whole word which is in the table, ignoring case, and returns its value.
+func word(table map[string]int64) Parser[int64] {
+	return AndThen(letters, func(w string) Parser[int64] {
+		if n, ok := table[strings.ToLower(w)]; ok {
+			return Succeed(n)
+		}
+		return Fail[int64]
+	})
+}
+
+// sum returns a parser for first, then separator, then second, giving the sum of their values.
+func sum[T any](first Parser[int64], separator Parser[T], second Parser[int64]) Parser[int64] {
+	s := StartKeeping(first)
+	s1 := AppendSkipping(s, separator)
+	s2 := AppendKeeping(s1, second)
+	return Apply2(s2, func(a, b int64) int64 { return a + b })
+}
+
+// product is like sum, but gives the product of the values.
+func product[T any](first Parser[int64], separator Parser[T], second Parser[int64]) Parser[int64] {
+	s := StartKeeping(first)
+	s1 := AppendSkipping(s, separator)
+	s2 := AppendKeeping(s1, second)
+	return Apply2(s2, func(a, b int64) int64 { return a * b })
+}
+
+// below100 returns a parser for a number from 1 to 99 whose last word is in the form last.
+func below100(last form) Parser[int64] {
+	return OneOf(
+		sum(word(cardinal.tens), OneOf(Exactly("-"), space), word(last.units)),
+		word(last.tens),
+		word(last.teens),
+		word(last.units),
+	)
+}
+
+// chunk returns a parser for a number from 1 to 999 whose last word is in the form last.
+func chunk(last form) Parser[int64] {
+	rest := Apply(AppendKeeping(StartSkipping(and), below100(last)), func(n int64) int64 { return n })
+	hundreds := product(word(cardinal.units), space, word(cardinal.hundred))
+	return OneOf(
+		sum(hundreds, Succeed(Empty{}), rest),
+		product(word(cardinal.units), space, word(last.hundred)),
+		below100(last),
+	)
+}
+
+// scaled holds the total of the chunks and scales parsed so far, and the last scale, which the
+// next must be smaller than; it is zero before the first chunk.
+type scaled struct {
+	total, scale int64
+}
+
+// number returns a parser for a number whose last word is in the form last.
+func number(last form) Parser[int64] {
+	return OneOf(word(last.zero), Loop(scaled{}, func(sc scaled) Parser[Step[scaled, int64]] {
+		first, final := chunk(cardinal), chunk(last)
+		if sc.scale != 0 {
+			first = Apply(AppendKeeping(StartSkipping(and), first), func(n int64) int64 { return n })
+			final = Apply(AppendKeeping(StartSkipping(and), final), func(n int64) int64 { return n })
+		}
+		// withScale parses a chunk and a scale, in the form f, smaller than the last scale.
+		withScale := func(f form) Parser[scaled] {
+			smaller := AndThen(word(f.scales), func(scale int64) Parser[int64] {
+				if sc.scale != 0 && scale >= sc.scale {
+					return Fail[int64]
+				}
+				return Succeed(scale)
+			})
+			s := StartKeeping(first)
+			s1 := AppendSkipping(s, space)
+			s2 := AppendKeeping(s1, smaller)
+			return Apply2(s2, func(n, scale int64) scaled { return scaled{total: sc.total + n*scale, scale: scale} })
+		}
+		steps := []Parser[Step[scaled, int64]]{
+			Map(withScale(cardinal), func(next scaled) Step[scaled, int64] { return Step[scaled, int64]{Accum: next} }),
+		}
+		if last.ordinal {
+			steps = append(steps, Map(withScale(ordinal), func(next scaled) Step[scaled, int64] {
+				return Step[scaled, int64]{Done: true, Value: next.total}
+			}))
+		}
+		steps = append(steps, Map(final, func(n int64) Step[scaled, int64] {
+			return Step[scaled, int64]{Done: true, Value: sc.total + n}
+		}))
+		if !last.ordinal && sc.scale != 0 {
+			steps = append(steps, Succeed(Step[scaled, int64]{Done: true, Value: sc.total}))
+		}
+		return OneOf(steps...)
+	}))
+}
+
+// Cardinal is a Parser[int64] for a number written in words, such as "zero", "forty-two" or
+// "one million two hundred thousand and seven".
+var Cardinal = number(cardinal)
+
+// digits parses a decimal number written in digits.
+var digits = AndThen(TakeSome(func(r rune) bool { return r >= '0' && r <= '9' }), func(text string) Parser[int64] {
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return Fail[int64]
+	}
+	return Succeed(n)
+})
+
+// suffix returns the English ordinal suffix for n: "st", "nd", "rd" or "th".
+func suffix(n int64) string {
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+		return "th"
+	case n%10 == 1:
+		return "st"
+	case n%10 == 2:
+		return "nd"
+	case n%10 == 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// numeric parses an ordinal written in digits with the right suffix, such as "1st", "12th" or
+// "23rd", ignoring the case of the suffix.
+var numeric = func() Parser[int64] {
+	type written struct {
+		n      int64
+		suffix string
+	}
+	s := StartKeeping(digits)
+	s1 := AppendKeeping(s, letters)
+	return AndThen(Apply2(s1, func(n int64, suffix string) written { return written{n, suffix} }),
+		func(w written) Parser[int64] {
+			if strings.ToLower(w.suffix) != suffix(w.n) {
+				return Fail[int64]
+			}
+			return Succeed(w.n)
+		})
+}()
+
+// Ordinal is a Parser[int64] for an ordinal, written in words, such as "first", "twenty-first"
+// or "one hundredth", or in digits with a suffix, such as "1st", "22nd" or "113th".  The suffix
+// must be the right one for the number, so "11st" fails.
+var Ordinal = OneOf(numeric, number(ordinal))
+
+// Number is a Parser[int64] for a number written in digits, such as "42", or in words, as
+// Cardinal parses it.
+var Number = OneOf(digits, Cardinal)
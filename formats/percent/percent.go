@@ -0,0 +1,144 @@
+// Package percent provides parsers for percent-encoded text, as used in URLs, and for
+// application/x-www-form-urlencoded query strings.
+package percent
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// EscapeError is the error returned for a "%" which isn't followed by two hexadecimal digits.
+type EscapeError struct {
+	Offset int    // Byte offset of the "%" from the start of the input.
+	Escape string // The malformed escape, e.g. "%G1" or "%4".
+}
+
+func (e *EscapeError) Error() string {
+	return fmt.Sprintf("malformed percent escape %q at offset %d", e.Escape, e.Offset)
+}
+
+// Pair is a single key and value from a query string.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+func unhex(b byte) (byte, bool) {
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0', true
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10, true
+	case b >= 'A' && b <= 'F':
+		return b - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// Decoded returns a Parser[string] which consumes a run of runes accepted by allowed, along with
+// any percent escapes among them, and returns the text with the escapes decoded.  The allowed
+// function is never asked about "%".  The parser succeeds even if the run is empty, but fails with
+// an *EscapeError on a "%" not followed by two hexadecimal digits.  The decoded text is whatever
+// bytes the escapes stand for, which needn't be valid UTF-8.
+func Decoded(allowed func(rune) bool) Parser[string] {
+	return decoded(allowed, false)
+}
+
+// decoded is Decoded, additionally decoding "+" as a space if plusIsSpace is set.
+func decoded(allowed func(rune) bool, plusIsSpace bool) Parser[string] {
+	return func(initial State) (string, State, error) {
+		var b strings.Builder
+		rest := initial.Remaining()
+		i := 0
+		for i < len(rest) {
+			if rest[i] == '%' {
+				var hi, lo byte
+				ok := i+2 < len(rest)
+				if ok {
+					var ok2 bool
+					hi, ok = unhex(rest[i+1])
+					lo, ok2 = unhex(rest[i+2])
+					ok = ok && ok2
+				}
+				if !ok {
+					end := i + 3
+					if end > len(rest) {
+						end = len(rest)
+					}
+					return "", initial, &EscapeError{Offset: initial.Offset() + i, Escape: rest[i:end]}
+				}
+				b.WriteByte(hi<<4 | lo)
+				i += 3
+				continue
+			}
+			r, w := utf8.DecodeRuneInString(rest[i:])
+			if !allowed(r) {
+				break
+			}
+			if r == '+' && plusIsSpace {
+				b.WriteByte(' ')
+			} else {
+				b.WriteString(rest[i : i+w])
+			}
+			i += w
+		}
+		return b.String(), initial.Consume(i), nil
+	}
+}
+
+// isUnreserved reports whether r may appear unescaped in a URL component, per RFC 3986.
+func isUnreserved(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '-' || r == '.' || r == '_' || r == '~'
+}
+
+// Unreserved is a Parser[string] for percent-encoded text made of the unreserved characters
+// of RFC 3986 and escapes, returning the decoded text.
+var Unreserved = Decoded(isUnreserved)
+
+// formKey and formValue decode the two halves of a query string pair.
+var (
+	formKey = decoded(func(r rune) bool {
+		return r != '&' && r != '=' && r != '#'
+	}, true)
+	formValue = decoded(func(r rune) bool {
+		return r != '&' && r != '#'
+	}, true)
+)
+
+// pair parses a single key, optionally followed by "=" and a value.
+var pair = AndThen(formKey, func(key string) Parser[Pair] {
+	withValue := Apply(
+		AppendKeeping(StartSkipping(Exactly("=")), formValue),
+		func(value string) Pair { return Pair{Key: key, Value: value} })
+	return OneOf(withValue, Succeed(Pair{Key: key}))
+})
+
+// Query is a Parser[[]Pair] for an application/x-www-form-urlencoded query string, such as
+// "a=1&b=two+words&c=%E2%9C%93", without any leading "?".  Pairs are returned in input order,
+// and repeated keys are kept.  Keys and values are decoded, with "+" meaning space.  A key without
+// "=" gets an empty value, and empty pairs (as in "a=1&&b=2") are skipped.  The query ends at a
+// "#" or at the end of the input; a malformed escape fails it with an *EscapeError.
+var Query = Loop(nil, func(pairs []Pair) Parser[Step[[]Pair, []Pair]] {
+	next := pair
+	if pairs != nil {
+		s := StartSkipping(Exactly("&"))
+		next = Apply(AppendKeeping(s, pair), func(p Pair) Pair { return p })
+	}
+	return OneOf(
+		Map(next, func(p Pair) Step[[]Pair, []Pair] {
+			accum := pairs
+			if accum == nil {
+				accum = []Pair{}
+			}
+			if p != (Pair{}) {
+				accum = append(accum, p)
+			}
+			return Step[[]Pair, []Pair]{Accum: accum}
+		}),
+		Succeed(Step[[]Pair, []Pair]{Done: true, Value: pairs}),
+	)
+})
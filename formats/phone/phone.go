@@ -0,0 +1,96 @@
+// Package phone provides a parser for international phone numbers in the E.164 form, such as
+// "+1 (415) 555-2671 ext. 12", as typed into forms, returning them normalized as
+// "+14155552671;ext=12".
+//
+// Here is the grammar the parser follows:
+//
+//	number:     '+' group (separator? group)* extension?
+//
+//	group:      digit+ | '(' digit+ ')'
+//
+//	separator:  ' ' | '-' | '.'
+//
+//	extension:  ';ext=' digit+ | ' '* ('ext' | 'extension' | 'x' | '#') '.'? ' '* digit+
+//
+// The ext words are matched ignoring case.  A number has from 8 to 15 digits, counting the
+// country code, which doesn't begin with 0.  The parser doesn't know the numbering plans of
+// individual countries, so it can't tell whether a number is actually in use, or even
+// well-formed in its country; it only checks the shape that E.164 gives all numbers.
+package phone
+
+import (
+	"strings"
+	"unicode"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+var (
+	digits = TakeSome(isDigit)
+	spaces = ConsumeWhile(func(r rune) bool { return r == ' ' })
+
+	separator = OneOf(Exactly(" "), Exactly("-"), Exactly("."))
+
+	// group parses a run of digits, perhaps in parentheses, and returns the digits.
+	group = OneOf(digits, Apply(AppendSkipping(AppendKeeping(StartSkipping(Exactly("(")), digits), Exactly(")")),
+		func(d string) string { return d }))
+)
+
+// groups parses the groups of digits after the "+", and returns their digits run together.
+var groups = Loop("", func(number string) Parser[Step[string, string]] {
+	more := func(d string) Step[string, string] { return Step[string, string]{Accum: number + d} }
+	if number == "" {
+		return Map(group, more)
+	}
+	separated := Apply(AppendKeeping(StartSkipping(separator), group), func(d string) string { return d })
+	return OneOf(
+		Map(separated, more),
+		Map(group, more),
+		Succeed(Step[string, string]{Done: true, Value: number}),
+	)
+})
+
+// extWord parses one of the words which may introduce an extension, ignoring case.
+var extWord = AndThen(TakeSome(unicode.IsLetter), func(w string) Parser[Empty] {
+	switch strings.ToLower(w) {
+	case "ext", "extension", "x":
+		return Succeed(Empty{})
+	}
+	return Fail[Empty]
+})
+
+// extension parses an extension, and returns its digits.
+var extension = OneOf(
+	Apply(AppendKeeping(StartSkipping(Exactly(";ext=")), digits), func(d string) string { return d }),
+	func() Parser[string] {
+		s := StartSkipping(spaces)
+		s1 := AppendSkipping(s, OneOf(extWord, Exactly("#")))
+		s2 := AppendSkipping(s1, OneOf(Exactly("."), Succeed(Empty{})))
+		s3 := AppendSkipping(s2, spaces)
+		s4 := AppendKeeping(s3, digits)
+		return Apply(s4, func(d string) string { return d })
+	}(),
+)
+
+// E164 is a Parser[string] for a phone number, written with its country code, as described in
+// the package documentation.  It returns the number as "+" followed by its digits, and then, if
+// it has an extension, ";ext=" and the extension's digits, as in the tel URIs of RFC 3966.
+var E164 = func() Parser[string] {
+	s := StartSkipping(Exactly("+"))
+	s1 := AppendKeeping(s, groups)
+	s2 := AppendKeeping(s1, OneOf(extension, Succeed("")))
+	number := Apply2(s2, func(number, ext string) [2]string { return [2]string{number, ext} })
+	return AndThen(number, func(parts [2]string) Parser[string] {
+		if len(parts[0]) < 8 || len(parts[0]) > 15 || parts[0][0] == '0' {
+			return Fail[string]
+		}
+		if parts[1] == "" {
+			return Succeed("+" + parts[0])
+		}
+		return Succeed("+" + parts[0] + ";ext=" + parts[1])
+	})
+}()
@@ -0,0 +1,178 @@
+// Package quantity provides parsers for numbers with units, such as `10MiB`, `1.5GB`, `250ms`
+// and `3km`.  The units understood are given by a Units table, so the same parser serves for
+// byte sizes, durations, lengths or anything else; Bytes, Durations and Lengths are provided,
+// and SI and Binary build tables of prefixed units for any base unit.
+package quantity
+
+import (
+	"strconv"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Unit is one unit in a Units table.
+type Unit struct {
+	Symbol string  // The unit as written, e.g. "MiB".
+	Base   string  // The canonical unit it measures in, e.g. "B".
+	Factor float64 // How many Base units make one of this unit, e.g. 1048576.
+}
+
+// Units is a table of the units a parser accepts.  Symbols are matched case-sensitively, longest
+// first, so "ms" is preferred to "m" and "MiB" to "M".
+type Units []Unit
+
+// Quantity is a parsed number and the unit it was written in.
+type Quantity struct {
+	Value float64
+	Unit  Unit
+}
+
+// Normalize returns the quantity in its unit's Base unit, e.g. 1048576 for 1MiB.
+func (q Quantity) Normalize() float64 {
+	return q.Value * q.Unit.Factor
+}
+
+func (q Quantity) String() string {
+	return strconv.FormatFloat(q.Value, 'g', -1, 64) + q.Unit.Symbol
+}
+
+// Options configures a parser made with New.
+type Options struct {
+	Space    bool // Whether spaces may separate the number from its unit.
+	Negative bool // Whether the number may have a leading "-".
+	Unitless bool // Whether the unit may be left out; the value is then taken to be in the table's first unit.
+}
+
+// SI returns a table of base and its decimal multiples with SI prefixes, from k (10³) to E (10¹⁸),
+// and submultiples from m (10⁻³) to n (10⁻⁹), e.g. "km", "m" and "nm" for base "m".
+func SI(base string) Units {
+	units := Units{{Symbol: base, Base: base, Factor: 1}}
+	prefixes := []struct {
+		prefix string
+		factor float64
+	}{
+		{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15}, {"E", 1e18},
+		{"m", 1e-3}, {"µ", 1e-6}, {"u", 1e-6}, {"n", 1e-9},
+	}
+	for _, p := range prefixes {
+		units = append(units, Unit{Symbol: p.prefix + base, Base: base, Factor: p.factor})
+	}
+	return units
+}
+
+// Binary returns a table of base and its multiples with IEC binary prefixes, from Ki (2¹⁰) to
+// Ei (2⁶⁰), e.g. "KiB" and "GiB" for base "B".
+func Binary(base string) Units {
+	units := Units{{Symbol: base, Base: base, Factor: 1}}
+	factor := 1.0
+	for _, p := range []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei"} {
+		factor *= 1024
+		units = append(units, Unit{Symbol: p + base, Base: base, Factor: factor})
+	}
+	return units
+}
+
+// Bytes is a table of byte sizes in bytes ("B"), with both decimal prefixes ("kB", "MB", ...) and binary
+// ones ("KiB", "MiB", ...).  "KB" is accepted as a common spelling of "kB".
+var Bytes = append(append(SI("B")[:7], Binary("B")[1:]...), Unit{Symbol: "KB", Base: "B", Factor: 1e3})
+
+// Durations is a table of durations in seconds ("s"): "ns", "us", "µs", "ms", "s", "m", "h" and "d",
+// as in Go's time.ParseDuration with days added.
+var Durations = Units{
+	{Symbol: "s", Base: "s", Factor: 1},
+	{Symbol: "ns", Base: "s", Factor: 1e-9},
+	{Symbol: "us", Base: "s", Factor: 1e-6},
+	{Symbol: "µs", Base: "s", Factor: 1e-6},
+	{Symbol: "ms", Base: "s", Factor: 1e-3},
+	{Symbol: "m", Base: "s", Factor: 60},
+	{Symbol: "h", Base: "s", Factor: 3600},
+	{Symbol: "d", Base: "s", Factor: 86400},
+}
+
+// Lengths is a table of lengths in metres ("m"), with SI prefixes.
+var Lengths = SI("m")
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// number parses digits with an optional fraction, optionally signed with "-".
+func number(negative bool) Parser[float64] {
+	sign := Succeed(Empty{})
+	if negative {
+		sign = OneOf(Exactly("-"), sign)
+	}
+	fraction := OneOf(AppendSkipping(StartSkipping(Exactly(".")), ConsumeSome(isDigit)), Succeed(Empty{}))
+	s := StartSkipping(sign)
+	s1 := AppendSkipping(s, ConsumeSome(isDigit))
+	s2 := AppendSkipping(s1, fraction)
+	return AndThen(GetString(s2), func(text string) Parser[float64] {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return Fail[float64]
+		}
+		return Succeed(f)
+	})
+}
+
+// New returns a Parser[Quantity] for a number followed by one of the units in the table.
+func New(units Units, options Options) Parser[Quantity] {
+	bySymbol := make(map[string]Unit, len(units))
+	symbols := make([]string, 0, len(units))
+	for _, u := range units {
+		if _, ok := bySymbol[u.Symbol]; !ok {
+			bySymbol[u.Symbol] = u
+			symbols = append(symbols, u.Symbol)
+		}
+	}
+	unit := Map(ExactlyAnyTable(CompileLiterals(false, symbols...)), func(symbol string) Unit {
+		return bySymbol[symbol]
+	})
+	if options.Space {
+		unit = Apply(AppendKeeping(StartSkipping(ConsumeWhile(isSpace)), unit), func(u Unit) Unit { return u })
+	}
+	if options.Unitless && len(units) > 0 {
+		unit = OneOf(unit, Succeed(units[0]))
+	}
+	s := StartKeeping(number(options.Negative))
+	s1 := AppendKeeping(s, unit)
+	return Apply2(s1, func(value float64, unit Unit) Quantity {
+		return Quantity{Value: value, Unit: unit}
+	})
+}
+
+// Normalized returns a Parser[float64] like New, but returning the quantity converted to its
+// unit's Base.  It is meant for tables like Bytes whose units all share one Base.
+func Normalized(units Units, options Options) Parser[float64] {
+	return Map(New(units, options), Quantity.Normalize)
+}
+
+// Compound returns a Parser[float64] for one or more quantities written together, such as
+// "1h30m", or "2d 4h" with Options.Space, returning their sum in the Base unit.  With
+// Options.Negative, a single leading "-" negates the whole sum, as in "-1h30m".
+func Compound(units Units, options Options) Parser[float64] {
+	one := Normalized(units, Options{})
+	if options.Space {
+		one = Apply(AppendKeeping(StartSkipping(ConsumeWhile(isSpace)), one), func(f float64) float64 { return f })
+	}
+	sign := Succeed(1.0)
+	if options.Negative {
+		sign = OneOf(Map(Exactly("-"), func(Empty) float64 { return -1 }), sign)
+	}
+	return AndThen(sign, func(sign float64) Parser[float64] {
+		return AndThen(Normalized(units, Options{}), func(first float64) Parser[float64] {
+			return Loop(sign*first, func(total float64) Parser[Step[float64, float64]] {
+				return OneOf(
+					Map(one, func(f float64) Step[float64, float64] {
+						return Step[float64, float64]{Accum: total + sign*f}
+					}),
+					Succeed(Step[float64, float64]{Done: true, Value: total}),
+				)
+			})
+		})
+	})
+}
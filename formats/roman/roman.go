@@ -0,0 +1,117 @@
+// Package roman provides a parser for Roman numerals, such as "XIV" and "mcmxcix", as found in
+// outline and section numbers, regnal names and copyright dates.
+//
+// The parser works in two steps, which is a pattern worth copying for any format whose rules
+// are easier to check than to write as a grammar.  It first parses the text with a simple
+// grammar, here a run of numeral letters, and then checks the text against the rules of the
+// format, here by reading its value and making sure it is written as the mode requires,
+// failing with ErrNoMatch if not.
+package roman
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// Mode selects which numerals the parser accepts.
+type Mode int
+
+const (
+	// Strict accepts only numerals in the standard modern form, in upper case: from I to
+	// MMMCMXCIX (3999), with the subtractive pairs IV, IX, XL, XC, CD and CM, and no letter
+	// repeated more than three times in a row.  Each number has exactly one strict numeral.
+	Strict Mode = iota
+
+	// Lenient accepts numerals in either case, all upper or all lower, with the variations
+	// found on clock faces and old inscriptions: additive forms such as IIII and VIIII, any
+	// number of Ms, and irregular subtractive pairs such as IC for 99.  A letter before a
+	// larger one is subtracted from it, but the values after subtraction must not increase
+	// from left to right, so IIX and IXC fail.  V, L and D are never repeated or subtracted, so
+	// VV and VX fail.
+	Lenient
+)
+
+var values = map[rune]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+
+// numerals lists the numerals which make up strict numerals, largest first.
+var numerals = []struct {
+	text  string
+	value int
+}{
+	{"M", 1000}, {"CM", 900}, {"D", 500}, {"CD", 400}, {"C", 100}, {"XC", 90},
+	{"L", 50}, {"XL", 40}, {"X", 10}, {"IX", 9}, {"V", 5}, {"IV", 4}, {"I", 1},
+}
+
+// Format returns the strict numeral for n, or "" if n is not from 1 to 3999.
+func Format(n int) string {
+	if n < 1 || n > 3999 {
+		return ""
+	}
+	var b strings.Builder
+	for _, numeral := range numerals {
+		for n >= numeral.value {
+			b.WriteString(numeral.text)
+			n -= numeral.value
+		}
+	}
+	return b.String()
+}
+
+// letters parses a whole word made of numeral letters, in either case, and returns it.
+var letters Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	i := 0
+	for i < len(rest) && values[unicode.ToUpper(rune(rest[i]))] != 0 {
+		i++
+	}
+	if r, _ := utf8.DecodeRuneInString(rest[i:]); i == 0 || unicode.IsLetter(r) {
+		return "", initial, ErrNoMatch
+	}
+	return rest[:i], initial.Consume(i), nil
+}
+
+// lenient returns the value of text by the lenient rules, and whether it keeps them.
+func lenient(text string) (int, bool) {
+	total, last := 0, 0
+	for i := 0; i < len(text); i++ {
+		v := values[rune(text[i])]
+		if strings.IndexByte("VLD", text[i]) >= 0 && i+1 < len(text) && v <= values[rune(text[i+1])] {
+			return 0, false
+		}
+		if i+1 < len(text) && v < values[rune(text[i+1])] {
+			i++
+			v = values[rune(text[i])] - v
+		}
+		if last != 0 && v > last {
+			return 0, false
+		}
+		total, last = total+v, v
+	}
+	return total, true
+}
+
+// Numeral returns a Parser[int] for a Roman numeral accepted by mode, giving its value.  The
+// numeral must be a whole word, so the "mix" of "mixture" doesn't match.
+func Numeral(mode Mode) Parser[int] {
+	return AndThen(letters, func(text string) Parser[int] {
+		upper := strings.ToUpper(text)
+		if mode == Strict {
+			n, _ := lenient(upper)
+			if text != upper || Format(n) != text {
+				return Fail[int]
+			}
+			return Succeed(n)
+		}
+		if text != upper && text != strings.ToLower(text) {
+			return Fail[int]
+		}
+		n, ok := lenient(upper)
+		if !ok {
+			return Fail[int]
+		}
+		return Succeed(n)
+	})
+}
@@ -0,0 +1,319 @@
+// Package sfv provides parsers for HTTP Structured Field Values, as defined by RFC 8941: the
+// Lists, Dictionaries and Items that newer header fields such as Priority and Cache-Status
+// are defined in terms of.  For example, the Dictionary
+//
+//	u=1, i, a=(1 2);q=0.5, b=:cHJldGVuZCB0aGlzIGlzIGJpbmFyeQ==:
+//
+// has the Integer 1 under "u", the Boolean true under "i", an Inner List of two Integers with a
+// parameter "q" under "a", and a Byte Sequence under "b".
+//
+// The value of an Item is an int64 for an Integer, a float64 for a Decimal, a string for a
+// String, a Token for a Token, a []byte for a Byte Sequence, and a bool for a Boolean.
+//
+// Parsing follows the algorithms of RFC 8941 section 4.2, which are strict: anything they
+// reject fails the whole field, as the RFC requires.  The parsers take the entire field value;
+// when a field appears more than once in a message, join the values with "," first.  As the RFC
+// suggests, a Byte Sequence without its "=" padding is accepted.
+package sfv
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Token is a short textual word, such as an enumerated value, as distinct from a String.
+type Token string
+
+// A Param is one parameter of an Item or Inner List.  A parameter without a value has the
+// value true.
+type Param struct {
+	Key   string
+	Value any
+}
+
+// Params are the parameters of an Item or Inner List, in input order.  When a key repeats, the
+// last value is kept, at the position where the key first appeared.
+type Params []Param
+
+// Get returns the value of the parameter with the key, and whether there was one.
+func (p Params) Get(key string) (any, bool) {
+	for _, param := range p {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+	return nil, false
+}
+
+// A Member is a member of a List or Dictionary: an Item or an InnerList.
+type Member interface {
+	isMember()
+}
+
+// An Item is a single value with parameters.
+type Item struct {
+	Value  any
+	Params Params
+}
+
+// An InnerList is a list of Items with parameters of its own.
+type InnerList struct {
+	Items  []Item
+	Params Params
+}
+
+func (Item) isMember()      {}
+func (InnerList) isMember() {}
+
+// A List is the value of a List field, such as "sugar, tea, rum".
+type List []Member
+
+// An Entry is one member of a Dictionary.  A key without a value has the Item true, with any
+// parameters written after the key.
+type Entry struct {
+	Key    string
+	Member Member
+}
+
+// A Dictionary is the value of a Dictionary field, such as "en="Applepie", da=:w4ZibGV0w6ZydGU=:".
+// When a key repeats, the last member is kept, at the position where the key first appeared.
+type Dictionary []Entry
+
+// Get returns the member with the key, and whether there was one.
+func (d Dictionary) Get(key string) (Member, bool) {
+	for _, e := range d {
+		if e.Key == key {
+			return e.Member, true
+		}
+	}
+	return nil, false
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isAlpha(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// isTokenRune reports whether r may follow the first character of a Token: a tchar, ":" or "/".
+func isTokenRune(r rune) bool {
+	return isAlpha(r) || isDigit(r) || strings.ContainsRune("!#$%&'*+-.^_`|~:/", r)
+}
+
+func isKeyStart(r rune) bool {
+	return r >= 'a' && r <= 'z' || r == '*'
+}
+
+func isKeyRune(r rune) bool {
+	return isKeyStart(r) || isDigit(r) || strings.ContainsRune("_-.", r)
+}
+
+func isBase64Rune(r rune) bool {
+	return isAlpha(r) || isDigit(r) || r == '+' || r == '/' || r == '='
+}
+
+var (
+	spaces = ConsumeWhile(func(r rune) bool { return r == ' ' })
+	ows    = ConsumeWhile(func(r rune) bool { return r == ' ' || r == '\t' })
+	key    = GetString(AppendSkipping(ConsumeIf(isKeyStart), ConsumeWhile(isKeyRune)))
+)
+
+// number parses an Integer or Decimal, enforcing the RFC's limits of 15 digits for an Integer,
+// and 12 before and 3 after the "." for a Decimal.
+var number Parser[any] = func(initial State) (any, State, error) {
+	rest := initial.Remaining()
+	i := 0
+	if strings.HasPrefix(rest, "-") {
+		i++
+	}
+	start := i
+	for i < len(rest) && isDigit(rune(rest[i])) {
+		i++
+	}
+	whole := i - start
+	if whole == 0 {
+		return nil, initial, ErrNoMatch
+	}
+	if i == len(rest) || rest[i] != '.' {
+		if whole > 15 {
+			return nil, initial, ErrNoMatch
+		}
+		n, _ := strconv.ParseInt(rest[:i], 10, 64)
+		return n, initial.Consume(i), nil
+	}
+	i++
+	fraction := i
+	for i < len(rest) && isDigit(rune(rest[i])) {
+		i++
+	}
+	if whole > 12 || i == fraction || i-fraction > 3 {
+		return nil, initial, ErrNoMatch
+	}
+	f, _ := strconv.ParseFloat(rest[:i], 64)
+	return f, initial.Consume(i), nil
+}
+
+// str parses a String, which may contain printable ASCII, with "\" escaping only "\" and DQUOTE.
+var str Parser[any] = func(initial State) (any, State, error) {
+	rest := initial.Remaining()
+	if !strings.HasPrefix(rest, `"`) {
+		return nil, initial, ErrNoMatch
+	}
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '"':
+			return b.String(), initial.Consume(i + 1), nil
+		case c == '\\':
+			if i+1 == len(rest) || rest[i+1] != '"' && rest[i+1] != '\\' {
+				return nil, initial, ErrNoMatch
+			}
+			i++
+			b.WriteByte(rest[i])
+		case c < ' ' || c > '~':
+			return nil, initial, ErrNoMatch
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil, initial, ErrNoMatch
+}
+
+var token = Map(GetString(AppendSkipping(ConsumeIf(func(r rune) bool { return isAlpha(r) || r == '*' }), ConsumeWhile(isTokenRune))),
+	func(t string) any { return Token(t) })
+
+var byteSequence = func() Parser[any] {
+	s := StartSkipping(Exactly(":"))
+	s1 := AppendKeeping(s, TakeWhile(isBase64Rune))
+	s2 := AppendSkipping(s1, Exactly(":"))
+	return AndThen(Apply(s2, func(text string) string { return text }), func(text string) Parser[any] {
+		b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(text, "="))
+		if err != nil || strings.Contains(strings.TrimRight(text, "="), "=") {
+			return Fail[any]
+		}
+		return Succeed[any](b)
+	})
+}()
+
+var boolean = Map(ExactlyAnyFold("?0", "?1"), func(text string) any { return text == "?1" })
+
+// bareItem parses the value of an Item, without parameters.
+var bareItem = OneOf(number, str, token, byteSequence, boolean)
+
+// set puts the value under k in entries, replacing the value of an earlier entry with the same
+// key, and returns the result.
+func set[E any](entries []E, k string, e E, keyOf func(E) string) []E {
+	for i := range entries {
+		if keyOf(entries[i]) == k {
+			entries[i] = e
+			return entries
+		}
+	}
+	return append(entries, e)
+}
+
+// params parses the parameters after an Item or Inner List: (";" SP* key ["=" bare-item])*.
+var params = Loop[Params](nil, func(ps Params) Parser[Step[Params, Params]] {
+	value := OneOf(Apply(AppendKeeping(StartSkipping(Exactly("=")), bareItem), func(v any) any { return v }), Succeed[any](true))
+	s := StartSkipping(Exactly(";"))
+	s1 := AppendSkipping(s, spaces)
+	s2 := AppendKeeping(s1, key)
+	s3 := AppendKeeping(s2, value)
+	return OneOf(
+		Apply2(s3, func(k string, v any) Step[Params, Params] {
+			return Step[Params, Params]{Accum: set(ps, k, Param{Key: k, Value: v}, func(p Param) string { return p.Key })}
+		}),
+		Succeed(Step[Params, Params]{Done: true, Value: ps}),
+	)
+})
+
+var item = func() Parser[Item] {
+	s := StartKeeping(bareItem)
+	s1 := AppendKeeping(s, params)
+	return Apply2(s1, func(v any, ps Params) Item { return Item{Value: v, Params: ps} })
+}()
+
+// innerList parses "(" SP* [item (SP+ item)* SP*] ")" parameters.
+var innerList = func() Parser[InnerList] {
+	items := Loop[[]Item](nil, func(items []Item) Parser[Step[[]Item, []Item]] {
+		done := Map(Exactly(")"), func(Empty) Step[[]Item, []Item] { return Step[[]Item, []Item]{Done: true, Value: items} })
+		next := item
+		if items != nil {
+			// Items must be separated by at least one space.
+			next = Apply(AppendKeeping(StartSkipping(ConsumeSome(func(r rune) bool { return r == ' ' })), item), func(i Item) Item { return i })
+		}
+		return OneOf(
+			Apply(AppendKeeping(StartSkipping(spaces), done), func(s Step[[]Item, []Item]) Step[[]Item, []Item] { return s }),
+			Map(next, func(i Item) Step[[]Item, []Item] { return Step[[]Item, []Item]{Accum: append(items, i)} }),
+		)
+	})
+	s := StartSkipping(Exactly("("))
+	s1 := AppendSkipping(s, spaces)
+	s2 := AppendKeeping(s1, items)
+	s3 := AppendKeeping(s2, params)
+	return Apply2(s3, func(items []Item, ps Params) InnerList { return InnerList{Items: items, Params: ps} })
+}()
+
+var member = OneOf(
+	Map(innerList, func(l InnerList) Member { return l }),
+	Map(item, func(i Item) Member { return i }),
+)
+
+// members returns a parser for zero or more of element separated by OWS "," OWS.  A trailing
+// comma isn't consumed, so that the field fails.
+func members[E any](element Parser[E]) Parser[[]E] {
+	comma := AppendSkipping(AppendSkipping(StartSkipping(ows), Exactly(",")), ows)
+	return OneOf(
+		AndThen(element, func(first E) Parser[[]E] {
+			return Loop([]E{first}, func(es []E) Parser[Step[[]E, []E]] {
+				s := StartSkipping(comma)
+				s1 := AppendKeeping(s, element)
+				return OneOf(
+					Apply(s1, func(e E) Step[[]E, []E] { return Step[[]E, []E]{Accum: append(es, e)} }),
+					Succeed(Step[[]E, []E]{Done: true, Value: es}),
+				)
+			})
+		}),
+		Succeed([]E(nil)),
+	)
+}
+
+// field wraps parser to discard leading and trailing spaces around the whole field.
+func field[T any](parser Parser[T]) Parser[T] {
+	s := StartSkipping(spaces)
+	s1 := AppendKeeping(s, parser)
+	s2 := AppendSkipping(s1, spaces)
+	s3 := AppendSkipping(s2, EndOfInput)
+	return Apply(s3, func(t T) T { return t })
+}
+
+// ListParser is a Parser[List] for the whole value of a List field.  An empty value gives an
+// empty List.
+var ListParser = field(Map(members(member), func(ms []Member) List { return List(ms) }))
+
+// DictionaryParser is a Parser[Dictionary] for the whole value of a Dictionary field.  An empty
+// value gives an empty Dictionary.
+var DictionaryParser = func() Parser[Dictionary] {
+	value := OneOf(
+		Apply(AppendKeeping(StartSkipping(Exactly("=")), member), func(m Member) Member { return m }),
+		Map(params, func(ps Params) Member { return Item{Value: true, Params: ps} }),
+	)
+	s := StartKeeping(key)
+	s1 := AppendKeeping(s, value)
+	entry := Apply2(s1, func(k string, m Member) Entry { return Entry{Key: k, Member: m} })
+	return field(Map(members(entry), func(entries []Entry) Dictionary {
+		var d Dictionary
+		for _, e := range entries {
+			d = set(d, e.Key, e, func(e Entry) string { return e.Key })
+		}
+		return d
+	}))
+}()
+
+// ItemParser is a Parser[Item] for the whole value of an Item field.
+var ItemParser = field(item)
@@ -0,0 +1,213 @@
+// Package timezone provides parsers for the time zone parts of timestamps: numeric UTC offsets
+// such as "+05:30", "-0800" and "Z", and IANA time zone names such as "Europe/London".
+//
+// Offsets parse to an Offset, which keeps what was written, and which gives a *time.Location
+// with Location.  Zone names parse to a *time.Location through a validation hook, which by
+// default is time.LoadLocation, so that a name that looks right but isn't in the time zone
+// database fails with a *ZoneError.  Location accepts either.
+//
+// None of the parsers require the end of the input, so they can follow the date and time in a
+// larger grammar, as in
+//
+//	s := StartKeeping(dateTime)
+//	s1 := AppendKeeping(s, timezone.RFC3339)
+package timezone
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// An Offset is a numeric offset from UTC.
+type Offset struct {
+	Seconds int  // East of UTC.
+	Zulu    bool // Whether it was written "Z".
+	Unknown bool // Whether it was written "-00:00", which RFC 3339 uses for UTC when the local offset is unknown.
+}
+
+// Location returns a *time.Location for the offset: time.UTC for a zero offset, or a fixed zone
+// without a name, as time.Parse makes for a numeric offset.
+func (o Offset) Location() *time.Location {
+	if o.Seconds == 0 {
+		return time.UTC
+	}
+	return time.FixedZone("", o.Seconds)
+}
+
+// String returns the offset as RFC 3339 writes it, with seconds if it has any.
+func (o Offset) String() string {
+	if o.Zulu {
+		return "Z"
+	}
+	sign, s := '+', o.Seconds
+	if s < 0 || o.Unknown {
+		sign, s = '-', -s
+	}
+	text := fmt.Sprintf("%c%02d:%02d", sign, s/3600, s/60%60)
+	if s%60 != 0 {
+		text += fmt.Sprintf(":%02d", s%60)
+	}
+	return text
+}
+
+// ZoneError is the error returned for a time zone name that the validation hook rejects.
+type ZoneError struct {
+	Offset int // Byte offset of the name from the start of the input.
+	Name   string
+	Err    error // As returned by the hook.
+}
+
+func (e *ZoneError) Error() string {
+	return fmt.Sprintf("time zone %q: %v", e.Name, e.Err)
+}
+
+func (e *ZoneError) Unwrap() error {
+	return e.Err
+}
+
+// twoDigits returns the number in the two digits at the start of s, and whether there are any.
+func twoDigits(s string) (int, bool) {
+	if len(s) < 2 || !isDigit(rune(s[0])) || !isDigit(rune(s[1])) {
+		return 0, false
+	}
+	return int(s[0]-'0')*10 + int(s[1]-'0'), true
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// offset returns a parser for "Z" or a signed offset of hours and minutes.  If strict, the
+// offset must be written ±hh:mm; otherwise it may be written ±hh, ±hhmm, ±hhmmss, ±hh:mm or
+// ±hh:mm:ss, and the sign may be U+2212 MINUS SIGN, as ISO 8601 prefers.
+func offset(strict bool) Parser[Offset] {
+	return func(initial State) (Offset, State, error) {
+		rest := initial.Remaining()
+		if strings.HasPrefix(rest, "Z") || strings.HasPrefix(rest, "z") {
+			return Offset{Zulu: true}, initial.Consume(1), nil
+		}
+		sign, i := 1, 1
+		switch {
+		case strings.HasPrefix(rest, "+"):
+		case strings.HasPrefix(rest, "-"):
+			sign = -1
+		case !strict && strings.HasPrefix(rest, "−"):
+			sign, i = -1, len("−")
+		default:
+			return Offset{}, initial, ErrNoMatch
+		}
+		// fields holds the hours, minutes and seconds, and colon whether they are separated by
+		// colons, which must be all or none of them.
+		var fields []int
+		colon := strings.HasPrefix(rest[i:], ":")
+		for len(fields) < 3 {
+			at := i
+			if len(fields) > 0 && colon {
+				if !strings.HasPrefix(rest[i:], ":") {
+					break
+				}
+				at++
+			}
+			n, ok := twoDigits(rest[at:])
+			if !ok {
+				break
+			}
+			fields, i = append(fields, n), at+2
+			if len(fields) == 1 {
+				colon = strings.HasPrefix(rest[i:], ":")
+			}
+		}
+		if len(fields) == 0 || strict && (len(fields) != 2 || !colon) {
+			return Offset{}, initial, ErrNoMatch
+		}
+		fields = append(fields, 0, 0)
+		if fields[0] > 23 || fields[1] > 59 || fields[2] > 59 {
+			return Offset{}, initial, ErrNoMatch
+		}
+		seconds := fields[0]*3600 + fields[1]*60 + fields[2]
+		return Offset{Seconds: sign * seconds, Unknown: sign < 0 && seconds == 0}, initial.Consume(i), nil
+	}
+}
+
+// RFC3339 is a Parser[Offset] for the time-offset of an RFC 3339 timestamp: "Z" or ±hh:mm, with
+// hours up to 23 and minutes up to 59.  As RFC 3339 allows, "z" may be lower case.
+var RFC3339 = offset(true)
+
+// Numeric is a Parser[Offset] like RFC3339, which also accepts the other ways ISO 8601 writes
+// offsets: ±hh, ±hhmm, and with seconds, ±hhmmss or ±hh:mm:ss, and U+2212 MINUS SIGN for "-".
+// Colons must separate all the fields or none of them.
+var Numeric = offset(false)
+
+func isZoneStart(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func isZoneRune(r rune) bool {
+	return isZoneStart(r) || isDigit(r) || r == '.' || r == '_' || r == '-' || r == '+'
+}
+
+// ZoneName is a Parser[string] for a name that is well formed as an IANA time zone name, such
+// as "America/New_York", "Etc/GMT+5" or "UTC": one or more components separated by "/", each
+// beginning with a letter and continuing with letters, digits, ".", "_", "-" and "+".  It checks
+// only the form of the name; whether there is such a zone is for Zone to find out.
+var ZoneName Parser[string] = func(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	end := 0
+	for i := 0; i < len(rest) && isZoneStart(rune(rest[i])); i++ {
+		for i++; i < len(rest) && isZoneRune(rune(rest[i])); i++ {
+		}
+		end = i
+		if i == len(rest) || rest[i] != '/' {
+			break
+		}
+	}
+	if end == 0 {
+		return "", initial, ErrNoMatch
+	}
+	return rest[:end], initial.Consume(end), nil
+}
+
+// Zone returns a Parser[*time.Location] for an IANA time zone name, which calls validate to look
+// up the zone.  If validate is nil, time.LoadLocation is used.  If validate returns an error,
+// the parser fails with a *ZoneError wrapping it, rather than ErrNoMatch, so that misspelled
+// zones are reported as such.  A hook can restrict the zones accepted, as in
+//
+//	timezone.Zone(func(name string) (*time.Location, error) {
+//		if !strings.Contains(name, "/") {
+//			return nil, errors.New("use a region-based zone name")
+//		}
+//		return time.LoadLocation(name)
+//	})
+func Zone(validate func(name string) (*time.Location, error)) Parser[*time.Location] {
+	if validate == nil {
+		validate = time.LoadLocation
+	}
+	return func(initial State) (*time.Location, State, error) {
+		name, next, err := ZoneName(initial)
+		if err != nil {
+			return nil, initial, err
+		}
+		loc, err := validate(name)
+		if err != nil {
+			return nil, initial, &ZoneError{Offset: initial.Offset(), Name: name, Err: err}
+		}
+		return loc, next, nil
+	}
+}
+
+// Location returns a Parser[*time.Location] for either a numeric offset, as Numeric parses it,
+// or a time zone name, as Zone(validate) parses it.  A lone "Z" is the offset, but "Zulu" is a
+// name.
+func Location(validate func(name string) (*time.Location, error)) Parser[*time.Location] {
+	numeric := Map(Numeric, Offset.Location)
+	zone := Zone(validate)
+	return func(initial State) (*time.Location, State, error) {
+		if name, _, err := ZoneName(initial); err == nil && !strings.EqualFold(name, "Z") {
+			return zone(initial)
+		}
+		return numeric(initial)
+	}
+}
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// AltSet[T] is a set of alternative Parser[T]s which can be extended after the grammar using it
+// has been built, e.g. so that plugins can contribute new statement forms to a host application's
+// grammar.  Its Parser method returns a parser which tries the alternatives like OneOf.
+//
+// An AltSet is safe for concurrent use: Append copies the alternatives rather than changing them,
+// so a parse which is already running keeps seeing the alternatives as they were when it reached
+// the AltSet.
+type AltSet[T any] struct {
+	mu           sync.Mutex // Serializes Append.
+	alternatives atomic.Pointer[[]Parser[T]]
+}
+
+// NewAltSet[T] returns an AltSet[T] initially containing the parsers.
+func NewAltSet[T any](parsers ...Parser[T]) *AltSet[T] {
+	a := &AltSet[T]{}
+	alternatives := append([]Parser[T](nil), parsers...)
+	a.alternatives.Store(&alternatives)
+	return a
+}
+
+// Append adds the parsers to the end of the set, so they are tried after the existing alternatives.
+func (a *AltSet[T]) Append(parsers ...Parser[T]) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	old := *a.alternatives.Load()
+	alternatives := make([]Parser[T], 0, len(old)+len(parsers))
+	alternatives = append(append(alternatives, old...), parsers...)
+	a.alternatives.Store(&alternatives)
+}
+
+// Parser returns a Parser[T] which tries the alternatives in the set when it runs, as OneOf does.
+// Alternatives appended later are seen by the same Parser, so it can be built into a grammar first.
+func (a *AltSet[T]) Parser() Parser[T] {
+	return func(initial State) (T, State, error) {
+		return OneOf(*a.alternatives.Load()...)(initial)
+	}
+}
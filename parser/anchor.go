@@ -0,0 +1,72 @@
+package parser
+
+// Anchors are zero-width parsers: they succeed or fail depending on where in the input
+// they are run, and never consume any input.
+
+// StartOfInput is a parser which succeeds only at the very beginning of the input.
+func StartOfInput(initial State) (Empty, State, error) {
+	if initial.offset != 0 {
+		return Empty{}, initial, ErrNoMatch
+	}
+	return Empty{}, initial, nil
+}
+
+// EndOfInput is a parser which succeeds only when no input remains.
+func EndOfInput(initial State) (Empty, State, error) {
+	if initial.offset < len(initial.data) {
+		return Empty{}, initial, ErrNoMatch
+	}
+	return Empty{}, initial, nil
+}
+
+// StartOfLine is a parser which succeeds only at the beginning of the input or
+// immediately after a "\n".
+func StartOfLine(initial State) (Empty, State, error) {
+	if !initial.atLineStart() {
+		return Empty{}, initial, ErrNoMatch
+	}
+	return Empty{}, initial, nil
+}
+
+// EndOfLine is a parser which succeeds only at the end of the input or immediately
+// before a "\n" or "\r\n".  It does not consume the line terminator.
+func EndOfLine(initial State) (Empty, State, error) {
+	if !initial.atLineEnd() {
+		return Empty{}, initial, ErrNoMatch
+	}
+	return Empty{}, initial, nil
+}
+
+// NotFollowedBy[T] returns a parser which succeeds only where the parser argument fails, and
+// never consumes any input.  It is for telling apart things which begin alike, such as the
+// keyword "true" and the identifier "trueX":
+//
+//	AppendSkipping(StartKeeping(Exactly("true")), NotFollowedBy(ConsumeIf(isIdentRune)))
+//
+// Where the parser argument succeeds, NotFollowedBy fails with ErrNoMatch.  Where it fails
+// with ErrNoMatch or ErrUnconsumedInput, NotFollowedBy succeeds, but any other error, such as
+// ErrBudgetExceeded, fails it too.  Either way, how far the parser argument got and what it
+// expected are forgotten, so they don't change the ParseError a later failure reports.
+func NotFollowedBy[T any](parser Parser[T]) Parser[Empty] {
+	return func(initial State) (Empty, State, error) {
+		checkpoint := initial.Save()
+		var furthest int
+		if initial.run != nil {
+			furthest = initial.run.furthest
+		}
+		expected := initial.expectation()
+		_, _, err := parser(initial)
+		initial = initial.Restore(checkpoint)
+		if initial.run != nil {
+			initial.run.furthest = furthest
+		}
+		initial.forgetExpected(expected)
+		switch {
+		case err == nil:
+			return Empty{}, initial, ErrNoMatch
+		case isNoMatch(err):
+			return Empty{}, initial, nil
+		}
+		return Empty{}, initial, err
+	}
+}
@@ -0,0 +1,267 @@
+// Code generated by genapply -max 9; DO NOT EDIT.
+
+package parser
+
+// Apply4 returns a parser by transforming the output of the argument parser, which produces
+// a four-element sequence.  The resulting parser transforms the four values from the sequence
+// into the final result value using the argument mapper function.
+func Apply4[T any, U any, V any, W any, A any](parser Parser[Seq[Seq[Seq[Seq[Empty, T], U], V], W]], mapper func(T, U, V, W) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		return mapper(seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned4 is like Apply4, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned4[T any, U any, V any, W any, A any](parser Parser[Seq[Seq[Seq[Seq[Empty, T], U], V], W]], mapper func(Span, T, U, V, W) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// Apply5 returns a parser by transforming the output of the argument parser, which produces
+// a five-element sequence.  The resulting parser transforms the five values from the sequence
+// into the final result value using the argument mapper function.
+func Apply5[T any, U any, V any, W any, X any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X]], mapper func(T, U, V, W, X) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		return mapper(seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned5 is like Apply5, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned5[T any, U any, V any, W any, X any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X]], mapper func(Span, T, U, V, W, X) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// Apply6 returns a parser by transforming the output of the argument parser, which produces
+// a six-element sequence.  The resulting parser transforms the six values from the sequence
+// into the final result value using the argument mapper function.
+func Apply6[T any, U any, V any, W any, X any, Y any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y]], mapper func(T, U, V, W, X, Y) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		return mapper(seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned6 is like Apply6, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned6[T any, U any, V any, W any, X any, Y any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y]], mapper func(Span, T, U, V, W, X, Y) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// Apply7 returns a parser by transforming the output of the argument parser, which produces
+// a seven-element sequence.  The resulting parser transforms the seven values from the sequence
+// into the final result value using the argument mapper function.
+func Apply7[T any, U any, V any, W any, X any, Y any, Z any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z]], mapper func(T, U, V, W, X, Y, Z) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		return mapper(seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned7 is like Apply7, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned7[T any, U any, V any, W any, X any, Y any, Z any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z]], mapper func(Span, T, U, V, W, X, Y, Z) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// Apply8 returns a parser by transforming the output of the argument parser, which produces
+// an eight-element sequence.  The resulting parser transforms the eight values from the sequence
+// into the final result value using the argument mapper function.
+func Apply8[T any, U any, V any, W any, X any, Y any, Z any, R any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R]], mapper func(T, U, V, W, X, Y, Z, R) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		return mapper(seq.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned8 is like Apply8, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned8[T any, U any, V any, W any, X any, Y any, Z any, R any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R]], mapper func(Span, T, U, V, W, X, Y, Z, R) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// Apply9 returns a parser by transforming the output of the argument parser, which produces
+// a nine-element sequence.  The resulting parser transforms the nine values from the sequence
+// into the final result value using the argument mapper function.
+func Apply9[T any, U any, V any, W any, X any, Y any, Z any, R any, S any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R], S]], mapper func(T, U, V, W, X, Y, Z, R, S) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		return mapper(seq.first.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned9 is like Apply9, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned9[T any, U any, V any, W any, X any, Y any, Z any, R any, S any, A any](parser Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R], S]], mapper func(Span, T, U, V, W, X, Y, Z, R, S) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
+
+// Values2 returns the two values of a two-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values2[T any, U any](seq Seq[Seq[Empty, T], U]) (T, U) {
+	return seq.first.second, seq.second
+}
+
+// Values3 returns the three values of a three-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values3[T any, U any, V any](seq Seq[Seq[Seq[Empty, T], U], V]) (T, U, V) {
+	return seq.first.first.second, seq.first.second, seq.second
+}
+
+// Values4 returns the four values of a four-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values4[T any, U any, V any, W any](seq Seq[Seq[Seq[Seq[Empty, T], U], V], W]) (T, U, V, W) {
+	return seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second
+}
+
+// Values5 returns the five values of a five-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values5[T any, U any, V any, W any, X any](seq Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X]) (T, U, V, W, X) {
+	return seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second
+}
+
+// Values6 returns the six values of a six-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values6[T any, U any, V any, W any, X any, Y any](seq Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y]) (T, U, V, W, X, Y) {
+	return seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second
+}
+
+// Values7 returns the seven values of a seven-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values7[T any, U any, V any, W any, X any, Y any, Z any](seq Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z]) (T, U, V, W, X, Y, Z) {
+	return seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second
+}
+
+// Values8 returns the eight values of an eight-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values8[T any, U any, V any, W any, X any, Y any, Z any, R any](seq Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R]) (T, U, V, W, X, Y, Z, R) {
+	return seq.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second
+}
+
+// Values9 returns the nine values of a nine-element sequence, for hand-written parsers
+// which run a sequence parser themselves.
+func Values9[T any, U any, V any, W any, X any, Y any, Z any, R any, S any](seq Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R], S]) (T, U, V, W, X, Y, Z, R, S) {
+	return seq.first.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.first.second, seq.first.first.first.first.first.first.second, seq.first.first.first.first.first.second, seq.first.first.first.first.second, seq.first.first.first.second, seq.first.first.second, seq.first.second, seq.second
+}
+
+// Keep2 returns a sequence Parser which runs the two argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply2.
+func Keep2[T any, U any](t Parser[T], u Parser[U]) Parser[Seq[Seq[Empty, T], U]] {
+	return AppendKeeping(StartKeeping(t), u)
+}
+
+// Keep3 returns a sequence Parser which runs the three argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply3.
+func Keep3[T any, U any, V any](t Parser[T], u Parser[U], v Parser[V]) Parser[Seq[Seq[Seq[Empty, T], U], V]] {
+	return AppendKeeping(AppendKeeping(StartKeeping(t), u), v)
+}
+
+// Keep4 returns a sequence Parser which runs the four argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply4.
+func Keep4[T any, U any, V any, W any](t Parser[T], u Parser[U], v Parser[V], w Parser[W]) Parser[Seq[Seq[Seq[Seq[Empty, T], U], V], W]] {
+	return AppendKeeping(AppendKeeping(AppendKeeping(StartKeeping(t), u), v), w)
+}
+
+// Keep5 returns a sequence Parser which runs the five argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply5.
+func Keep5[T any, U any, V any, W any, X any](t Parser[T], u Parser[U], v Parser[V], w Parser[W], x Parser[X]) Parser[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X]] {
+	return AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(StartKeeping(t), u), v), w), x)
+}
+
+// Keep6 returns a sequence Parser which runs the six argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply6.
+func Keep6[T any, U any, V any, W any, X any, Y any](t Parser[T], u Parser[U], v Parser[V], w Parser[W], x Parser[X], y Parser[Y]) Parser[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y]] {
+	return AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(StartKeeping(t), u), v), w), x), y)
+}
+
+// Keep7 returns a sequence Parser which runs the seven argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply7.
+func Keep7[T any, U any, V any, W any, X any, Y any, Z any](t Parser[T], u Parser[U], v Parser[V], w Parser[W], x Parser[X], y Parser[Y], z Parser[Z]) Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z]] {
+	return AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(StartKeeping(t), u), v), w), x), y), z)
+}
+
+// Keep8 returns a sequence Parser which runs the eight argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply8.
+func Keep8[T any, U any, V any, W any, X any, Y any, Z any, R any](t Parser[T], u Parser[U], v Parser[V], w Parser[W], x Parser[X], y Parser[Y], z Parser[Z], r Parser[R]) Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R]] {
+	return AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(StartKeeping(t), u), v), w), x), y), z), r)
+}
+
+// Keep9 returns a sequence Parser which runs the nine argument parsers one after another
+// and keeps all their values, as StartKeeping followed by AppendKeeping does, for Apply9.
+func Keep9[T any, U any, V any, W any, X any, Y any, Z any, R any, S any](t Parser[T], u Parser[U], v Parser[V], w Parser[W], x Parser[X], y Parser[Y], z Parser[Z], r Parser[R], s Parser[S]) Parser[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Seq[Empty, T], U], V], W], X], Y], Z], R], S]] {
+	return AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(AppendKeeping(StartKeeping(t), u), v), w), x), y), z), r), s)
+}
@@ -0,0 +1,133 @@
+package parser
+
+// An Arena holds the memory for the slices returned by SepBy, SepBy1, EndBy, Repeat, Count and
+// Sequence, and for the SyntaxNodes recorded by WithSyntaxTree, so that a program parsing many
+// inputs can reuse the same memory for each rather than leaving the garbage collector to clear
+// up after every parse.  Pass one to Parse with WithArena, and call Reset once the results of
+// the parse are no longer needed; the next parse then reuses the memory.
+//
+// Everything a parse allocated from an Arena belongs to it: after Reset, the slices and nodes
+// must not be used, as the next parse will overwrite them.  So copy what must outlive the
+// parse; a SharedMemo, for the same reason, keeps the outcomes of a parse with an Arena to that
+// parse alone.  An Arena is for one parse at a time; a server parsing concurrently can keep
+// them in a sync.Pool:
+//
+//	var arenas = sync.Pool{New: func() any { return new(Arena) }}
+//
+//	arena := arenas.Get().(*Arena)
+//	defer func() { arena.Reset(); arenas.Put(arena) }()
+//	request, err := Parse(grammar, input, WithArena(arena))
+//
+// The zero Arena is empty, and ready to use.
+type Arena struct {
+	slabs map[any]resetter // A *slab[T] for each type T allocated, by slabKey[T].
+}
+
+// Reset makes all the memory of the Arena free for reuse by the next parse.  It zeroes the
+// memory, so that the Arena doesn't keep alive whatever the old values referred to.
+func (a *Arena) Reset() {
+	for _, s := range a.slabs {
+		s.reset()
+	}
+}
+
+// WithArena returns an Option which makes the parse allocate from arena, as described there.
+func WithArena(arena *Arena) Option {
+	return func(c *config) {
+		c.arena = arena
+	}
+}
+
+// arena returns the Arena the parse allocates from, or nil if there is none.
+func (s State) arena() *Arena {
+	if s.run == nil {
+		return nil
+	}
+	return s.run.arena
+}
+
+// slabKey[T] is the key of the slab of values of type T in an Arena.
+type slabKey[T any] struct{}
+
+// resetter is implemented by every *slab[T], for Reset.
+type resetter interface {
+	reset()
+}
+
+// A slab[T] is the memory for values of type T in an Arena: chunks[:current] are full, and
+// chunks[current][:off] is in use.  The chunks after it are free, after a Reset.
+type slab[T any] struct {
+	chunks  [][]T
+	current int
+	off     int
+}
+
+// minChunk is the fewest values a slab allocates at a time.
+const minChunk = 64
+
+// alloc returns an empty slice with room for n values.
+func (s *slab[T]) alloc(n int) []T {
+	for ; s.current < len(s.chunks); s.current, s.off = s.current+1, 0 {
+		if chunk := s.chunks[s.current]; len(chunk)-s.off >= n {
+			s.off += n
+			return chunk[s.off-n : s.off-n : s.off]
+		}
+	}
+	size := minChunk
+	if last := len(s.chunks) - 1; last >= 0 {
+		size = 2 * len(s.chunks[last])
+	}
+	if size < n {
+		size = n
+	}
+	chunk := make([]T, size)
+	s.chunks = append(s.chunks, chunk)
+	s.current, s.off = len(s.chunks)-1, n
+	return chunk[0:0:n]
+}
+
+func (s *slab[T]) reset() {
+	var zero T
+	for i := 0; i <= s.current && i < len(s.chunks); i++ {
+		chunk := s.chunks[i]
+		for j := range chunk {
+			chunk[j] = zero
+		}
+	}
+	s.current, s.off = 0, 0
+}
+
+// allocate[T] returns an empty slice with room for n values of type T, from a if it isn't nil.
+func allocate[T any](a *Arena, n int) []T {
+	if a == nil {
+		return make([]T, 0, n)
+	}
+	s, ok := a.slabs[slabKey[T]{}].(*slab[T])
+	if !ok {
+		if a.slabs == nil {
+			a.slabs = make(map[any]resetter)
+		}
+		s = new(slab[T])
+		a.slabs[slabKey[T]{}] = s
+	}
+	return s.alloc(n)
+}
+
+// appendTo[T] appends t to items as append does, but growing items from a if it isn't nil.
+func appendTo[T any](a *Arena, items []T, t T) []T {
+	if a == nil || len(items) < cap(items) {
+		return append(items, t)
+	}
+	grown := allocate[T](a, 2*len(items)+4)
+	return append(append(grown, items...), t)
+}
+
+// newNode returns a new SyntaxNode called name, from a if it isn't nil.
+func newNode(a *Arena, name string) *SyntaxNode {
+	if a == nil {
+		return &SyntaxNode{Name: name}
+	}
+	node := &allocate[SyntaxNode](a, 1)[:1][0]
+	node.Name = name
+	return node
+}
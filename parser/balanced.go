@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Balanced returns a Parser[string] which matches an open delimiter, then everything up to
+// the close delimiter that balances it, and returns the raw text between the two.  Nested
+// open/close pairs inside the region are counted, so Balanced("{", "}") applied to "{a{b}c}"
+// returns "a{b}c".
+//
+// Each opaque parser is tried, in order, at every position inside the region before the
+// delimiters are checked; input matched by an opaque parser is skipped over whole.  Use this
+// for string literals and comments whose contents must not count as delimiters, e.g.
+// Balanced("{", "}", Quoted('"', '\\')).  The parser fails if the input ends before the
+// region is closed.
+func Balanced(open, close string, opaque ...Parser[Empty]) Parser[string] {
+	return func(initial State) (string, State, error) {
+		if !strings.HasPrefix(initial.Remaining(), open) {
+			return "", initial, ErrNoMatch
+		}
+		current := initial.Consume(len(open))
+		start := current.offset
+		depth := 1
+	scan:
+		for current.offset < len(current.data) {
+			if current.overBudget() {
+				return "", initial, ErrBudgetExceeded
+			}
+			for _, parser := range opaque {
+				checkpoint := current.Save()
+				_, next, err := parser(current)
+				if err == nil && next.offset > current.offset {
+					current = next
+					continue scan
+				}
+				if err != nil && !isNoMatch(err) {
+					return "", initial, err
+				}
+				current = current.Restore(checkpoint)
+			}
+			rest := current.Remaining()
+			switch {
+			case strings.HasPrefix(rest, close):
+				depth--
+				if depth == 0 {
+					end := current.offset
+					return current.data[start:end], current.Consume(len(close)), nil
+				}
+				current = current.Consume(len(close))
+			case strings.HasPrefix(rest, open):
+				depth++
+				current = current.Consume(len(open))
+			default:
+				_, current = current.nextRune()
+			}
+		}
+		return "", initial, ErrNoMatch
+	}
+}
+
+// Quoted returns a Parser which matches a quoted literal: the quote rune, then any runes up to
+// an unescaped closing quote rune.  A rune following the escape rune is always taken literally.
+// It is intended for use as an opaque parser with Balanced, and doesn't decode its contents.
+func Quoted(quote, escape rune) Parser[Empty] {
+	return func(initial State) (Empty, State, error) {
+		r, current := initial.nextRune()
+		if r != quote {
+			return Empty{}, initial, ErrNoMatch
+		}
+		for current.offset < len(current.data) {
+			r, current = current.nextRune()
+			switch r {
+			case quote:
+				return Empty{}, current.reached(), nil
+			case escape:
+				_, current = current.nextRune()
+			}
+		}
+		return Empty{}, initial, ErrNoMatch
+	}
+}
+
+// InQuotes returns a Parser[T] for a quoted literal whose contents are themselves in some
+// format, such as a JSON string holding a comma-separated list.  It matches a quoted literal
+// just as Quoted does, strips the quotes, decodes the contents with unescape, and then runs inner
+// over the decoded text, which inner must consume entirely.  If unescape is nil, each escape
+// rune is simply removed and the rune after it kept, so `"a\"b"` decodes to `a"b`.
+//
+// An error from unescape is returned as is.  If inner fails, or leaves some of the decoded text
+// unconsumed, InQuotes fails with inner's error or ErrUnconsumedInput.  inner sees the decoded
+// text as an input of its own, so anchors such as StartOfInput refer to the decoded text, while
+// offsets are counted as if the decoded text began just after the opening quote.
+func InQuotes[T any](quote, escape rune, unescape func(string) (string, error), inner Parser[T]) Parser[T] {
+	literal := GetString(Quoted(quote, escape))
+	return func(initial State) (T, State, error) {
+		var zero T
+		text, next, err := literal(initial)
+		if err != nil {
+			return zero, initial, err
+		}
+		q := utf8.RuneLen(quote)
+		contents := text[q : len(text)-q]
+		if unescape == nil {
+			contents = removeEscapes(contents, escape)
+		} else if contents, err = unescape(contents); err != nil {
+			return zero, initial, err
+		}
+		t, err := reparse(initial, contents, initial.Offset()+q, inner)
+		if err != nil {
+			return zero, initial, err
+		}
+		return t, next, nil
+	}
+}
+
+// removeEscapes removes each escape rune from text, keeping the rune after it.
+func removeEscapes(text string, escape rune) string {
+	if !strings.ContainsRune(text, escape) {
+		return text
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range text {
+		if r == escape && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
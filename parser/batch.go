@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// A Result[T] is what Parse returned for one of the inputs given to ParseBatch.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// ParseBatch[T] parses each of the inputs with the parser, as Parse does, on as many as
+// workers goroutines at once, and returns the results in the order of the inputs.  It is for
+// jobs such as reading log lines or validating uploaded records, where each input stands
+// alone: one failing doesn't stop the others, and BatchErr gathers the errors afterwards.  With
+// workers of 0 or less, it uses one goroutine for each CPU, as runtime.GOMAXPROCS says.
+//
+// The options are given to every call to Parse, so they mustn't be ones which each parse
+// needs its own of, such as WithSyntaxTree or WithArena; those can be used with Parse from
+// goroutines of the caller's own.
+func ParseBatch[T any](parser Parser[T], inputs []string, workers int, options ...Option) []Result[T] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	results := make([]Result[T], len(inputs))
+	var next atomic.Int64 // The index of the next input to parse.
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(inputs); i = int(next.Add(1) - 1) {
+				results[i].Value, results[i].Err = Parse(parser, inputs[i], options...)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// BatchErr[T] returns a *BatchError for the inputs whose results have errors, or nil if none of
+// them do.
+func BatchErr[T any](results []Result[T]) error {
+	var failed []*InputError
+	for i, r := range results {
+		if r.Err != nil {
+			failed = append(failed, &InputError{Index: i, Err: r.Err})
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: failed, Inputs: len(results)}
+}
+
+// An InputError is the error for one input of a batch, saying which it was.
+type InputError struct {
+	Index int   // The index of the input in the batch.
+	Err   error // What Parse returned for it.
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("input %d: %v", e.Index, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// A BatchError is the error for a batch in which some of the inputs failed to parse, as
+// returned by BatchErr.  errors.Is and errors.As look through each of the failures, so a
+// caller can ask, for instance, whether any input was over the limit of WithMaxInput.
+type BatchError struct {
+	Failed []*InputError // The errors of the inputs which failed, in the order of the inputs.
+	Inputs int           // How many inputs there were in all.
+}
+
+func (e *BatchError) Error() string {
+	text := fmt.Sprintf("%d of %d inputs failed to parse: %v", len(e.Failed), e.Inputs, e.Failed[0])
+	if len(e.Failed) > 1 {
+		text += fmt.Sprintf(" (and %d more)", len(e.Failed)-1)
+	}
+	return text
+}
+
+// Is reports whether any of the failures is target, for errors.Is.
+func (e *BatchError) Is(target error) bool {
+	for _, failed := range e.Failed {
+		if errors.Is(failed, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the failures which matches target, for errors.As.
+func (e *BatchError) As(target any) bool {
+	for _, failed := range e.Failed {
+		if errors.As(failed, target) {
+			return true
+		}
+	}
+	return false
+}
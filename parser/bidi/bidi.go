@@ -0,0 +1,131 @@
+// Package bidi defines simple grammar rules once and derives from each both a parser, built
+// with the parser package, and a printer, built with the printer package, so that a format's
+// grammar and its formatter can't drift apart.  A list of bindings, for instance:
+//
+//	name, number := Runes(unicode.IsLetter), Runes(unicode.IsDigit)
+//	binding := Pair(After(name, Literal(" = ")), number)
+//	comma := After(Literal(","), Space(unicode.IsSpace, printer.Line))
+//	bindings := Group(SepBy(binding, comma))
+//
+// parses "a = 1,b = 2" and prints it as "a = 1, b = 2", or with each binding on a line of its
+// own if they don't fit on one.  Rules for literals, sequences and separated lists are
+// provided; a rule for anything else can be made from a hand-written parser and printer for
+// it, as a Rule literal.
+package bidi
+
+import (
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/parser/printer"
+)
+
+// A Rule[T] is a parser for T together with a printer for it.  Print must give text which
+// Parse parses as the same value, so that printing and then parsing gets back what was printed.
+type Rule[T any] struct {
+	Parse parser.Parser[T]
+	Print func(T) printer.Doc
+}
+
+// Format returns v printed by rule, laid out for a page width runes wide.
+func Format[T any](rule Rule[T], width int, v T) string {
+	return printer.Render(width, rule.Print(v))
+}
+
+// Literal returns the Rule which parses and prints exactly text.
+func Literal(text string) Rule[parser.Empty] {
+	return Rule[parser.Empty]{
+		Parse: parser.Exactly(text),
+		Print: func(parser.Empty) printer.Doc { return printer.Text(text) },
+	}
+}
+
+// Runes returns the Rule for one or more runes for which condition returns true, such as a
+// name or a number, which prints the text it parsed as it is.
+func Runes(condition func(rune) bool) Rule[string] {
+	return Rule[string]{
+		Parse: parser.TakeSome(condition),
+		Print: printer.Text,
+	}
+}
+
+// Space returns the Rule for optional white space, as decided by isSpace, which prints as doc,
+// such as printer.Line to let the printer break the line there.
+func Space(isSpace func(rune) bool, doc printer.Doc) Rule[parser.Empty] {
+	return Rule[parser.Empty]{
+		Parse: parser.ConsumeWhile(isSpace),
+		Print: func(parser.Empty) printer.Doc { return doc },
+	}
+}
+
+// Map[T, U] returns the Rule for U made from rule by converting its values with to after
+// parsing, and back with from before printing.  The conversions must be inverses.
+func Map[T, U any](rule Rule[T], to func(T) U, from func(U) T) Rule[U] {
+	return Rule[U]{
+		Parse: parser.Map(rule.Parse, to),
+		Print: func(u U) printer.Doc { return rule.Print(from(u)) },
+	}
+}
+
+// A Tuple[T, U] is the value of a Pair.
+type Tuple[T, U any] struct {
+	First  T
+	Second U
+}
+
+// Pair[T, U] returns the Rule for first followed by second, whose value is both of theirs.
+func Pair[T, U any](first Rule[T], second Rule[U]) Rule[Tuple[T, U]] {
+	return Rule[Tuple[T, U]]{
+		Parse: parser.Apply2(parser.AppendKeeping(parser.StartKeeping(first.Parse), second.Parse),
+			func(t T, u U) Tuple[T, U] { return Tuple[T, U]{First: t, Second: u} }),
+		Print: func(v Tuple[T, U]) printer.Doc {
+			return printer.Concat(first.Print(v.First), second.Print(v.Second))
+		},
+	}
+}
+
+// Before[T] returns the Rule for literal followed by rule, whose value is rule's.
+func Before[T any](literal Rule[parser.Empty], rule Rule[T]) Rule[T] {
+	return Rule[T]{
+		Parse: parser.Apply(parser.AppendKeeping(parser.StartSkipping(literal.Parse), rule.Parse), func(t T) T { return t }),
+		Print: func(t T) printer.Doc { return printer.Concat(literal.Print(parser.Empty{}), rule.Print(t)) },
+	}
+}
+
+// After[T] returns the Rule for rule followed by literal, whose value is rule's.
+func After[T any](rule Rule[T], literal Rule[parser.Empty]) Rule[T] {
+	return Rule[T]{
+		Parse: parser.Apply(parser.AppendSkipping(parser.StartKeeping(rule.Parse), literal.Parse), func(t T) T { return t }),
+		Print: func(t T) printer.Doc { return printer.Concat(rule.Print(t), literal.Print(parser.Empty{})) },
+	}
+}
+
+// SepBy[T] returns the Rule for zero or more items separated by separator, as parsed by
+// parser.SepBy.  To let the printer break a long list into lines, end separator with a Space
+// which prints as a line break, and Group the list.
+func SepBy[T any](item Rule[T], separator Rule[parser.Empty]) Rule[[]T] {
+	return Rule[[]T]{
+		Parse: parser.SepBy(item.Parse, separator.Parse),
+		Print: func(items []T) printer.Doc {
+			docs := make([]printer.Doc, len(items))
+			for i, t := range items {
+				docs[i] = item.Print(t)
+			}
+			return printer.Join(separator.Print(parser.Empty{}), docs...)
+		},
+	}
+}
+
+// Group[T] returns rule printed as a printer.Group, on one line if it fits.
+func Group[T any](rule Rule[T]) Rule[T] {
+	return Rule[T]{
+		Parse: rule.Parse,
+		Print: func(t T) printer.Doc { return printer.Group(rule.Print(t)) },
+	}
+}
+
+// Nest[T] returns rule printed with the lines which begin inside it indented by indent more.
+func Nest[T any](indent int, rule Rule[T]) Rule[T] {
+	return Rule[T]{
+		Parse: rule.Parse,
+		Print: func(t T) printer.Doc { return printer.Nest(indent, rule.Print(t)) },
+	}
+}
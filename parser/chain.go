@@ -0,0 +1,79 @@
+package parser
+
+// ChainLeft1[T] returns a Parser[T] for one or more terms separated by binary operators which
+// associate to the left, as subtraction does: "1-2-3" is (1-2)-3.  The op parser matches an
+// operator and returns the function which combines the values of the terms either side of it,
+// so ChainLeft1 combines them from the left as it goes.  For operators of several precedences,
+// chain the tighter ones first and use the result as the term of the looser ones:
+//
+//	product := ChainLeft1(number, OneOf(times, divide))
+//	sum := ChainLeft1(product, OneOf(plus, minus))
+//
+// As with SepBy, an operator not followed by a term is left unconsumed, and the chain ends
+// when either fails with ErrNoMatch or ErrUnconsumedInput; any other error fails the whole
+// chain.  There must be at least one term.
+func ChainLeft1[T any](term Parser[T], op Parser[func(T, T) T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		terms, ops, next, err := chain(initial, term, op)
+		if err != nil {
+			var zero T
+			return zero, initial, err
+		}
+		t := terms[0]
+		for i, f := range ops {
+			t = f(t, terms[i+1])
+		}
+		return t, next, nil
+	}
+}
+
+// ChainRight1[T] is like ChainLeft1, but for operators which associate to the right, as
+// exponentiation does: "2^3^2" is 2^(3^2).  The terms and operators are all parsed before any
+// are combined, so a long chain doesn't recurse deeply.
+func ChainRight1[T any](term Parser[T], op Parser[func(T, T) T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		terms, ops, next, err := chain(initial, term, op)
+		if err != nil {
+			var zero T
+			return zero, initial, err
+		}
+		t := terms[len(terms)-1]
+		for i := len(ops) - 1; i >= 0; i-- {
+			t = ops[i](terms[i], t)
+		}
+		return t, next, nil
+	}
+}
+
+// chain parses the terms and operators for ChainLeft1 and ChainRight1, returning them in input
+// order, so that there is one more term than operators, and the state after the last term.
+func chain[T any](initial State, term Parser[T], op Parser[func(T, T) T]) ([]T, []func(T, T) T, State, error) {
+	t, current, err := term(initial)
+	if err != nil {
+		return nil, nil, initial, err
+	}
+	terms := []T{t}
+	var ops []func(T, T) T
+	for {
+		current.tick()
+		if current.overBudget() {
+			return nil, nil, initial, ErrBudgetExceeded
+		}
+		checkpoint := current.Save()
+		f, afterOp, err := op(current)
+		if err == nil {
+			t, next, err := term(afterOp)
+			if err == nil && next.offset > current.offset {
+				terms, ops = append(terms, t), append(ops, f)
+				current = next
+				continue
+			}
+			if err != nil && !isNoMatch(err) {
+				return nil, nil, initial, err
+			}
+		} else if !isNoMatch(err) {
+			return nil, nil, initial, err
+		}
+		return terms, ops, current.Restore(checkpoint), nil
+	}
+}
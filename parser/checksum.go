@@ -0,0 +1,92 @@
+package parser
+
+import "errors"
+
+// ErrChecksumMismatch is returned by a Checksummed parser whose stated checksum doesn't match its contents.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Recognized[T] is a value together with the exact text it was parsed from.
+type Recognized[T any] struct {
+	Value T
+	Text  string
+}
+
+// Recognize[T] returns a Parser which succeeds exactly when the parser argument succeeds,
+// returning its value along with the text it consumed.  It is GetString for when the value
+// is needed as well as the text.
+func Recognize[T any](parser Parser[T]) Parser[Recognized[T]] {
+	return func(initial State) (Recognized[T], State, error) {
+		t, next, err := parser(initial)
+		if err != nil {
+			return Recognized[T]{}, initial, err
+		}
+		return Recognized[T]{Value: t, Text: next.data[initial.offset:next.offset]}, next, nil
+	}
+}
+
+// Checksummed[T, C] returns a Parser[T] for formats which end in a checksum of what came
+// before, such as NMEA sentences ("...*4A") or lines with a trailing CRC.  It parses body,
+// then trailer, which parses the stated checksum, and then calls verify with the exact text
+// body consumed and the checksum.  If verify returns false, the parser fails with
+// ErrChecksumMismatch; otherwise it returns body's value, having consumed both.
+//
+// ErrChecksumMismatch is not a no-match, so a OneOf containing a Checksummed parser reports
+// the corruption rather than trying its other alternatives.
+func Checksummed[T, C any](body Parser[T], trailer Parser[C], verify func(text string, checksum C) bool) Parser[T] {
+	recognize := Recognize(body)
+	return func(initial State) (T, State, error) {
+		var zero T
+		r, afterBody, err := recognize(initial)
+		if err != nil {
+			return zero, initial, err
+		}
+		checksum, next, err := trailer(afterBody)
+		if err != nil {
+			return zero, initial, err
+		}
+		if !verify(r.Text, checksum) {
+			return zero, initial, ErrChecksumMismatch
+		}
+		return r.Value, next, nil
+	}
+}
+
+// Verify[T] returns a Parser[T] which parses with the parser argument and then calls check on
+// its value, for rules which are easier to check on the value than to write into the grammar.
+// If check returns an error the parser fails with it; otherwise it returns the value.  Which
+// error check returns decides how a failure is treated: ErrNoMatch lets a OneOf go on to its
+// next alternative, while ErrChecksumMismatch, or an error of the grammar's own, reports the
+// problem straight away.
+func Verify[T any](parser Parser[T], check func(T) error) Parser[T] {
+	return func(initial State) (T, State, error) {
+		t, next, err := parser(initial)
+		if err == nil {
+			err = check(t)
+		}
+		if err != nil {
+			var zero T
+			return zero, initial, err
+		}
+		return t, next, nil
+	}
+}
+
+// Filter[T] returns a Parser[T] which parses with the parser argument and returns its value if
+// keep returns true for it, and otherwise doesn't match, failing with an ExpectedError labelled
+// label at the offset where it began, as if the parser were made with Label.  So a rule such as
+// "no leading zeros" reads as what was expected in the error, rather than a bare ErrNoMatch,
+// and OneOf still goes on to its next alternative.  Use Verify for a check which should report
+// its own error instead.
+func Filter[T any](parser Parser[T], keep func(T) bool, label string) Parser[T] {
+	return func(initial State) (T, State, error) {
+		t, next, err := parser(initial)
+		if err == nil && !keep(t) {
+			err = initial.expect(&ExpectedError{Offset: initial.Offset(), Expected: []string{label}})
+		}
+		if err != nil {
+			var zero T
+			return zero, initial, err
+		}
+		return t, next, nil
+	}
+}
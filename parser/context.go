@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ContextError is the error returned by a parser made with InContext which fails, saying which
+// parts of the grammar the parse was inside: "in configuration > binding > value: expected int
+// or bool".  Err is the error from the innermost part, and ContextError unwraps to it, so
+// errors.Is(err, ErrNoMatch) still works and OneOf still backtracks past it.
+type ContextError struct {
+	Context []string // The names given to InContext, outermost first.
+	Err     error
+}
+
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("in %s: %v", strings.Join(e.Context, " > "), e.Err)
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// InContext[T] returns a Parser[T] which behaves like the parser argument, except that when it
+// fails, the failure is wrapped in a *ContextError with name at the front of its Context.  So
+// errors from a grammar which names its parts this way say where in the grammar they happened,
+// and the *ParseError from Parse has the same names in its Context field.
+//
+// An error wrapped by InContext is no longer an *ExpectedError itself, so a OneOf whose
+// alternatives are in contexts doesn't merge their labels; put Label outside InContext, or
+// InContext outside the OneOf, to have both.
+func InContext[T any](name string, parser Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		result, next, err := named(name, parser, initial)
+		if err == nil {
+			return result, next, nil
+		}
+		if inner, ok := err.(*ContextError); ok {
+			context := append([]string{name}, inner.Context...)
+			return result, initial, &ContextError{Context: context, Err: inner.Err}
+		}
+		return result, initial, &ContextError{Context: []string{name}, Err: err}
+	}
+}
+
+// contextOf returns the Context of the *ContextError in err's chain, if there is one.
+func contextOf(err error) []string {
+	var contextErr *ContextError
+	if errors.As(err, &contextErr) {
+		return contextErr.Context
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package parser
+
+// A SyntaxNode is a node of the concrete syntax tree recorded by WithSyntaxTree: the name of a
+// part of the grammar, the Span of input it matched, and the nodes for the named parts inside
+// it, in input order.  The tree is lossless, in that the text of any node, including what lies
+// between its children, can be got back from the input with Text.
+type SyntaxNode struct {
+	Name     string
+	Span     Span
+	Children []*SyntaxNode
+}
+
+// Text returns the text of input which the node matched.
+func (n *SyntaxNode) Text(input string) string {
+	return input[n.Span.Start:n.Span.End]
+}
+
+// WithSyntaxTree returns an Option which makes Parse record a concrete syntax tree as it goes,
+// with a SyntaxNode for each named part of the grammar which matched: each parser made with
+// InContext, Label or Rule, named as given to it.  If the parse succeeds, root becomes the root
+// of the tree, with no name, the Span of the whole input, and the outermost named parts as its
+// children; otherwise root is left as it was.  The typed result of Parse is unaffected, so
+// formatters, highlighters and the like can have the tree for a grammar which was written
+// without them in mind:
+//
+//	var tree SyntaxNode
+//	config, err := Parse(configFile, input, WithSyntaxTree(&tree))
+//
+// Only the parts on the path the parse finally took are recorded.  Parsers in this package
+// which backtrack forget the nodes recorded by what they backtracked over, as a hand-written
+// parser does by using Save and Restore.  Nodes replayed by a Memo parser may be shared with
+// the trees of other parses using the same SharedMemo, so treat the tree as read-only.
+func WithSyntaxTree(root *SyntaxNode) Option {
+	return func(c *config) {
+		c.tree = root
+	}
+}
+
+// syntaxTree is the syntax tree being recorded by a parse with WithSyntaxTree.
+type syntaxTree struct {
+	open []*SyntaxNode // The nodes of the named parsers now running, the root first.
+}
+
+// syntax returns the syntax tree the parse is recording, or nil if it isn't recording one.
+func (s State) syntax() *syntaxTree {
+	if s.run == nil {
+		return nil
+	}
+	return s.run.tree
+}
+
+// mark returns how many children the innermost open node has, for discarding any added after.
+func (t *syntaxTree) mark() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.open[len(t.open)-1].Children)
+}
+
+// since returns the children the innermost open node has been given since mark was called.
+func (t *syntaxTree) since(mark int) []*SyntaxNode {
+	if t == nil {
+		return nil
+	}
+	return append([]*SyntaxNode(nil), t.open[len(t.open)-1].Children[mark:]...)
+}
+
+// truncate discards the children the innermost open node has been given since mark was called.
+func (t *syntaxTree) truncate(mark int) {
+	if t == nil {
+		return
+	}
+	parent := t.open[len(t.open)-1]
+	if len(parent.Children) > mark {
+		parent.Children = parent.Children[:mark]
+	}
+}
+
+// adopt gives nodes to the innermost open node as its next children.
+func (t *syntaxTree) adopt(nodes []*SyntaxNode) {
+	if t == nil || len(nodes) == 0 {
+		return
+	}
+	parent := t.open[len(t.open)-1]
+	parent.Children = append(parent.Children, nodes...)
+}
+
+// named runs parser from initial, recording a SyntaxNode called name for what it matches if
+// the parse is recording a syntax tree, and checking the parser if the parse was run
+// WithInvariantChecks.  It is how InContext, Label, Rule and Use record theirs.
+func named[T any](name string, parser Parser[T], initial State) (T, State, error) {
+	if initial.checking() {
+		parser = Checked(name, parser)
+	}
+	t := initial.syntax()
+	if t == nil {
+		return parser(initial)
+	}
+	node := newNode(initial.arena(), name)
+	t.open = append(t.open, node)
+	result, next, err := parser(initial)
+	t.open = t.open[:len(t.open)-1]
+	if err == nil {
+		node.Span = Span{Start: initial.Offset(), End: next.Offset()}
+		t.adopt([]*SyntaxNode{node})
+	}
+	return result, next, err
+}
@@ -0,0 +1,180 @@
+// Package diag renders the errors returned by Parse in a form meant for people: the message,
+// the line of input where the parse failed, and a caret under the place, like this:
+//
+//	line 2, column 7: expected one of: number, string
+//	  2 | size = @large
+//	    |        ^
+//
+// When the failure came from parsers made with Label, the message lists what was expected
+// there, and when it came from inside parsers made with InContext, it names them too; any
+// grammar which labels its parts gets such messages without doing anything more.
+//
+// Applications which present errors in other ways, such as a one-line form for logs or a JSON
+// body for a web API, can say how with a Formatter, which is given each error already taken
+// apart as a Problem, so nothing has to be got back out of a message string.  Linters which
+// report to GitHub code scanning and the like can collect their errors in a SARIF log instead.
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Problem is a single parse error taken apart for a Formatter.
+type Problem struct {
+	Offset      int      // Byte offset from the start of the input.
+	Line        int      // 1-based line number.
+	Column      int      // 1-based column, counted in runes.
+	Message     string   // What went wrong, without the position or context, e.g. "expected number".
+	Expected    []string // What was expected, if the error came from Label, Keyword or Exactly.
+	Suggestions []string // Suggested corrections, nearest first, if the error came from Keyword.
+	Context     []string // The names of the InContext parsers, outermost first.
+	Fixes       []Fix    // Suggested corrections, if the grammar offers any; see WithFix.
+	Source      string   // The text of the line the error is on, without its line break.
+	Indent      string   // Whitespace as wide as Source up to the error, to put a caret after.
+	Err         error    // The underlying error, for errors.Is and errors.As.
+}
+
+// Describe returns the Problem for e, a *ParseError from parsing input.
+func Describe(input string, e *ParseError) Problem {
+	offset := e.Offset
+	if offset > len(input) {
+		offset = len(input)
+	}
+	start := strings.LastIndexByte(input[:offset], '\n') + 1
+	end := strings.IndexByte(input[offset:], '\n')
+	if end < 0 {
+		end = len(input)
+	} else {
+		end += offset
+	}
+	p := Problem{
+		Offset:  e.Offset,
+		Line:    e.Line,
+		Column:  e.Column,
+		Context: e.Context,
+		Fixes:   e.Fixes,
+		Source:  strings.TrimSuffix(input[start:end], "\r"),
+		Indent:  indent(input[start:offset]),
+		Message: e.Message(),
+		Err:     e.Err,
+	}
+	var expected *ExpectedError
+	if errors.As(e.Err, &expected) {
+		p.Expected, p.Suggestions = expected.Expected, expected.Suggestions
+	}
+	return p
+}
+
+// A Formatter presents a Problem as text.
+type Formatter interface {
+	Format(p Problem) string
+}
+
+// FormatterFunc is a function which implements Formatter.
+type FormatterFunc func(p Problem) string
+
+func (f FormatterFunc) Format(p Problem) string {
+	return f(p)
+}
+
+// heading returns the first line of the Excerpt and Short formats.
+func heading(p Problem) string {
+	if len(p.Context) > 0 {
+		return fmt.Sprintf("line %d, column %d: in %s: %s", p.Line, p.Column, strings.Join(p.Context, " > "), p.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", p.Line, p.Column, p.Message)
+}
+
+// Excerpt is the Formatter used by Render, which shows the line of input with a caret under the
+// place, as in the package documentation.
+var Excerpt Formatter = FormatterFunc(func(p Problem) string {
+	number := fmt.Sprint(p.Line)
+	gutter := strings.Repeat(" ", len(number))
+	return fmt.Sprintf("%s\n  %s | %s\n  %s | %s^", heading(p), number, p.Source, gutter, p.Indent)
+})
+
+// Short is a Formatter which gives just the first line of Excerpt, for logs and the like.
+var Short Formatter = FormatterFunc(heading)
+
+// Template returns a Formatter which executes a text/template with the Problem as its data, so
+// that `{{.Line}}:{{.Column}}: {{.Message}}` gives "3:14: expected number".  It returns an error if
+// text doesn't parse as a template.  If executing the template fails, the Formatter returns the
+// Short format followed by the template's error, rather than losing the problem altogether.
+func Template(text string) (Formatter, error) {
+	t, err := template.New("problem").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return FormatterFunc(func(p Problem) string {
+		var b strings.Builder
+		if err := t.Execute(&b, p); err != nil {
+			return heading(p) + " (" + err.Error() + ")"
+		}
+		return b.String()
+	}), nil
+}
+
+// Render returns a description of err, an error returned by Parse for input, showing where in
+// input it is.  If err is Diagnostics, each of them is shown, separated by blank lines.  An
+// error which doesn't say where it is, such as ErrInputTooLarge, is just given as its message.
+func Render(input string, err error) string {
+	return RenderWith(Excerpt, input, err)
+}
+
+// RenderWith is like Render, but presents each parse error with the formatter f.  Diagnostics
+// are separated by newlines, or blank lines if any of them takes more than one line.
+func RenderWith(f Formatter, input string, err error) string {
+	problems := Problems(input, err)
+	if problems == nil {
+		return err.Error()
+	}
+	rendered := make([]string, len(problems))
+	separator := "\n"
+	for i, p := range problems {
+		rendered[i] = f.Format(p)
+		if strings.Contains(rendered[i], "\n") {
+			separator = "\n\n"
+		}
+	}
+	return strings.Join(rendered, separator)
+}
+
+// Problems returns the Problems in err, an error returned by Parse for input: one for a
+// *ParseError, one for each of Diagnostics, and none for an error which doesn't say where it is.
+func Problems(input string, err error) []Problem {
+	var diags Diagnostics
+	if errors.As(err, &diags) {
+		problems := make([]Problem, len(diags))
+		for i, d := range diags {
+			problems[i] = Describe(input, d)
+		}
+		return problems
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return []Problem{Describe(input, parseErr)}
+	}
+	return nil
+}
+
+// indent returns the whitespace which lines up with text when printed beneath it: a space for
+// each rune, but tabs kept as tabs, so that the caret lands under the right character however
+// wide the terminal's tab stops are.
+func indent(text string) string {
+	var b strings.Builder
+	b.Grow(utf8.RuneCountInString(text))
+	for _, r := range text {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
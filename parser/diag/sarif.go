@@ -0,0 +1,217 @@
+package diag
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Tool describes the program reporting parse errors in a SARIF log.  Name is required by
+// SARIF; Version and InformationURI are left out if empty.
+type Tool struct {
+	Name           string
+	Version        string
+	InformationURI string
+}
+
+// RuleID is the SARIF rule which every parse error is reported under.
+const RuleID = "parse-error"
+
+// A SARIF collects the parse errors found in one or more inputs, such as the files a linter was
+// run on, into a log in the Static Analysis Results Interchange Format, version 2.1.0, the form
+// read by GitHub code scanning and other tools.  Create one with NewSARIF, Add the result of
+// each Parse, and then encode it with encoding/json.
+type SARIF struct {
+	tool    Tool
+	results []sarifResult
+}
+
+// NewSARIF returns an empty SARIF log for errors reported by tool.
+func NewSARIF(tool Tool) *SARIF {
+	return &SARIF{tool: tool}
+}
+
+// Add records err, an error returned by Parse for input, which was read from the file or other
+// artifact at uri, a URI or a path relative to the root of the repository.  Each of Diagnostics
+// is a result of its own, located by line and column; an error which doesn't say where it is,
+// such as ErrInputTooLarge, is a result located in the artifact as a whole.  Nothing is recorded
+// if err is nil.
+func (s *SARIF) Add(uri, input string, err error) {
+	if err == nil {
+		return
+	}
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: uri},
+	}}
+	problems := Problems(input, err)
+	if problems == nil {
+		s.results = append(s.results, sarifResult{
+			RuleID:    RuleID,
+			Level:     "error",
+			Message:   sarifMessage{Text: err.Error()},
+			Locations: []sarifLocation{location},
+		})
+		return
+	}
+	for _, p := range problems {
+		text := p.Message
+		if len(p.Context) > 0 {
+			text = "in " + strings.Join(p.Context, " > ") + ": " + text
+		}
+		location.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   p.Line,
+			StartColumn: p.Column,
+			ByteOffset:  p.Offset,
+		}
+		result := sarifResult{
+			RuleID:    RuleID,
+			Level:     "error",
+			Message:   sarifMessage{Text: text},
+			Locations: []sarifLocation{location},
+		}
+		for _, f := range p.Fixes {
+			result.Fixes = append(result.Fixes, sarifFixOf(uri, f))
+		}
+		if p.Expected != nil || p.Suggestions != nil || p.Context != nil {
+			result.Properties = &sarifProperties{Expected: p.Expected, Suggestions: p.Suggestions, Context: p.Context}
+		}
+		s.results = append(s.results, result)
+	}
+}
+
+// Len returns how many results have been recorded, so that a linter can choose its exit status.
+func (s *SARIF) Len() int {
+	return len(s.results)
+}
+
+// MarshalJSON implements json.Marshaler, giving the SARIF log with a single run.  Columns are
+// counted in runes, as in ParseError, which the run declares with its columnKind, since SARIF
+// otherwise counts them in UTF-16 code units.
+func (s *SARIF) MarshalJSON() ([]byte, error) {
+	results := s.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+	return json.Marshal(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           s.tool.Name,
+				Version:        s.tool.Version,
+				InformationURI: s.tool.InformationURI,
+				Rules: []sarifRule{{
+					ID:               RuleID,
+					ShortDescription: sarifMessage{Text: "The input does not follow the grammar."},
+				}},
+			}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	})
+}
+
+// The types below are the parts of the SARIF 2.1.0 schema which SARIF uses.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string           `json:"ruleId"`
+	Level      string           `json:"level"`
+	Message    sarifMessage     `json:"message"`
+	Locations  []sarifLocation  `json:"locations"`
+	Fixes      []sarifFix       `json:"fixes,omitempty"`
+	Properties *sarifProperties `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	ByteOffset  int `json:"byteOffset"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifByteRegion `json:"deletedRegion"`
+	InsertedContent *sarifMessage   `json:"insertedContent,omitempty"`
+}
+
+// sarifByteRegion is a region given by byte offsets, which is how an Edit gives its Span.
+type sarifByteRegion struct {
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+}
+
+// sarifFixOf returns the SARIF form of f, a Fix for the artifact at uri.
+func sarifFixOf(uri string, f Fix) sarifFix {
+	change := sarifArtifactChange{ArtifactLocation: sarifArtifactLocation{URI: uri}}
+	for _, e := range f.Edits {
+		r := sarifReplacement{DeletedRegion: sarifByteRegion{ByteOffset: e.Span.Start, ByteLength: e.Span.End - e.Span.Start}}
+		if e.Text != "" {
+			r.InsertedContent = &sarifMessage{Text: e.Text}
+		}
+		change.Replacements = append(change.Replacements, r)
+	}
+	return sarifFix{Description: sarifMessage{Text: f.Title}, ArtifactChanges: []sarifArtifactChange{change}}
+}
+
+// sarifProperties is the property bag of a result, holding what ParseError's JSON form has
+// which SARIF has no place for.
+type sarifProperties struct {
+	Expected    []string `json:"expected,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Context     []string `json:"context,omitempty"`
+}
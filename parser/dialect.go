@@ -0,0 +1,44 @@
+package parser
+
+// Dialects let one grammar definition serve a family of related languages.  Rather than
+// copying a grammar to make a variant, wrap the productions that differ with Feature or
+// IfFeature, and choose which features are on for each parse with WithFeatures:
+//
+//	trailingComma := IfFeature("trailing-commas", Exactly(","))
+//	...
+//	Parse(grammar, input, WithFeatures("trailing-commas"))
+
+// WithFeatures returns an Option which enables the named dialect features for the parse.
+// Features not named are disabled.
+func WithFeatures(features ...string) Option {
+	return func(c *config) {
+		if c.features == nil {
+			c.features = make(map[string]bool)
+		}
+		for _, feature := range features {
+			c.features[feature] = true
+		}
+	}
+}
+
+// Feature[T] returns a Parser[T] which runs the enabled parser if the named feature is enabled
+// for the current parse, and the disabled parser otherwise.
+func Feature[T any](name string, enabled Parser[T], disabled Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		if initial.featureEnabled(name) {
+			return enabled(initial)
+		}
+		return disabled(initial)
+	}
+}
+
+// IfFeature[T] returns a Parser[T] which runs the parser argument if the named feature is
+// enabled for the current parse, and fails otherwise.
+func IfFeature[T any](name string, parser Parser[T]) Parser[T] {
+	return Feature(name, parser, Fail[T])
+}
+
+// featureEnabled reports whether the named feature was enabled with WithFeatures.
+func (s State) featureEnabled(name string) bool {
+	return s.run != nil && s.run.features[name]
+}
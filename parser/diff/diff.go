@@ -0,0 +1,193 @@
+// Package diff compares two trees parsed by grammars built with the parser package and reports
+// what changed between them as spans of the two inputs, for tools which want to say which parts
+// of a document changed, such as which bindings of a configuration file, rather than which lines.
+//
+// The trees can be syntax trees recorded with parser.WithSyntaxTree, seen through Syntax, or a
+// grammar's own result types, if they implement Node.
+package diff
+
+import (
+	"fmt"
+
+	. "github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Node is a node of a parsed tree, as Diff sees it.
+type Node interface {
+	Kind() string     // What sort of node it is, such as the name of its rule.
+	Text() string     // The input the node was parsed from.
+	Span() Span       // Where that input is.
+	Children() []Node // The nodes for the parts of it, in input order.
+}
+
+// Syntax returns root, a syntax tree recorded while parsing input, as a Node.
+func Syntax(root *SyntaxNode, input string) Node {
+	return syntaxNode{root, input}
+}
+
+type syntaxNode struct {
+	node  *SyntaxNode
+	input string
+}
+
+func (n syntaxNode) Kind() string { return n.node.Name }
+func (n syntaxNode) Text() string { return n.node.Text(n.input) }
+func (n syntaxNode) Span() Span   { return n.node.Span }
+
+func (n syntaxNode) Children() []Node {
+	children := make([]Node, len(n.node.Children))
+	for i, c := range n.node.Children {
+		children[i] = syntaxNode{c, n.input}
+	}
+	return children
+}
+
+// An Op says how a node differs between the trees.
+type Op int
+
+const (
+	Changed  Op = iota // The node is in both trees, but its text differs.
+	Inserted           // The node is only in the new tree.
+	Deleted            // The node is only in the old tree.
+)
+
+func (op Op) String() string {
+	switch op {
+	case Changed:
+		return "changed"
+	case Inserted:
+		return "inserted"
+	case Deleted:
+		return "deleted"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// A Change is a difference between two trees.
+type Change struct {
+	Op   Op
+	Path []string // The Kinds of the nodes from the root down to the one which changed, if not "".
+	Old  Span     // The node in the old input; for Inserted, the empty Span where it would go.
+	New  Span     // The node in the new input; for Deleted, the empty Span where it was.
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%v %v: %d-%d -> %d-%d", c.Op, c.Path, c.Old.Start, c.Old.End, c.New.Start, c.New.End)
+}
+
+// Diff returns the Changes which turn the tree old into the tree new, in input order.  Nodes
+// with the same Kind and Text are the same; between those, nodes of the same Kind are taken to
+// be one node which changed, and compared part by part, so that the Changes are as small as
+// the trees allow.  A node whose Kind differs, or which has no parts, changes as a whole.
+func Diff(old, new Node) []Change {
+	var changes []Change
+	compare(&changes, nil, old, new)
+	return changes
+}
+
+// compare appends the Changes between old and new, which are at path, to changes.
+func compare(changes *[]Change, path []string, old, new Node) {
+	if old.Kind() != new.Kind() {
+		*changes = append(*changes, Change{Op: Changed, Path: extend(path, new.Kind()), Old: old.Span(), New: new.Span()})
+		return
+	}
+	if old.Text() == new.Text() {
+		return
+	}
+	path = extend(path, new.Kind())
+	oldChildren, newChildren := old.Children(), new.Children()
+	if len(oldChildren) == 0 || len(newChildren) == 0 {
+		*changes = append(*changes, Change{Op: Changed, Path: path, Old: old.Span(), New: new.Span()})
+		return
+	}
+	compareLists(changes, path, oldChildren, newChildren, old.Span().Start, new.Span().Start)
+}
+
+// compareLists appends the Changes between the lists of nodes old and new, which are at path and
+// begin at offsets oldAt and newAt.  The nodes the lists have in common, as found by a longest
+// common subsequence, anchor the comparison; the nodes between them are compared by compareGap.
+func compareLists(changes *[]Change, path []string, old, new []Node, oldAt, newAt int) {
+	// common[i][j] is the length of the longest common subsequence of old[i:] and new[j:].
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			switch {
+			case same(old[i], new[j]):
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	gapI, gapJ := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case same(old[i], new[j]):
+			compareGap(changes, path, old[gapI:i], new[gapJ:j], oldAt, newAt)
+			oldAt, newAt = old[i].Span().End, new[j].Span().End
+			i, j = i+1, j+1
+			gapI, gapJ = i, j
+		case common[i+1][j] >= common[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	compareGap(changes, path, old[gapI:], new[gapJ:], oldAt, newAt)
+}
+
+// compareGap appends the Changes between old and new, runs of nodes with nothing in common
+// which begin at offsets oldAt and newAt.  Nodes of the same Kind are paired up in order and
+// compared; the rest are Inserted or Deleted.
+func compareGap(changes *[]Change, path []string, old, new []Node, oldAt, newAt int) {
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i].Kind() == new[j].Kind():
+			compare(changes, path, old[i], new[j])
+			oldAt, newAt = old[i].Span().End, new[j].Span().End
+			i, j = i+1, j+1
+		case j < len(new) && (i == len(old) || hasKind(new[j+1:], old[i].Kind())):
+			span := new[j].Span()
+			*changes = append(*changes, Change{Op: Inserted, Path: extend(path, new[j].Kind()), Old: Span{Start: oldAt, End: oldAt}, New: span})
+			newAt = span.End
+			j++
+		default:
+			span := old[i].Span()
+			*changes = append(*changes, Change{Op: Deleted, Path: extend(path, old[i].Kind()), Old: span, New: Span{Start: newAt, End: newAt}})
+			oldAt = span.End
+			i++
+		}
+	}
+}
+
+// same reports whether a and b are the same node, unchanged.
+func same(a, b Node) bool {
+	return a.Kind() == b.Kind() && a.Text() == b.Text()
+}
+
+// hasKind reports whether any of nodes is of the given kind.
+func hasKind(nodes []Node, kind string) bool {
+	for _, n := range nodes {
+		if n.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// extend returns path with kind added, unless it is "", as the root of a syntax tree's is.  It
+// doesn't share storage with path, since paths are kept in Changes.
+func extend(path []string, kind string) []string {
+	extended := append(make([]string, 0, len(path)+1), path...)
+	if kind != "" {
+		extended = append(extended, kind)
+	}
+	return extended
+}
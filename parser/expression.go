@@ -0,0 +1,183 @@
+package parser
+
+import "math"
+
+// An OperatorTable[T] lists the operators of an expression grammar, for Expression: each one a
+// parser which matches the operator and returns the function which applies it, along with its
+// precedence, where operators of higher precedence bind more tightly.  The zero value is an
+// empty table; add operators with its methods, before the Expression parser runs.
+//
+//	var ops OperatorTable[float64]
+//	ops.InfixLeft(1, plus)
+//	ops.InfixLeft(1, minus)
+//	ops.InfixLeft(2, times)
+//	ops.Prefix(3, negate)
+//	ops.InfixRight(4, power)
+//	ops.Postfix(5, factorial)
+//	arithmetic := Expression(number, &ops)
+type OperatorTable[T any] struct {
+	prefix  []operator[func(T) T]
+	infix   []operator[func(T, T) T]
+	postfix []operator[func(T) T]
+}
+
+// operator is an entry in an OperatorTable.
+type operator[F any] struct {
+	precedence int
+	right      bool // For infix operators, whether they associate to the right.
+	parser     Parser[F]
+}
+
+// Prefix adds an operator which comes before its operand, as negation does.  The operand is an
+// expression of operators of the same or higher precedence, so -x^2 is -(x^2) if "^" is of
+// higher precedence than "-".  A prefix operator may begin any operand, whatever the operators
+// around it, so 2^-1 is 2^(-1).
+func (t *OperatorTable[T]) Prefix(precedence int, op Parser[func(T) T]) {
+	t.prefix = append(t.prefix, operator[func(T) T]{precedence: precedence, parser: op})
+}
+
+// InfixLeft adds a binary operator which associates to the left, as subtraction does.
+func (t *OperatorTable[T]) InfixLeft(precedence int, op Parser[func(T, T) T]) {
+	t.infix = append(t.infix, operator[func(T, T) T]{precedence: precedence, parser: op})
+}
+
+// InfixRight adds a binary operator which associates to the right, as exponentiation does.
+func (t *OperatorTable[T]) InfixRight(precedence int, op Parser[func(T, T) T]) {
+	t.infix = append(t.infix, operator[func(T, T) T]{precedence: precedence, right: true, parser: op})
+}
+
+// Postfix adds an operator which comes after its operand, as the factorial "!" does.
+func (t *OperatorTable[T]) Postfix(precedence int, op Parser[func(T) T]) {
+	t.postfix = append(t.postfix, operator[func(T) T]{precedence: precedence, parser: op})
+}
+
+// Expression[T] returns a Parser[T] for expressions made of the term parser's terms and the
+// operators in the table, combining their values with the operators' functions according to
+// their precedence and associativity.  Parenthesized expressions are terms like any other;
+// use a ParserRef to let the term parser refer back to the expression.
+//
+// At each point, operators are tried in the order they were added to the table, postfix
+// before infix.  A prefix operator whose operand doesn't match is taken to be the start of a
+// term instead, and an infix operator not followed by an operand is left unconsumed, as with
+// ChainLeft1.  Other than ErrNoMatch and ErrUnconsumedInput, an error from the term parser or
+// an operator fails the whole expression.
+func Expression[T any](term Parser[T], table *OperatorTable[T]) Parser[T] {
+	var expression func(initial State, min int) (T, State, error)
+	expression = func(initial State, min int) (T, State, error) {
+		var zero T
+		var left T
+		current := initial
+		checkpoint := initial.Save()
+		prefix, f, after, ok, err := matchOperator(initial, table.prefix, math.MinInt)
+		if err != nil {
+			return zero, initial, err
+		}
+		if ok {
+			operand, next, err := expression(after, prefix.precedence)
+			switch {
+			case err == nil:
+				left, current = f(operand), next
+			case isNoMatch(err):
+				ok = false
+				initial = initial.Restore(checkpoint)
+			default:
+				return zero, initial, err
+			}
+		}
+		if !ok {
+			left, current, err = term(initial)
+			if err != nil {
+				return zero, initial, err
+			}
+		}
+		for {
+			current.tick()
+			if current.overBudget() {
+				return zero, initial, ErrBudgetExceeded
+			}
+			checkpoint := current.Save()
+			_, f, afterOp, ok, err := matchOperator(current, table.postfix, min)
+			if err != nil {
+				return zero, initial, err
+			}
+			if ok && afterOp.offset > current.offset {
+				left, current = f(left), afterOp
+				continue
+			}
+			current = current.Restore(checkpoint)
+			infix, g, afterOp, ok, err := matchOperator(current, table.infix, min)
+			if err != nil {
+				return zero, initial, err
+			}
+			if !ok {
+				break
+			}
+			rightMin := infix.precedence + 1
+			if infix.right {
+				rightMin = infix.precedence
+			}
+			right, afterRight, err := expression(afterOp, rightMin)
+			if err != nil && !isNoMatch(err) {
+				return zero, initial, err
+			}
+			if err != nil || afterRight.offset == current.offset {
+				current = current.Restore(checkpoint)
+				break
+			}
+			left, current = g(left, right), afterRight
+		}
+		return left, current, nil
+	}
+	return func(initial State) (T, State, error) {
+		var zero T
+		t, next, err := expression(initial, minPrecedence(table))
+		if err != nil {
+			return zero, initial, err
+		}
+		return t, next, nil
+	}
+}
+
+// matchOperator tries each of the operators of at least precedence min in turn at initial,
+// and returns the first which matches, with its function and the state after it.  It reports
+// whether one matched; the error is only for failures other than not matching.
+func matchOperator[F any](initial State, operators []operator[F], min int) (operator[F], F, State, bool, error) {
+	for _, op := range operators {
+		if op.precedence < min {
+			continue
+		}
+		checkpoint := initial.Save()
+		f, next, err := op.parser(initial)
+		if err == nil {
+			return op, f, next, true, nil
+		}
+		initial = initial.Restore(checkpoint)
+		if !isNoMatch(err) {
+			var zero F
+			return op, zero, initial, false, err
+		}
+	}
+	var zero F
+	return operator[F]{}, zero, initial, false, nil
+}
+
+// minPrecedence returns the lowest precedence of any operator in the table, at which the
+// whole expression is parsed, so that precedences may be any ints, negative ones included.
+func minPrecedence[T any](table *OperatorTable[T]) int {
+	min, first := 0, true
+	lower := func(p int) {
+		if first || p < min {
+			min, first = p, false
+		}
+	}
+	for _, op := range table.prefix {
+		lower(op.precedence)
+	}
+	for _, op := range table.infix {
+		lower(op.precedence)
+	}
+	for _, op := range table.postfix {
+		lower(op.precedence)
+	}
+	return min
+}
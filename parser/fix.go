@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// An Edit is a change to the input: the text in Span replaced by Text.  An Edit whose Span is
+// empty inserts Text, and one whose Text is empty deletes the Span.
+type Edit struct {
+	Span Span
+	Text string
+}
+
+// A Fix is a suggested correction for a parse error, such as "insert missing ','", made of
+// Edits which are meant to be applied together, as editors do with quick-fixes.
+type Fix struct {
+	Title string // What the Fix does, for showing to the user.
+	Edits []Edit
+}
+
+// ErrOverlappingEdits is returned by Fix.Apply when two of its Edits change the same text.
+var ErrOverlappingEdits = errors.New("overlapping edits")
+
+// Apply returns input with the Fix's Edits made.  Their Spans are offsets into input as it
+// was, so the Edits may be in any order, but they must not overlap.
+func (f Fix) Apply(input string) (string, error) {
+	edits := append([]Edit(nil), f.Edits...)
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Span.Start < edits[j].Span.Start })
+	var b strings.Builder
+	done := 0
+	for _, e := range edits {
+		if e.Span.Start < done || e.Span.End < e.Span.Start || e.Span.End > len(input) {
+			return "", ErrOverlappingEdits
+		}
+		b.WriteString(input[done:e.Span.Start])
+		b.WriteString(e.Text)
+		done = e.Span.End
+	}
+	b.WriteString(input[done:])
+	return b.String(), nil
+}
+
+// FixError is an error along with Fixes which would correct it, as returned by parsers made
+// with WithFix and recorded by ExactlyOrInsert.  It unwraps to Err, so OneOf still backtracks past
+// it, and the *ParseError from Parse has the same Fixes in its Fixes field.
+type FixError struct {
+	Fixes []Fix
+	Err   error
+}
+
+func (e *FixError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FixError) Unwrap() error {
+	return e.Err
+}
+
+// WithFix[T] returns a Parser[T] which behaves like the parser argument, except that when it
+// fails with ErrNoMatch or ErrUnconsumedInput, the error is wrapped in a *FixError with the
+// Fix which fix returns for the offset where the error was found.  Wrapped in Recover, this
+// makes a rule which both goes on past a mistake and says how to correct it:
+//
+//	closing := WithFix(Exactly("]"), func(at int) Fix { return Insertion("]", at) })
+//	Recover(AppendSkipping(items, closing), isLineEnd)
+func WithFix[T any](parser Parser[T], fix func(offset int) Fix) Parser[T] {
+	return func(initial State) (T, State, error) {
+		result, next, err := parser(initial)
+		if err == nil || !isNoMatch(err) {
+			return result, next, err
+		}
+		offset := initial.Offset()
+		var expected *ExpectedError
+		if errors.As(err, &expected) {
+			offset = expected.Offset
+		}
+		return result, initial, &FixError{Fixes: []Fix{fix(offset)}, Err: err}
+	}
+}
+
+// Insertion returns the Fix which inserts text at offset, titled like `insert missing ","`.
+func Insertion(text string, offset int) Fix {
+	return Fix{
+		Title: fmt.Sprintf("insert missing %q", text),
+		Edits: []Edit{{Span: Span{Start: offset, End: offset}, Text: text}},
+	}
+}
+
+// ExactlyOrInsert returns a Parser[Empty] which matches token, like Exactly, but which recovers
+// if token isn't there, as if it had been: it records an *ExpectedError for token, with the Fix
+// which inserts it, and succeeds without consuming anything.  So a grammar can go on past a
+// forgotten "," or ")" and Parse reports each as one of the Diagnostics, as Recover does.
+func ExactlyOrInsert(token string) Parser[Empty] {
+	exactly := Exactly(token)
+	expected := []string{fmt.Sprintf("%q", token)}
+	return func(initial State) (Empty, State, error) {
+		_, next, err := exactly(initial)
+		if err == nil || !isNoMatch(err) {
+			return Empty{}, next, err
+		}
+		if initial.run != nil {
+			offset := initial.Offset()
+			initial.run.recovered = append(initial.run.recovered, diagnostic{
+				offset: offset,
+				err: &FixError{
+					Fixes: []Fix{Insertion(token, offset)},
+					Err:   &ExpectedError{Offset: offset, Expected: expected},
+				},
+			})
+		}
+		return Empty{}, initial, nil
+	}
+}
+
+// fixesOf returns the Fixes of the *FixErrors in err's chain, outermost first.
+func fixesOf(err error) []Fix {
+	var fixes []Fix
+	for err != nil {
+		var fixErr *FixError
+		if !errors.As(err, &fixErr) {
+			break
+		}
+		fixes = append(fixes, fixErr.Fixes...)
+		err = fixErr.Err
+	}
+	return fixes
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column describes one field of a fixed-width layout for use with FixedWidth.
+type Column[V any] struct {
+	Name   string    // Names the field in the result and in errors.
+	Width  int       // The field's width in bytes.
+	Parser Parser[V] // Parses the field, and must consume all of it; see Padded and FieldText.
+}
+
+// FieldError is the error returned when a field of a FixedWidth record fails to parse.  It wraps
+// the field parser's error, so errors.Is(err, ErrNoMatch) still works on it.
+type FieldError struct {
+	Field  string // The Column's Name.
+	Offset int    // Byte offset of the start of the field.
+	Err    error  // The error from the field's parser.
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("field %q at offset %d: %v", e.Field, e.Offset, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FixedWidth[V] returns a Parser for a record laid out in fixed-width columns, as in mainframe
+// exports, bank statement files and many legacy logs.  Each column's parser is run on just its
+// Width bytes, as with Within, and the fields are returned in column order.  If a column's parser
+// fails or doesn't consume the whole field, FixedWidth fails with a *FieldError giving the
+// column and where it starts.  The record consumes exactly the sum of the widths; combine it
+// with LinesOf for a file of one record per line.
+func FixedWidth[V any](columns ...Column[V]) Parser[[]Field[V]] {
+	return func(initial State) ([]Field[V], State, error) {
+		fields := make([]Field[V], 0, len(columns))
+		current := initial
+		for _, column := range columns {
+			v, next, err := within(current, current.offset+column.Width, column.Parser)
+			if err != nil {
+				return nil, initial, &FieldError{Field: column.Name, Offset: current.Offset(), Err: err}
+			}
+			fields = append(fields, Field[V]{Name: column.Name, Value: v})
+			current = next
+		}
+		return fields, current, nil
+	}
+}
+
+// Padded[T] returns a Parser[T] which skips any spaces, runs the parser argument, and then skips
+// any spaces again: the usual shape of a fixed-width field, whose value is padded to fill it.
+func Padded[T any](parser Parser[T]) Parser[T] {
+	spaces := ConsumeWhile(func(r rune) bool { return r == ' ' })
+	s := StartSkipping(spaces)
+	s1 := AppendKeeping(s, parser)
+	s2 := AppendSkipping(s1, spaces)
+	return Apply(s2, func(t T) T { return t })
+}
+
+// FieldText is a Parser[string] which consumes the rest of a fixed-width field and returns it with
+// surrounding spaces trimmed; with Within or FixedWidth, the rest of the field is the whole field.
+func FieldText(initial State) (string, State, error) {
+	rest := initial.Remaining()
+	return strings.Trim(rest, " "), initial.Consume(len(rest)), nil
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"sync"
+)
+
+// FoldCase returns s with each rune replaced by a canonical representative of its Unicode
+// simple case folding, so that FoldCase(a) == FoldCase(b) exactly when strings.EqualFold(a, b).
+// It is meant for map keys and comparisons in case-insensitive languages, not for display.
+func FoldCase(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// FoldTable interns identifiers under Unicode simple case folding: the first spelling of an
+// identifier to be interned becomes its canonical spelling, and every later spelling that differs
+// only in case interns to the same string.  Grammars for case-insensitive languages can use it so
+// that "Foo", "FOO" and "foo" become one symbol, while error messages still show the spelling
+// the author first used.
+//
+// A FoldTable is safe for concurrent use.
+type FoldTable struct {
+	mu      sync.RWMutex
+	symbols map[string]string // From FoldCase of an identifier to its canonical spelling.
+}
+
+// NewFoldTable returns an empty FoldTable.
+func NewFoldTable() *FoldTable {
+	return &FoldTable{symbols: make(map[string]string)}
+}
+
+// Intern returns the canonical spelling of name, making name itself canonical if nothing
+// equal to it under case folding has been interned before.
+func (t *FoldTable) Intern(name string) string {
+	key := FoldCase(name)
+	t.mu.RLock()
+	canonical, ok := t.symbols[key]
+	t.mu.RUnlock()
+	if ok {
+		return canonical
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if canonical, ok := t.symbols[key]; ok {
+		return canonical
+	}
+	t.symbols[key] = name
+	return name
+}
+
+// Lookup returns the canonical spelling of name and true if something equal to it under
+// case folding has been interned, or "" and false otherwise.
+func (t *FoldTable) Lookup(name string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	canonical, ok := t.symbols[FoldCase(name)]
+	return canonical, ok
+}
+
+// Interned returns a Parser[string] which succeeds exactly when the parser argument succeeds,
+// returning the canonical spelling of its result from the table.
+func Interned(parser Parser[string], table *FoldTable) Parser[string] {
+	return Map(parser, table.Intern)
+}
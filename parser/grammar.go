@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrRuleConflict is returned when a Grammar inherits two different rules of the same name
+// from its bases, and doesn't override the name to say which it means.
+var ErrRuleConflict = errors.New("conflicting rules")
+
+// A Grammar is a named set of rules which can be shared between Go packages and specialized:
+// a base expression grammar, say, which several languages extend, each with terms of its own.
+// Rules are defined with Define, and refer to one another by name with Use, which looks the
+// name up when the rule runs, in whichever grammar the parse was started in with Start.  So a
+// Grammar which extends another inherits its rules, and can Override some of them, and the
+// inherited rules then use the overriding ones, as they would the methods of a subclass:
+//
+//	var Expr = NewGrammar("expr")
+//	Define(Expr, "sum", ChainLeft1(Use[int]("term"), plus))
+//	Define(Expr, "term", number)
+//
+//	var Calc = NewGrammar("calc", Expr)
+//	Override(Calc, "term", OneOf(number, Use[int]("variable"), Use[int]("expr.term")))
+//	Define(Calc, "variable", variable)
+//
+//	Parse(Start[int](Calc, "sum"), "1+x+2")
+//
+// A name qualified by the name of one of the grammars extended, such as "expr.term" above,
+// refers to that grammar's rule, and not any override, so an override can build on what it
+// replaces.  Rules should all be defined before parsing begins.  A Grammar is safe for
+// concurrent use.
+type Grammar struct {
+	name  string
+	bases []*Grammar
+	mu    sync.RWMutex
+	rules map[string]any // Values are Parser[T] for whichever T they were defined with.
+}
+
+// NewGrammar returns an empty Grammar called name, which extends the bases, if any, inheriting
+// their rules.  If two of the bases have different rules of the same name, the new Grammar must
+// override it before the rule is used; Check reports any it hasn't.
+func NewGrammar(name string, bases ...*Grammar) *Grammar {
+	return &Grammar{name: name, bases: bases, rules: make(map[string]any)}
+}
+
+// Name returns the name the Grammar was created with.
+func (g *Grammar) Name() string {
+	return g.name
+}
+
+// Define adds parser to the grammar g as the rule called name.  It panics if g already has a
+// rule of that name, or inherits one, which must be replaced with Override instead.
+func Define[T any](g *Grammar, name string, parser Parser[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.rules[name]; ok {
+		panic(fmt.Sprintf("parser: rule %q defined twice in grammar %q", name, g.name))
+	}
+	if _, _, err := g.inherited(name); !errors.Is(err, ErrUnknownRule) {
+		panic(fmt.Sprintf("parser: grammar %q defines inherited rule %q; use Override", g.name, name))
+	}
+	g.rules[name] = parser
+}
+
+// Override replaces the rule called name which the grammar g inherits from its bases with
+// parser, wherever it is used in a parse started in g.  It panics if g doesn't inherit such a
+// rule, or the rule produces a different type.  Overriding a name inherited from two bases
+// resolves the conflict between them.
+func Override[T any](g *Grammar, name string, parser Parser[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.rules[name]; ok {
+		panic(fmt.Sprintf("parser: rule %q defined twice in grammar %q", name, g.name))
+	}
+	rule, _, err := g.inherited(name)
+	if err != nil && !errors.Is(err, ErrRuleConflict) {
+		panic(fmt.Sprintf("parser: grammar %q overrides rule %q, which it doesn't inherit", g.name, name))
+	}
+	if _, ok := rule.(Parser[T]); err == nil && !ok {
+		panic(fmt.Sprintf("parser: grammar %q overrides rule %q, a %T, with a %T", g.name, name, rule, parser))
+	}
+	g.rules[name] = parser
+}
+
+// Check returns an error wrapping ErrRuleConflict which lists the names the grammar g inherits
+// conflicting rules for, or nil if there are none.  It is meant for a grammar's tests, or
+// for an init function to panic with, since a conflict otherwise only shows when it is used.
+func (g *Grammar) Check() error {
+	var names []string
+	seen := make(map[string]bool)
+	var visit func(*Grammar)
+	visit = func(base *Grammar) {
+		base.mu.RLock()
+		for name := range base.rules {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		base.mu.RUnlock()
+		for _, b := range base.bases {
+			visit(b)
+		}
+	}
+	visit(g)
+	sort.Strings(names)
+	var problems []string
+	for _, name := range names {
+		if _, _, err := g.resolve(name); errors.Is(err, ErrRuleConflict) {
+			problems = append(problems, strconv.Quote(name))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w in grammar %q: %s", ErrRuleConflict, g.name, strings.Join(problems, ", "))
+}
+
+// resolve returns the rule called name in g, and the grammar which defines it: g's own rule,
+// or else the one it inherits, or for a name qualified by the name of a grammar g extends,
+// that grammar's rule.
+func (g *Grammar) resolve(name string) (any, *Grammar, error) {
+	g.mu.RLock()
+	rule, ok := g.rules[name]
+	g.mu.RUnlock()
+	if ok {
+		return rule, g, nil
+	}
+	rule, definer, err := g.inherited(name)
+	if !errors.Is(err, ErrUnknownRule) {
+		return rule, definer, err
+	}
+	if base, rest := g.qualifier(name); base != nil {
+		return base.resolve(rest)
+	}
+	return nil, nil, err
+}
+
+// inherited returns the rule called name which g inherits from its bases, and the grammar
+// which defines it.  It is ErrRuleConflict for two bases to have different rules of the name.
+// g.mu need not be held, since only the bases' rules are read.
+func (g *Grammar) inherited(name string) (any, *Grammar, error) {
+	var rule any
+	var definer *Grammar
+	for _, base := range g.bases {
+		r, d, err := base.resolve(name)
+		switch {
+		case errors.Is(err, ErrUnknownRule):
+			continue
+		case err != nil:
+			return nil, nil, err
+		case definer != nil && d != definer:
+			return nil, nil, fmt.Errorf("%w %q from grammars %q and %q", ErrRuleConflict, name, definer.name, d.name)
+		}
+		rule, definer = r, d
+	}
+	if definer == nil {
+		return nil, nil, fmt.Errorf("%w %q in grammar %q", ErrUnknownRule, name, g.name)
+	}
+	return rule, definer, nil
+}
+
+// qualifier returns the grammar g extends, directly or not, whose name qualifies name, as
+// "expr" qualifies "expr.term", and the rest of the name; or nil if there is none.
+func (g *Grammar) qualifier(name string) (*Grammar, string) {
+	for _, base := range g.bases {
+		if prefix := base.name + "."; strings.HasPrefix(name, prefix) {
+			return base, name[len(prefix):]
+		}
+		if b, rest := base.qualifier(name); b != nil {
+			return b, rest
+		}
+	}
+	return nil, ""
+}
+
+// Start[T] returns a Parser[T] which runs the rule called name in the grammar g, with g as the
+// grammar in which the names given to Use are looked up, until the rule returns.
+func Start[T any](g *Grammar, name string) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		if initial.run == nil {
+			return zero, initial, fmt.Errorf("%w %q: no parse in progress", ErrUnknownRule, name)
+		}
+		outer := initial.run.grammar
+		initial.run.grammar = g
+		defer func() { initial.run.grammar = outer }()
+		return Use[T](name)(initial)
+	}
+}
+
+// Use[T] returns a Parser[T] which runs the rule called name in the grammar the parse was
+// started in with Start.  The name is looked up each time the parser runs, so that the rule
+// may be defined after Use is called, and overridden by grammars which extend the one it is
+// used in.  The parser fails with an error wrapping ErrUnknownRule if there is no such rule,
+// or the parse wasn't started in a grammar, ErrRuleConflict if the rule is ambiguous, or
+// ErrRuleType if the rule isn't a Parser[T].
+func Use[T any](name string) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		if initial.run == nil || initial.run.grammar == nil {
+			return zero, initial, fmt.Errorf("%w %q: not in a grammar", ErrUnknownRule, name)
+		}
+		rule, _, err := initial.run.grammar.resolve(name)
+		if err != nil {
+			return zero, initial, err
+		}
+		parser, ok := rule.(Parser[T])
+		if !ok {
+			return zero, initial, fmt.Errorf("%w: %q is a %T", ErrRuleType, name, rule)
+		}
+		return named(name, parser, initial)
+	}
+}
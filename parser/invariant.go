@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvariant is wrapped by the InvariantError returned when a parser breaks the rules every
+// parser must keep for backtracking to work.
+var ErrInvariant = errors.New("parser invariant broken")
+
+// InvariantError is the error returned by Checked, and by the named parsers of a parse run with
+// WithInvariantChecks, when the parser they run breaks one of the rules of the State contract:
+// a parser which fails returns the State it was given; one which succeeds returns a State of
+// the same parse, no earlier in the input; and neither discards the diagnostics or syntax nodes
+// recorded before it ran, nor leaves syntax nodes of its own open.
+type InvariantError struct {
+	Name    string // The name of the parser which broke the rule.
+	Offset  int    // The offset at which it was run.
+	Problem string // Which rule it broke, and how.
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("%v by %s at offset %d: %s", ErrInvariant, e.Name, e.Offset, e.Problem)
+}
+
+func (e *InvariantError) Unwrap() error {
+	return ErrInvariant
+}
+
+// WithInvariantChecks returns an Option which makes every parser made with Label, InContext,
+// Rule or Use check that the parser it runs keeps to the State contract, as Checked does, and
+// fail the parse with an InvariantError if not.  It is meant for the tests of grammars with
+// hand-written parsers, since the checks slow parsing down.
+func WithInvariantChecks() Option {
+	return func(c *config) {
+		c.checks = true
+	}
+}
+
+// Checked[T] returns a Parser[T] which runs the parser argument, and fails with an
+// InvariantError naming it name if it breaks the State contract; see InvariantError for the
+// rules.  Wrapping a hand-written parser in Checked in its tests finds the mistakes which
+// otherwise show only as wrong results after backtracking, such as returning a half-consumed
+// State along with an error.
+func Checked[T any](name string, parser Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		before := bookkeepingOf(initial)
+		t, next, err := parser(initial)
+		if problem := contractProblem(initial, next, err, before); problem != "" {
+			var zero T
+			return zero, initial, &InvariantError{Name: name, Offset: initial.Offset(), Problem: problem}
+		}
+		return t, next, err
+	}
+}
+
+// checking reports whether the parse was run WithInvariantChecks.
+func (s State) checking() bool {
+	return s.run != nil && s.run.checks
+}
+
+// bookkeeping is what a parse has recorded in its parseRun, as far as Checked checks it.
+type bookkeeping struct {
+	recovered    int // How many diagnostics have been recorded,
+	lastOffset   int // and the offset of the last of them.
+	open         int // How many syntax nodes are open,
+	lastChildren int // and how many children the innermost of them has.
+}
+
+func bookkeepingOf(s State) bookkeeping {
+	var b bookkeeping
+	if s.run == nil {
+		return b
+	}
+	b.recovered = len(s.run.recovered)
+	if b.recovered > 0 {
+		b.lastOffset = s.run.recovered[b.recovered-1].offset
+	}
+	if s.run.tree != nil {
+		b.open = len(s.run.tree.open)
+		b.lastChildren = s.run.tree.mark()
+	}
+	return b
+}
+
+// contractProblem returns how a parser run from initial, which returned next and err, broke
+// the State contract, given the bookkeeping from before it ran, or "" if it didn't.
+func contractProblem(initial, next State, err error, before bookkeeping) string {
+	switch {
+	case next.run != initial.run:
+		return "returned a State from another parse"
+	case err != nil && (next.data != initial.data || next.offset != initial.offset || next.base != initial.base):
+		return fmt.Sprintf("failed with %q but returned a State at offset %d instead of the one it was given", err, next.Offset())
+	case err == nil && next.data == initial.data && next.offset < initial.offset:
+		return fmt.Sprintf("moved back to offset %d", next.Offset())
+	case next.offset > len(next.data):
+		return fmt.Sprintf("returned offset %d, past the end of the input", next.Offset())
+	}
+	after := bookkeepingOf(next)
+	switch {
+	case after.recovered < before.recovered ||
+		before.recovered > 0 && next.run.recovered[before.recovered-1].offset != before.lastOffset:
+		return "discarded diagnostics recorded before it ran"
+	case after.open != before.open:
+		return fmt.Sprintf("left %d syntax nodes open instead of %d", after.open, before.open)
+	case after.lastChildren < before.lastChildren:
+		return "discarded syntax nodes recorded before it ran"
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Message returns what went wrong, without the position or the InContext names, which are in
+// the ParseError's other fields: "expected number", "unconsumed input" and so on.
+func (e *ParseError) Message() string {
+	var expected *ExpectedError
+	var contextErr *ContextError
+	switch {
+	case errors.As(e.Err, &expected):
+		text := expected.expected()
+		if len(expected.Suggestions) > 0 {
+			text += "; " + expected.DidYouMean()
+		}
+		return text
+	case errors.As(e.Err, &contextErr):
+		return contextErr.Err.Error()
+	}
+	return e.Err.Error()
+}
+
+// jsonError is the JSON form of a ParseError.
+type jsonError struct {
+	Message     string    `json:"message"`
+	Severity    string    `json:"severity"`
+	Span        jsonSpan  `json:"span"`
+	Line        int       `json:"line"`
+	Column      int       `json:"column"`
+	Expected    []string  `json:"expected,omitempty"`
+	Suggestions []string  `json:"suggestions,omitempty"`
+	Context     []string  `json:"context,omitempty"`
+	Fixes       []jsonFix `json:"fixes,omitempty"`
+}
+
+// jsonFix is the JSON form of a Fix.
+type jsonFix struct {
+	Title string     `json:"title"`
+	Edits []jsonEdit `json:"edits"`
+}
+
+// jsonEdit is the JSON form of an Edit.
+type jsonEdit struct {
+	Span jsonSpan `json:"span"`
+	Text string   `json:"text"`
+}
+
+// jsonSpan is the JSON form of where a ParseError is, as byte offsets.  A ParseError is at a
+// single point, so Start and End are the same, but the form leaves room for errors which cover
+// a stretch of the input.
+type jsonSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// MarshalJSON implements json.Marshaler, so that services and editors can pass parse errors on
+// as they are.  The form is an object like this, where the last four members are left out
+// when empty, and severity is always "error":
+//
+//	{
+//	  "message": "expected one of: \"select\", \"limit\"; did you mean \"limit\"?",
+//	  "severity": "error",
+//	  "span": {"start": 7, "end": 7},
+//	  "line": 1,
+//	  "column": 8,
+//	  "expected": ["\"select\"", "\"limit\""],
+//	  "suggestions": ["limit"],
+//	  "context": ["query", "command"],
+//	  "fixes": [{"title": "insert missing \",\"", "edits": [{"span": {"start": 7, "end": 7}, "text": ","}]}]
+//	}
+func (e *ParseError) MarshalJSON() ([]byte, error) {
+	j := jsonError{
+		Message:  e.Message(),
+		Severity: "error",
+		Span:     jsonSpan{Start: e.Offset, End: e.Offset},
+		Line:     e.Line,
+		Column:   e.Column,
+		Context:  e.Context,
+	}
+	var expected *ExpectedError
+	if errors.As(e.Err, &expected) {
+		j.Expected, j.Suggestions = expected.Expected, expected.Suggestions
+	}
+	for _, f := range e.Fixes {
+		jf := jsonFix{Title: f.Title, Edits: make([]jsonEdit, len(f.Edits))}
+		for i, edit := range f.Edits {
+			jf.Edits[i] = jsonEdit{Span: jsonSpan{Start: edit.Span.Start, End: edit.Span.End}, Text: edit.Text}
+		}
+		j.Fixes = append(j.Fixes, jf)
+	}
+	return json.Marshal(j)
+}
+
+// MarshalJSON implements json.Marshaler, giving an array of the errors in the form used by
+// ParseError, which is empty rather than null if there are none.
+func (d Diagnostics) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]*ParseError(d))
+}
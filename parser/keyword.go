@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isWordRune reports whether r can be part of a word, for Keyword.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Keyword returns a Parser[string] which matches any one of the words, which are made of
+// letters, digits and "_", as a whole word, not followed by another such rune, and returns it.
+// When none of them matches, it fails with an *ExpectedError listing the words, quoted.  If
+// there is some other word at that point, the error also suggests the words nearest to it in
+// spelling, ignoring case, so that a user who typed "limt" can be asked `did you mean "limit"?`.
+//
+// When the parse uses WithNormalization, the words are compared with the input as Exactly
+// compares its token, and the word returned is the one given, however the input spelled it.
+func Keyword(words ...string) Parser[string] {
+	expected := make([]string, len(words))
+	for i, w := range words {
+		expected[i] = fmt.Sprintf("%q", w)
+	}
+	return func(initial State) (string, State, error) {
+		if initial.overBudget() {
+			return "", initial, ErrBudgetExceeded
+		}
+		rest := initial.Remaining()
+		end := 0
+		for end < len(rest) {
+			r, w := utf8.DecodeRuneInString(rest[end:])
+			if !isWordRune(r) {
+				break
+			}
+			end += w
+		}
+		found := rest[:end]
+		if normalize := initial.normalizer(); normalize != nil {
+			for _, w := range words {
+				if n, ok := hasPrefixNormalized(rest, w, normalize); ok && !startsWithWordRune(rest[n:]) {
+					return w, initial.Consume(n), nil
+				}
+			}
+		} else {
+			for _, w := range words {
+				if w == found {
+					return w, initial.Consume(len(w)), nil
+				}
+			}
+		}
+		return "", initial, initial.expect(&ExpectedError{
+			Offset:      initial.Offset(),
+			Expected:    expected,
+			Suggestions: suggest(found, words),
+		})
+	}
+}
+
+// startsWithWordRune reports whether s begins with a rune which can be part of a word.
+func startsWithWordRune(s string) bool {
+	r, w := utf8.DecodeRuneInString(s)
+	return w > 0 && isWordRune(r)
+}
+
+// maxSuggestions is the most words Keyword suggests.
+const maxSuggestions = 3
+
+// suggest returns the candidates which are near enough to found to be what was meant, nearest
+// first: those within an edit distance of a third of found's length, and at least 1.
+func suggest(found string, candidates []string) []string {
+	if found == "" {
+		return nil
+	}
+	limit := utf8.RuneCountInString(found) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	type near struct {
+		word     string
+		distance int
+	}
+	var nearest []near
+	lower := strings.ToLower(found)
+	for _, c := range candidates {
+		if d := levenshtein(lower, strings.ToLower(c)); d <= limit {
+			nearest = append(nearest, near{c, d})
+		}
+	}
+	sort.SliceStable(nearest, func(i, j int) bool { return nearest[i].distance < nearest[j].distance })
+	if len(nearest) > maxSuggestions {
+		nearest = nearest[:maxSuggestions]
+	}
+	var suggestions []string
+	for _, n := range nearest {
+		suggestions = append(suggestions, n.word)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b: how many runes must be inserted,
+// deleted or replaced to turn one into the other.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ExpectedError is the error returned by a parser made with Label, Keyword or Exactly which
+// doesn't match, naming what was expected where: labels as they were given, and tokens quoted.
+// It wraps ErrNoMatch, so OneOf still goes on to the next alternative, and when every
+// alternative fails this way OneOf merges their labels into one ExpectedError.
+//
+// The errors the labelled parsers failed with which say more than ErrNoMatch, such as those
+// from FailWith, are kept as its Causes, and errors.Is and errors.As look through them, so a
+// grammar's own errors reach the caller of Parse however many alternatives were tried.
+type ExpectedError struct {
+	Offset      int      // Byte offset at which the labelled parsers were tried.
+	Expected    []string // Their labels and quoted tokens, in the order they were tried, without repeats.
+	Suggestions []string // Words close to what was found instead, nearest first; see Keyword.
+	Causes      []error  // The more specific errors they failed with, in the order they did.
+}
+
+func (e *ExpectedError) Error() string {
+	text := fmt.Sprintf("%s at offset %d", e.expected(), e.Offset)
+	if len(e.Suggestions) > 0 {
+		text += "; " + e.DidYouMean()
+	}
+	if len(e.Causes) > 0 {
+		causes := make([]string, len(e.Causes))
+		for i, cause := range e.Causes {
+			causes[i] = cause.Error()
+		}
+		text += " (" + strings.Join(causes, "; ") + ")"
+	}
+	return text
+}
+
+// expected returns what was expected, as "expected number" or "expected one of: number, string".
+func (e *ExpectedError) expected() string {
+	if len(e.Expected) == 1 {
+		return "expected " + e.Expected[0]
+	}
+	return "expected one of: " + strings.Join(e.Expected, ", ")
+}
+
+// DidYouMean returns the Suggestions as a question, such as `did you mean "limit"?`, or "" if
+// there are none.
+func (e *ExpectedError) DidYouMean() string {
+	switch len(e.Suggestions) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("did you mean %q?", e.Suggestions[0])
+	}
+	quoted := make([]string, len(e.Suggestions))
+	for i, s := range e.Suggestions {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return "did you mean one of " + strings.Join(quoted, ", ") + "?"
+}
+
+func (e *ExpectedError) Unwrap() error {
+	return ErrNoMatch
+}
+
+// Is reports whether any of the Causes is target, for errors.Is.
+func (e *ExpectedError) Is(target error) bool {
+	for _, cause := range e.Causes {
+		if errors.Is(cause, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the Causes which matches target, for errors.As.
+func (e *ExpectedError) As(target any) bool {
+	for _, cause := range e.Causes {
+		if errors.As(cause, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// causesOf returns what is worth keeping of the no-match error err when it is to be replaced or
+// merged: the Causes of an ExpectedError, or err itself if it is more than a bare ErrNoMatch or
+// ErrUnconsumedInput.
+func causesOf(err error) []error {
+	var expected *ExpectedError
+	switch {
+	case errors.As(err, &expected):
+		return expected.Causes
+	case err == ErrNoMatch || err == ErrUnconsumedInput:
+		return nil
+	}
+	return []error{err}
+}
+
+// merge returns the ExpectedError combining e and other: the one which got further into the
+// input, or if they are at the same offset, one with the labels and suggestions of both.  Either
+// may be nil.
+func (e *ExpectedError) merge(other *ExpectedError) *ExpectedError {
+	switch {
+	case e == nil || other != nil && other.Offset > e.Offset:
+		return other
+	case other == nil || other.Offset < e.Offset:
+		return e
+	}
+	return &ExpectedError{
+		Offset:      e.Offset,
+		Expected:    union(e.Expected, other.Expected),
+		Suggestions: union(e.Suggestions, other.Suggestions),
+		Causes:      append(append([]error(nil), e.Causes...), other.Causes...),
+	}
+}
+
+// union returns the strings in a followed by those in b which aren't in a.
+func union(a, b []string) []string {
+	merged := append([]string(nil), a...)
+outer:
+	for _, s := range b {
+		for _, have := range merged {
+			if have == s {
+				continue outer
+			}
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// Label[T] returns a Parser[T] which behaves like the parser argument, except that when it
+// doesn't match it fails with an *ExpectedError naming what it parses, so that an error can say
+// "expected number at offset 12" rather than just "no match", keeping any more specific error
+// it failed with among the Causes.  Errors other than ErrNoMatch and ErrUnconsumedInput are
+// returned as they are.
+//
+// If the parser failed further into the input than where it began, failing there with an
+// *ExpectedError of its own, that error is kept: it says more precisely what went wrong.
+func Label[T any](parser Parser[T], name string) Parser[T] {
+	return func(initial State) (T, State, error) {
+		before := initial.expectation()
+		result, next, err := named(name, parser, initial)
+		if err == nil || !isNoMatch(err) {
+			return result, next, err
+		}
+		var inner *ExpectedError
+		if errors.As(err, &inner) && inner.Offset > initial.Offset() {
+			return result, initial, err
+		}
+		initial.unexpect(before)
+		var zero T
+		return zero, initial, initial.expect(&ExpectedError{Offset: initial.Offset(), Expected: []string{name}, Causes: causesOf(err)})
+	}
+}
+
+// expect records e, the error of a parser which didn't match at s, and returns it.  Parse
+// keeps the merged ExpectedErrors furthest into the input, so that when the parse fails there,
+// after backtracking out of alternatives, its error can say everything that was expected.
+func (s State) expect(e *ExpectedError) *ExpectedError {
+	if s.run != nil {
+		s.run.expected = s.run.expected.merge(e)
+	}
+	return e
+}
+
+// expectation returns what has been recorded by expect so far, for unexpect.
+func (s State) expectation() *ExpectedError {
+	if s.run == nil {
+		return nil
+	}
+	return s.run.expected
+}
+
+// forgetExpected puts back what expect had recorded when expectation returned expected,
+// forgetting the expectations of a parser which was only run to look ahead, or whose failure
+// was recovered from.
+func (s State) forgetExpected(expected *ExpectedError) {
+	if s.run != nil {
+		s.run.expected = expected
+	}
+}
+
+// unexpect forgets what has been recorded by expect since it was before, unless some of it is
+// further into the input than s, so that a Label names what it was expecting at s, not what
+// the parser it labels was.
+func (s State) unexpect(before *ExpectedError) {
+	if s.run != nil && s.run.expected != nil && s.run.expected.Offset <= s.Offset() {
+		s.run.expected = before
+	}
+}
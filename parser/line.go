@@ -0,0 +1,58 @@
+package parser
+
+// Lines are terminated by "\n" or "\r\n", or by the end of the input.  A terminator is never
+// included in the text returned by these parsers.
+
+// RestOfLine is a Parser[string] which returns the text from the current position up to the end
+// of the line, without consuming the line terminator.  It always succeeds, possibly returning "".
+func RestOfLine(initial State) (string, State, error) {
+	end, _ := initial.lineEnd()
+	return initial.data[initial.offset:end], initial.Consume(end - initial.offset), nil
+}
+
+// Line is a Parser[string] which returns the text from the current position up to the end of the
+// line, and consumes the line terminator as well.  It fails if no input remains, so a trailing
+// newline doesn't count as the start of one more empty line.
+func Line(initial State) (string, State, error) {
+	if initial.offset >= len(initial.data) {
+		return "", initial, ErrNoMatch
+	}
+	end, next := initial.lineEnd()
+	return initial.data[initial.offset:end], initial.Consume(next - initial.offset), nil
+}
+
+// NonEmptyLine is like Line, but fails on a line with no text before its terminator.
+func NonEmptyLine(initial State) (string, State, error) {
+	line, next, err := Line(initial)
+	if err != nil || line == "" {
+		return "", initial, ErrNoMatch
+	}
+	return line, next, nil
+}
+
+// LinesOf[T] returns a Parser[[]T] which parses every remaining line of the input with the
+// parser argument, one T per line.  The parser argument only sees the text of its own line,
+// and must consume all of it.  If it fails on any line, LinesOf fails with its error, which
+// Parse reports at the line and column where it was found.
+func LinesOf[T any](parser Parser[T]) Parser[[]T] {
+	return func(initial State) ([]T, State, error) {
+		var results []T
+		current := initial
+		for current.offset < len(current.data) {
+			if current.overBudget() {
+				return nil, initial, ErrBudgetExceeded
+			}
+			end, next := current.lineEnd()
+			t, after, err := parser(current.truncate(end))
+			if err == nil && after.offset < end {
+				err = ErrUnconsumedInput
+			}
+			if err != nil {
+				return nil, initial, err
+			}
+			results = append(results, t)
+			current = current.Consume(next - current.offset)
+		}
+		return results, current, nil
+	}
+}
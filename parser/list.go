@@ -0,0 +1,127 @@
+package parser
+
+// SepBy[T, S] returns a Parser[[]T] for zero or more items separated by separator, as in the
+// comma-separated lists found in nearly every grammar, returning the items in input order, or
+// nil if there are none.  A separator not followed by an item is left unconsumed, so SepBy
+// stops before a trailing comma; use EndBy to allow one.
+//
+// The list ends when there is no further separator and item, that is when either fails with
+// ErrNoMatch or ErrUnconsumedInput; any other error fails the whole list.  It also ends if a
+// separator and item succeed without consuming anything, which would otherwise repeat forever.
+func SepBy[T, S any](item Parser[T], separator Parser[S]) Parser[[]T] {
+	return sepBy(item, separator, false, false)
+}
+
+// SepBy1[T, S] is like SepBy, but there must be at least one item.
+func SepBy1[T, S any](item Parser[T], separator Parser[S]) Parser[[]T] {
+	return sepBy(item, separator, true, false)
+}
+
+// EndBy[T, S] is like SepBy, but it also consumes a separator after the last item, if there is
+// one, as in lists which allow a trailing comma, or statements which each end with ";".
+func EndBy[T, S any](item Parser[T], separator Parser[S]) Parser[[]T] {
+	return sepBy(item, separator, false, true)
+}
+
+// sepBy implements SepBy, SepBy1 and EndBy: one says whether an item is required, and trailing
+// whether a separator after the last item is consumed.
+func sepBy[T, S any](item Parser[T], separator Parser[S], one bool, trailing bool) Parser[[]T] {
+	return func(initial State) ([]T, State, error) {
+		start := initial.Save()
+		t, current, err := item(initial)
+		if err != nil {
+			if !one && isNoMatch(err) {
+				return nil, initial.Restore(start), nil
+			}
+			return nil, initial, err
+		}
+		arena := initial.arena()
+		items := appendTo(arena, []T(nil), t)
+		for {
+			current.tick()
+			if current.overBudget() {
+				return nil, initial, ErrBudgetExceeded
+			}
+			checkpoint := current.Save()
+			_, afterSeparator, err := separator(current)
+			if err != nil {
+				if !isNoMatch(err) {
+					return nil, initial, err
+				}
+				current = current.Restore(checkpoint)
+				break
+			}
+			separated := afterSeparator.Save()
+			t, next, err := item(afterSeparator)
+			if err != nil {
+				if !isNoMatch(err) {
+					return nil, initial, err
+				}
+				if trailing {
+					current = afterSeparator.Restore(separated)
+				} else {
+					current = current.Restore(checkpoint)
+				}
+				break
+			}
+			if next.offset == current.offset {
+				current = current.Restore(checkpoint)
+				break
+			}
+			items = appendTo(arena, items, t)
+			current = next
+		}
+		return items, current, nil
+	}
+}
+
+// Count[T] returns a Parser[[]T] which parses exactly n items with the parser argument, one after
+// another, as for fixed-width fields such as the four hexadecimal digits of `\u00e9`.
+func Count[T any](n int, parser Parser[T]) Parser[[]T] {
+	return Repeat(n, n, parser)
+}
+
+// Sequence[T] returns a Parser[[]T] which runs each of the parsers in turn, one after another,
+// and returns their values in order, or fails if any of them does.  It is for sequences whose
+// length is only known at run time, such as a row of as many columns as the header row had;
+// a sequence of a known length and its own types is better written with AppendKeeping.
+func Sequence[T any](parsers ...Parser[T]) Parser[[]T] {
+	return func(initial State) ([]T, State, error) {
+		items := allocate[T](initial.arena(), len(parsers))
+		current := initial
+		for _, parser := range parsers {
+			current.tick()
+			if current.overBudget() {
+				return nil, initial, ErrBudgetExceeded
+			}
+			item, next, err := parser(current)
+			if err != nil {
+				return nil, initial, err
+			}
+			items = append(items, item)
+			current = next
+		}
+		return items, current, nil
+	}
+}
+
+// Repeat[T] returns a Parser[[]T] which parses from min to max items with the parser argument,
+// one after another, and returns them in input order, or nil if there are none.  It takes as
+// many as it can, up to max: a failure with ErrNoMatch or ErrUnconsumedInput after min items
+// ends the repetition, while one before then fails it.  Any other error fails it straight away.
+func Repeat[T any](min, max int, parser Parser[T]) Parser[[]T] {
+	return func(initial State) ([]T, State, error) {
+		arena := initial.arena()
+		return Loop([]T(nil), func(items []T) Parser[Step[[]T, []T]] {
+			done := Succeed(Step[[]T, []T]{Done: true, Value: items})
+			if len(items) >= max {
+				return done
+			}
+			more := Map(parser, func(t T) Step[[]T, []T] { return Step[[]T, []T]{Accum: appendTo(arena, items, t)} })
+			if len(items) < min {
+				return more
+			}
+			return OneOf(more, done)
+		})(initial)
+	}
+}
@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExactlyAnyFold returns a Parser[string] which compares the beginning of the remaining input
+// against each of the literals under Unicode simple case folding, so "get", "GET" and "Get" all
+// match the literal "GET".  If more than one literal matches, the one consuming the most input wins.
+// On success the parser consumes the matched input and returns the literal exactly as it was passed
+// in, giving callers a canonical spelling regardless of how the input was cased.
+func ExactlyAnyFold(literals ...string) Parser[string] {
+	return ExactlyAnyTable(CompileLiterals(true, literals...))
+}
+
+// ExactlyAnyTable returns a Parser[string] which matches the longest of the literals in the
+// table at the beginning of the remaining input, consumes it, and returns the literal as it was
+// passed to CompileLiterals.  It fails if none of the literals match.
+func ExactlyAnyTable(table *LiteralTable) Parser[string] {
+	return func(initial State) (string, State, error) {
+		if initial.overBudget() {
+			return "", initial, ErrBudgetExceeded
+		}
+		index, n := table.match(initial.Remaining())
+		if index < 0 {
+			return "", initial, ErrNoMatch
+		}
+		return table.literals[index], initial.Consume(n), nil
+	}
+}
+
+// LiteralTable is a set of literals compiled into a trie for matching with ExactlyAnyTable,
+// optionally under Unicode simple case folding.  Matching takes time proportional to the length
+// matched, however many literals there are.
+//
+// A LiteralTable implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, so the
+// table for a large generated keyword set can be compiled once, written out, and loaded at
+// startup, for example from a file embedded with go:embed:
+//
+//	//go:embed keywords.bin
+//	var keywordData []byte
+//
+//	var keywords LiteralTable
+//	func init() {
+//		if err := keywords.UnmarshalBinary(keywordData); err != nil {
+//			panic(err)
+//		}
+//	}
+//
+// LiteralTable is the only compiled form in the package which can be saved this way.  Parsers
+// themselves are Go functions, which can't be, and they are run as they are written, so there
+// are no dispatch tables or FIRST sets computed from a grammar to persist.  A generated grammar
+// whose start-up cost is in its keyword and operator sets can load those as LiteralTables, and
+// build the rest of the grammar from them in code.
+//
+// A LiteralTable is safe for concurrent use once compiled or unmarshaled.
+type LiteralTable struct {
+	fold     bool
+	literals []string
+	nodes    []trieNode // nodes[0] is the root.
+}
+
+// trieNode is a node in a LiteralTable's trie.
+type trieNode struct {
+	literal int        // Index of the literal ending here, or -1.
+	edges   []trieEdge // Sorted by rune.
+}
+
+// trieEdge leads from one trieNode to the next on a rune, which is case-folded in a folding table.
+type trieEdge struct {
+	r     rune
+	child int
+}
+
+// CompileLiterals returns a LiteralTable matching the literals, comparing under Unicode
+// simple case folding if fold is set.  When two literals are equal (or equal under folding),
+// the first one is kept.
+func CompileLiterals(fold bool, literals ...string) *LiteralTable {
+	t := &LiteralTable{fold: fold, nodes: []trieNode{{literal: -1}}}
+	for _, literal := range literals {
+		node := 0
+		for _, r := range literal {
+			node = t.child(node, t.key(r))
+		}
+		if t.nodes[node].literal < 0 {
+			t.nodes[node].literal = len(t.literals)
+			t.literals = append(t.literals, literal)
+		}
+	}
+	return t
+}
+
+// child returns the child of node along r, adding it if need be.
+func (t *LiteralTable) child(node int, r rune) int {
+	edges := t.nodes[node].edges
+	i := 0
+	for i < len(edges) && edges[i].r < r {
+		i++
+	}
+	if i < len(edges) && edges[i].r == r {
+		return edges[i].child
+	}
+	t.nodes = append(t.nodes, trieNode{literal: -1})
+	child := len(t.nodes) - 1
+	edges = append(edges, trieEdge{})
+	copy(edges[i+1:], edges[i:])
+	edges[i] = trieEdge{r: r, child: child}
+	t.nodes[node].edges = edges
+	return child
+}
+
+// key returns the rune used to label trie edges for r.
+func (t *LiteralTable) key(r rune) rune {
+	if t.fold {
+		return foldRune(r)
+	}
+	return r
+}
+
+// match returns the index of the longest literal at the start of s, and its length in s,
+// or -1 if there is none.  The length can differ from the literal's, since folded runes
+// needn't have the same UTF-8 width (e.g. 'k' and the Kelvin sign).
+func (t *LiteralTable) match(s string) (int, int) {
+	best, bestLen := t.nodes[0].literal, 0
+	node, n := 0, 0
+	for n < len(s) {
+		r, w := utf8.DecodeRuneInString(s[n:])
+		next := t.next(node, t.key(r))
+		if next < 0 {
+			break
+		}
+		node, n = next, n+w
+		if t.nodes[node].literal >= 0 {
+			best, bestLen = t.nodes[node].literal, n
+		}
+	}
+	return best, bestLen
+}
+
+// next returns the child of node along r, or -1.
+func (t *LiteralTable) next(node int, r rune) int {
+	edges := t.nodes[node].edges
+	lo, hi := 0, len(edges)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if edges[mid].r < r {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(edges) && edges[lo].r == r {
+		return edges[lo].child
+	}
+	return -1
+}
+
+// foldRune returns the smallest rune equivalent to r under simple case folding, which serves
+// as a canonical representative of r's case-folding orbit.
+func foldRune(r rune) rune {
+	smallest := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < smallest {
+			smallest = f
+		}
+	}
+	return smallest
+}
+
+// ErrBadLiteralTable is returned by LiteralTable.UnmarshalBinary for data that wasn't produced
+// by MarshalBinary.
+var ErrBadLiteralTable = errors.New("malformed literal table")
+
+// literalTableMagic starts every marshaled LiteralTable; its last byte is the format version.
+const literalTableMagic = "PCLT\x01"
+
+// MarshalBinary encodes the table in a compact, versioned binary form.
+func (t *LiteralTable) MarshalBinary() ([]byte, error) {
+	b := []byte(literalTableMagic)
+	if t.fold {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = binary.AppendUvarint(b, uint64(len(t.literals)))
+	for _, literal := range t.literals {
+		b = binary.AppendUvarint(b, uint64(len(literal)))
+		b = append(b, literal...)
+	}
+	b = binary.AppendUvarint(b, uint64(len(t.nodes)))
+	for _, node := range t.nodes {
+		b = binary.AppendUvarint(b, uint64(node.literal+1))
+		b = binary.AppendUvarint(b, uint64(len(node.edges)))
+		for _, edge := range node.edges {
+			b = binary.AppendUvarint(b, uint64(edge.r))
+			b = binary.AppendUvarint(b, uint64(edge.child))
+		}
+	}
+	return b, nil
+}
+
+// UnmarshalBinary replaces the table with one decoded from data produced by MarshalBinary.
+// It returns ErrBadLiteralTable if the data is malformed, leaving the table unchanged.
+func (t *LiteralTable) UnmarshalBinary(data []byte) error {
+	if len(data) < len(literalTableMagic)+1 || string(data[:len(literalTableMagic)]) != literalTableMagic {
+		return ErrBadLiteralTable
+	}
+	fold := data[len(literalTableMagic)]
+	if fold > 1 {
+		return ErrBadLiteralTable
+	}
+	d := data[len(literalTableMagic)+1:]
+	ok := true
+	uvarint := func(limit int) int {
+		v, n := binary.Uvarint(d)
+		if n <= 0 || v > uint64(limit) {
+			ok = false
+			return 0
+		}
+		d = d[n:]
+		return int(v)
+	}
+	literals := make([]string, uvarint(len(d)))
+	for i := range literals {
+		n := uvarint(len(d))
+		if !ok || n > len(d) {
+			return ErrBadLiteralTable
+		}
+		literals[i] = string(d[:n])
+		d = d[n:]
+	}
+	nodes := make([]trieNode, uvarint(len(d)))
+	for i := range nodes {
+		// A node's literal is its index in literals, plus one so that 0 means none, and its
+		// children come after it, so that the trie has no cycles.
+		literal := uvarint(math.MaxInt32) - 1
+		if !ok || literal >= len(literals) {
+			return ErrBadLiteralTable
+		}
+		edges := make([]trieEdge, uvarint(len(d)))
+		for j := range edges {
+			r := rune(uvarint(unicode.MaxRune))
+			child := uvarint(math.MaxInt32)
+			if !ok || j > 0 && r <= edges[j-1].r || child <= i || child >= len(nodes) {
+				return ErrBadLiteralTable
+			}
+			edges[j] = trieEdge{r: r, child: child}
+		}
+		nodes[i] = trieNode{literal: literal, edges: edges}
+	}
+	if !ok || len(nodes) == 0 || len(d) != 0 {
+		return ErrBadLiteralTable
+	}
+	*t = LiteralTable{fold: fold == 1, literals: literals, nodes: nodes}
+	return nil
+}
@@ -16,10 +16,15 @@ type Step[A any, T any] struct {
 // is not set, Loop will iterate with the new Accum value from the Step.  If the Step's Done flag
 // is set, Loop will complete by returning the T value from the Step.
 func Loop[A any, T any](startAccum A, stepper func(A) Parser[Step[A, T]]) Parser[T] {
-	return func(initial state) (T, state, error) {
+	return func(initial State) (T, State, error) {
 		accum := startAccum
 		currentState := initial
 		for {
+			currentState.tick()
+			if currentState.overBudget() {
+				var zero T
+				return zero, initial, ErrBudgetExceeded
+			}
 			parser := stepper(accum)
 			step, nextState, err := parser(currentState)
 			if err != nil {
@@ -34,3 +39,34 @@ func Loop[A any, T any](startAccum A, stepper func(A) Parser[Step[A, T]]) Parser
 		}
 	}
 }
+
+// Fold[T, A] returns a Parser[A] which parses as many items as it can with the parser argument,
+// one after another, and combines them as it goes with f, starting from init, which it returns
+// if there are none.  So the items needn't be collected in a slice, only to be summed or put in
+// a map.  As with Repeat, a failure with ErrNoMatch or ErrUnconsumedInput ends the repetition,
+// and any other error fails it, as does exceeding the budget.  An item which consumes no input
+// also ends it, so that it stops.
+func Fold[T, A any](parser Parser[T], init A, f func(A, T) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		accum := init
+		current := initial
+		for {
+			current.tick()
+			if current.overBudget() {
+				var zero A
+				return zero, initial, ErrBudgetExceeded
+			}
+			checkpoint := current.Save()
+			item, next, err := parser(current)
+			if err != nil && !isNoMatch(err) {
+				var zero A
+				return zero, initial, err
+			}
+			if err != nil || next.offset == current.offset {
+				return accum, current.Restore(checkpoint), nil
+			}
+			accum = f(accum, item)
+			current = next
+		}
+	}
+}
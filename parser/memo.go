@@ -0,0 +1,278 @@
+package parser
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Memo[T] returns a Parser[T] which behaves exactly like the parser argument, but remembers
+// the outcome of running it at each input offset, so asking again at the same offset returns
+// the remembered outcome without re-parsing.  In a grammar with heavy backtracking -- several
+// OneOf alternatives all starting with the same rule, say -- wrapping the shared rules in Memo
+// turns repeated work into lookups, which is the idea behind packrat parsing.
+//
+// Each call to Memo creates a separate rule; keep the returned Parser and reuse it.  How
+// outcomes are stored, and for how long, is chosen per parse with WithMemo; by default they are
+// kept for the duration of a single call to Parse.  Memoized outcomes must not depend on
+// anything but the input, so don't Memo a parser whose handler functions have side effects.
+// Only values and failures to match are remembered: any other error, such as
+// ErrBudgetExceeded, belongs to the parse which ran into it, and is returned without being
+// stored.  The errors Recover recovered from inside the parser argument are remembered with
+// the outcome, and recorded again whenever it is replayed.
+func Memo[T any](parser Parser[T]) Parser[T] {
+	rule := new(memoRule)
+	return func(initial State) (T, State, error) {
+		table := initial.memoTable()
+		if table == nil {
+			return parser(initial)
+		}
+		key := memoKey{rule: rule, offset: initial.offset, limit: len(initial.data), grammar: initial.run.grammar}
+		tree := initial.syntax()
+		if entry, ok := table.get(key); ok && (tree == nil || entry.syntax) {
+			initial.run.recovered = append(initial.run.recovered, entry.recovered...)
+			if entry.err != nil {
+				var zero T
+				return zero, initial, entry.err
+			}
+			tree.adopt(entry.nodes)
+			return entry.value.(T), initial.Consume(entry.end - initial.offset), nil
+		}
+		mark := tree.mark()
+		recovered := len(initial.run.recovered)
+		t, next, err := parser(initial)
+		if err != nil && !isNoMatch(err) {
+			return t, next, err
+		}
+		entry := memoEntry{value: t, end: next.offset, err: err, syntax: tree != nil}
+		if len(initial.run.recovered) > recovered {
+			entry.recovered = append([]diagnostic(nil), initial.run.recovered[recovered:]...)
+		}
+		if err == nil {
+			entry.nodes = tree.since(mark)
+		}
+		table.put(key, entry)
+		return t, next, err
+	}
+}
+
+// A MemoPolicy decides how outcomes of Memo parsers are stored; pass one to WithMemo.
+// The policies are PerParse, BoundedLRU, and the *SharedMemo returned by NewSharedMemo.
+type MemoPolicy interface {
+	newTable(run *parseRun) memoTable
+}
+
+// WithMemo returns an Option which selects how Memo parsers store their outcomes during the parse.
+func WithMemo(policy MemoPolicy) Option {
+	return func(c *config) {
+		c.memo = policy
+	}
+}
+
+// PerParse returns the default MemoPolicy: every outcome is kept until the call to Parse returns.
+// Memory use grows with the number of distinct (rule, offset) pairs tried.
+func PerParse() MemoPolicy {
+	return perParse{}
+}
+
+// BoundedLRU returns a MemoPolicy which keeps at most capacity outcomes per call to Parse,
+// discarding the least recently used one to make room.  It trades some repeated work for a
+// fixed bound on memory when parsing large inputs.
+func BoundedLRU(capacity int) MemoPolicy {
+	return boundedLRU{capacity: capacity}
+}
+
+// SharedMemo is a MemoPolicy whose outcomes outlive a single call to Parse, so that parsing
+// the same input again -- as a server seeing repeated payloads might -- reuses earlier work.
+// Outcomes are kept for up to the most recently seen maxInputs distinct inputs.
+//
+// Parses share outcomes only with parses of the same input with the same WithFeatures, since
+// the features can change what a rule matches.  A parse WithNormalization shares nothing: its
+// normalize function can't be compared with another's, so its outcomes are kept for that parse
+// alone, as with PerParse.  Nor does a parse WithArena, whose values and syntax tree nodes are
+// reused by the next parse once the Arena is Reset.
+//
+// A SharedMemo is safe for concurrent use by parses running in separate goroutines.
+type SharedMemo struct {
+	mu        sync.Mutex
+	maxInputs int
+	inputs    map[sharedKey]*list.Element // Values are *sharedTable, most recently used at the front of order.
+	order     list.List
+}
+
+// NewSharedMemo returns a SharedMemo remembering outcomes for up to maxInputs distinct inputs.
+func NewSharedMemo(maxInputs int) *SharedMemo {
+	return &SharedMemo{maxInputs: maxInputs, inputs: make(map[sharedKey]*list.Element)}
+}
+
+// memoRule is the identity of a single Memo parser.  It isn't zero-sized, because distinct
+// pointers to zero-sized values needn't compare unequal.
+type memoRule struct {
+	_ byte
+}
+
+// memoKey identifies a remembered outcome: which rule, where in the input, where the input
+// ended, since Within and similar combinators can run a rule over a truncated input, and which
+// Grammar Use was looking rules up in, since a rule may be used by more than one.
+type memoKey struct {
+	rule    *memoRule
+	offset  int
+	limit   int
+	grammar *Grammar
+}
+
+// memoEntry is a remembered outcome.  It holds the end offset rather than the State, because a
+// State belongs to one call to Parse and a shared entry may be used by another.
+type memoEntry struct {
+	value     any
+	end       int
+	err       error
+	syntax    bool          // Whether the outcome was recorded by a parse recording a syntax tree.
+	nodes     []*SyntaxNode // If so, the syntax tree nodes the parser recorded.
+	recovered []diagnostic  // The errors Recover recovered from while the parser ran.
+}
+
+// memoTable stores outcomes for one call to Parse.
+type memoTable interface {
+	get(key memoKey) (memoEntry, bool)
+	put(key memoKey, entry memoEntry)
+}
+
+// memoTable returns the memo table for the parse, creating it on first use.
+func (s State) memoTable() memoTable {
+	if s.run == nil {
+		return nil
+	}
+	if s.run.memo == nil {
+		policy := s.run.memoPolicy
+		if policy == nil {
+			policy = perParse{}
+		}
+		s.run.memo = policy.newTable(s.run)
+	}
+	return s.run.memo
+}
+
+type perParse struct{}
+
+func (perParse) newTable(*parseRun) memoTable {
+	return mapTable{}
+}
+
+type mapTable map[memoKey]memoEntry
+
+func (t mapTable) get(key memoKey) (memoEntry, bool) {
+	e, ok := t[key]
+	return e, ok
+}
+
+func (t mapTable) put(key memoKey, entry memoEntry) {
+	t[key] = entry
+}
+
+type boundedLRU struct {
+	capacity int
+}
+
+func (p boundedLRU) newTable(*parseRun) memoTable {
+	return &lruTable{capacity: p.capacity, entries: make(map[memoKey]*list.Element)}
+}
+
+// lruTable is a memoTable holding at most capacity entries.
+type lruTable struct {
+	capacity int
+	entries  map[memoKey]*list.Element // Values are lruItem, most recently used at the front of order.
+	order    list.List
+}
+
+type lruItem struct {
+	key   memoKey
+	entry memoEntry
+}
+
+func (t *lruTable) get(key memoKey) (memoEntry, bool) {
+	e, ok := t.entries[key]
+	if !ok {
+		return memoEntry{}, false
+	}
+	t.order.MoveToFront(e)
+	return e.Value.(lruItem).entry, true
+}
+
+func (t *lruTable) put(key memoKey, entry memoEntry) {
+	if t.capacity <= 0 {
+		return
+	}
+	if e, ok := t.entries[key]; ok {
+		e.Value = lruItem{key: key, entry: entry}
+		t.order.MoveToFront(e)
+		return
+	}
+	if t.order.Len() >= t.capacity {
+		oldest := t.order.Back()
+		delete(t.entries, oldest.Value.(lruItem).key)
+		t.order.Remove(oldest)
+	}
+	t.entries[key] = t.order.PushFront(lruItem{key: key, entry: entry})
+}
+
+// sharedKey identifies the parses which share a sharedTable: those of one input, with the same
+// features enabled.
+type sharedKey struct {
+	input    string
+	features string // The enabled features, sorted, each followed by a NUL.
+}
+
+// sharedTable is the memoTable for one input of a SharedMemo, shared by every parse of that
+// input with the same features.
+type sharedTable struct {
+	mu      sync.Mutex
+	key     sharedKey
+	entries map[memoKey]memoEntry
+}
+
+func (m *SharedMemo) newTable(run *parseRun) memoTable {
+	if run.normalize != nil || run.arena != nil {
+		return mapTable{}
+	}
+	var features []string
+	for name, on := range run.features {
+		if on {
+			features = append(features, name+"\x00")
+		}
+	}
+	sort.Strings(features)
+	key := sharedKey{input: run.input, features: strings.Join(features, "")}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.inputs[key]; ok {
+		m.order.MoveToFront(e)
+		return e.Value.(*sharedTable)
+	}
+	t := &sharedTable{key: key, entries: make(map[memoKey]memoEntry)}
+	if m.maxInputs <= 0 {
+		return t
+	}
+	if m.order.Len() >= m.maxInputs {
+		oldest := m.order.Back()
+		delete(m.inputs, oldest.Value.(*sharedTable).key)
+		m.order.Remove(oldest)
+	}
+	m.inputs[key] = m.order.PushFront(t)
+	return t
+}
+
+func (t *sharedTable) get(key memoKey) (memoEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[key]
+	return e, ok
+}
+
+func (t *sharedTable) put(key memoKey, entry memoEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries[key] = entry
+}
@@ -0,0 +1,34 @@
+package parser
+
+import "strings"
+
+// Nested[T] returns a Parser[T] for layered formats, where some stretch of the input is a
+// separate document: a header whose value is an encoded payload, a string literal holding a
+// date, and so on.  The outer parser matches the stretch and returns the text of the inner
+// document, and then inner parses that text as an input of its own, consuming all of it.
+// If either fails, or inner leaves some of the text unconsumed, Nested fails with its error
+// or ErrUnconsumedInput.
+//
+// Positions inside inner are translated back to the original input: if the text outer returns
+// is a substring of what it consumed, as when outer strips delimiters, offsets, Spans and
+// errors built from them are exact.  If outer decoded the text, offsets are counted as if the
+// decoded text began where outer did, which places errors at the start of the stretch; decode
+// with Transformed instead to have them mapped exactly.
+func Nested[T any](outer Parser[string], inner Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		text, next, err := outer(initial)
+		if err != nil {
+			return zero, initial, err
+		}
+		base := initial.Offset()
+		if i := strings.Index(initial.data[initial.offset:next.offset], text); i >= 0 {
+			base += i
+		}
+		t, err := reparse(initial, text, base, inner)
+		if err != nil {
+			return zero, initial, err
+		}
+		return t, next, nil
+	}
+}
@@ -0,0 +1,71 @@
+package parser
+
+// An Option configures a single call to Parse.
+type Option func(*config)
+
+// config holds the settings for a single call to Parse, as set by Options.
+type config struct {
+	maxInput  int                 // Largest input Parse will accept, in bytes; 0 means no limit.
+	budget    int                 // Most operations Parse will perform; 0 means no limit.
+	memo      MemoPolicy          // How Memo parsers store outcomes; nil means PerParse.
+	features  map[string]bool     // Dialect features enabled with WithFeatures.
+	normalize func(string) string // From WithNormalization; nil means compare bytes as they are.
+	tree      *SyntaxNode         // From WithSyntaxTree; nil means no syntax tree is recorded.
+	checks    bool                // From WithInvariantChecks.
+	arena     *Arena              // From WithArena; nil means allocate from the heap.
+}
+
+// WithMaxInput returns an Option which makes Parse reject any input longer than n bytes
+// with ErrInputTooLarge, before running the parser at all, wrapped in a *ParseError at offset n,
+// where the input went past the limit.  This is a cheap first line of defense when parsing
+// untrusted payloads.
+func WithMaxInput(n int) Option {
+	return func(c *config) {
+		c.maxInput = n
+	}
+}
+
+// WithBudget returns an Option which makes Parse give up with ErrBudgetExceeded after
+// performing n operations, where consuming input and backtracking out of a failed OneOf
+// alternative are each an operation; the error is wrapped in a *ParseError at the furthest
+// offset the parse reached.  Unlike a wall-clock timeout, a budget gives the same
+// answer for the same input every time, so it's a deterministic defense against
+// pathological inputs that exploit backtracking or repetition.
+func WithBudget(n int) Option {
+	return func(c *config) {
+		c.budget = n
+	}
+}
+
+// WithNormalization returns an Option which makes Exactly compare its token with the input
+// after putting both into a normal form with the normalize function.  It is intended for
+// Unicode normalization, where the same text can be encoded more than one way: macOS file
+// names, for instance, arrive decomposed, so an "é" is "e" followed by a combining accent.
+// The golang.org/x/text/unicode/norm package provides suitable functions:
+//
+//	Parse(grammar, input, WithNormalization(norm.NFC.String))
+//
+// Positions, spans and the text returned by GetString still refer to the input as given.
+func WithNormalization(normalize func(string) string) Option {
+	return func(c *config) {
+		c.normalize = normalize
+	}
+}
+
+// Limit[T] returns a Parser[T] which runs the parser argument, but fails with ErrLimitExceeded
+// if it consumes more than maxBytes bytes of input.  The parser argument sees the whole of the
+// input, so its lookahead is unaffected by the limit; to bound the work it may do before
+// consuming too much, use WithBudget.
+func Limit[T any](parser Parser[T], maxBytes int) Parser[T] {
+	return func(initial State) (T, State, error) {
+		t, next, err := parser(initial)
+		if err == nil && next.offset-initial.offset > maxBytes {
+			err = ErrLimitExceeded
+		}
+		if err != nil {
+			var zero T
+			return zero, initial, err
+		}
+		return t, next, nil
+	}
+}
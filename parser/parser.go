@@ -8,15 +8,21 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 // A Parser[T] is a parser that, on parsing success, produces a value of type T.
 //
 // Parser[T] is implemented as a function, but that's a detail that need not
-// concern package users, as Parsers are created by calls to creation, combination, and transformation
+// concern most package users, as Parsers are created by calls to creation, combination, and transformation
 // functions in this package.  Actually parsing an input string is done using the Parse[T] function.
-type Parser[T any] func(state) (T, state, error)
+//
+// A Parser written by hand takes the initial State and returns its value and the State after
+// what it consumed.  On failure it must return a non-nil error along with the initial State;
+// Save and Restore help with that when the parser tries things speculatively.
+type Parser[T any] func(State) (T, State, error)
 
 // Empty is the type returned by Parsers that don't return anything more meaningful.
 type Empty struct{}
@@ -26,35 +32,174 @@ var (
 	ErrNoMatch = errors.New("no match") // When parsing outright failed.
 
 	ErrUnconsumedInput = errors.New("unconsumed input") // When parsing succeeded but didn't consume all the input.
+
+	ErrInputTooLarge = errors.New("input too large") // When the input is longer than allowed by WithMaxInput.
+
+	ErrLimitExceeded = errors.New("limit exceeded") // When a parser wrapped by Limit consumed too much input.
+
+	ErrBudgetExceeded = errors.New("budget exceeded") // When parsing took more operations than allowed by WithBudget.
 )
 
+// ParseError is the error returned by Parse[T] when the parse fails, saying where.  Err is the
+// underlying error, such as ErrNoMatch or ErrUnconsumedInput, so errors.Is(err, ErrNoMatch)
+// still works on a ParseError.
+//
+// A failing parser backtracks to where it began, so the position is instead the furthest
+// point in the input that any parser reached, which is usually just before the mistake.  For
+// ErrUnconsumedInput, that is at least where the parser stopped.  For an *ExpectedError, from
+// Label, it is where the expected thing was looked for.
+type ParseError struct {
+	Offset  int      // Byte offset from the start of the input.
+	Line    int      // 1-based line number.
+	Column  int      // 1-based column, counted in runes from the start of the line.
+	Context []string // The names of the InContext parsers the parse failed inside, outermost first.
+	Fixes   []Fix    // Suggested corrections, from WithFix and ExactlyOrInsert.
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns a *ParseError for err at offset in input.
+func newParseError(input string, offset int, err error) *ParseError {
+	before := input[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return &ParseError{
+		Offset:  offset,
+		Line:    strings.Count(before, "\n") + 1,
+		Column:  utf8.RuneCountInString(before[lineStart:]) + 1,
+		Context: contextOf(err),
+		Fixes:   fixesOf(err),
+		Err:     err,
+	}
+}
+
+// isNoMatch reports whether err means only that a parser didn't match, so that an
+// alternative may be tried instead.  ErrUnconsumedInput counts: a parser confined to a
+// window by Within, Nested and the like, which stops short of the window's end, just
+// doesn't fit there.
+func isNoMatch(err error) bool {
+	return errors.Is(err, ErrNoMatch) || errors.Is(err, ErrUnconsumedInput)
+}
+
 // Parse[T] takes a Parser[T] and an input string, and runs the Parser on the input string.
 // On success, Parser returns a value of type T.   Parse[T] returns ErrNoMatch for a failed parse,
-// and ErrUnconsumedInput if the parser succeeded but didn't consume all of the input string.
-func Parse[T any](parser Parser[T], data string) (T, error) {
-	initial := state{data: data, offset: 0}
+// and ErrUnconsumedInput if the parser succeeded but didn't consume all of the input string,
+// each wrapped in a *ParseError saying where in the input the parse failed.  Any other error
+// returned by the parser is wrapped in the same way, unless it already is a *ParseError.
+// When the error is an *ExpectedError with no Causes, it is given every expectation recorded
+// at its offset, such as those of alternatives tried there and backtracked out of, but not
+// the expectations of lookahead or of failures recovered from.  If parsers made with Recover
+// recovered from errors along the way, Parse instead returns Diagnostics listing them, along
+// with the value if the parse otherwise succeeded.
+// Options, if any, adjust how the parse is run; see WithMaxInput, WithBudget, WithMemo,
+// WithFeatures, WithNormalization and WithArena.
+func Parse[T any](parser Parser[T], data string, options ...Option) (T, error) {
+	var c config
+	for _, option := range options {
+		option(&c)
+	}
+	if c.maxInput > 0 && len(data) > c.maxInput {
+		var zero T
+		return zero, newParseError(data, c.maxInput, ErrInputTooLarge)
+	}
+	run := &parseRun{
+		input:      data,
+		budget:     c.budget,
+		memoPolicy: c.memo,
+		features:   c.features,
+		normalize:  c.normalize,
+		checks:     c.checks,
+		arena:      c.arena,
+	}
+	if c.tree != nil {
+		run.tree = &syntaxTree{open: []*SyntaxNode{{}}}
+	}
+	initial := State{data: data, offset: 0, run: run}
 	result, final, err := parser(initial)
+	if initial.overBudget() {
+		var zero T
+		return zero, newParseError(data, run.furthest, ErrBudgetExceeded)
+	}
 	if err != nil {
 		var zero T
-		return zero, err
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			return zero, run.diagnose(err)
+		}
+		offset := run.furthest
+		var expected *ExpectedError
+		if errors.As(err, &expected) {
+			offset = expected.Offset
+		}
+		if e, ok := err.(*ExpectedError); ok && len(e.Causes) == 0 && run.expected != nil && run.expected.Offset == e.Offset {
+			err = run.expected.merge(e)
+		}
+		return zero, run.diagnose(newParseError(data, offset, err))
 	}
 	if final.offset < len(final.data) {
 		var zero T
-		return zero, ErrUnconsumedInput
+		offset := final.offset
+		if run.furthest > offset {
+			offset = run.furthest
+		}
+		return zero, run.diagnose(newParseError(data, offset, ErrUnconsumedInput))
+	}
+	if c.tree != nil {
+		*c.tree = SyntaxNode{Span: Span{Start: 0, End: len(data)}, Children: run.tree.open[0].Children}
+	}
+	if len(run.recovered) > 0 {
+		return result, run.diagnose(nil)
 	}
 	return result, err
 }
 
 // Fail[T] is a parser which always fails to match.
-func Fail[T any](initial state) (T, state, error) {
+func Fail[T any](initial State) (T, State, error) {
 	var zero T
 	return zero, initial, ErrNoMatch
 }
 
+// FailWith[T] returns a Parser[T] which always fails with err, consuming no input, for a
+// grammar to report its own errors, such as ErrDuplicateKey, from an AndThen handler.  The
+// error also counts as not matching, so OneOf goes on to its next alternative, and Label
+// replaces it; but it is kept as a cause, so that errors.Is and errors.As find err in the
+// error Parse returns if the parse fails there.  Return err from a hand-written parser instead
+// to report it straight away.
+func FailWith[T any](err error) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		return zero, initial, &noMatchError{err: err}
+	}
+}
+
+// noMatchError is the error from FailWith: err, but also ErrNoMatch.
+type noMatchError struct {
+	err error
+}
+
+func (e *noMatchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *noMatchError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrNoMatch, for errors.Is; Unwrap gives err for the rest.
+func (e *noMatchError) Is(target error) bool {
+	return target == ErrNoMatch
+}
+
 // Succeed[T] returns a Parser[T] which always succeeds by producing the value argment from the call to Succeed.
 // Succeed consumes no input.
 func Succeed[T any](value T) Parser[T] {
-	return func(initial state) (T, state, error) {
+	return func(initial State) (T, State, error) {
 		return value, initial, nil
 	}
 }
@@ -62,7 +207,7 @@ func Succeed[T any](value T) Parser[T] {
 // Map[T, A] returns a Parser[A] which transforms the output of a successful parse using
 // the argument parser from type T to type A using the mapper argument.
 func Map[T any, A any](parser Parser[T], mapper func(T) A) Parser[A] {
-	return func(initial state) (A, state, error) {
+	return func(initial State) (A, State, error) {
 		t, next, err := parser(initial)
 		if err != nil {
 			var zero A
@@ -72,64 +217,236 @@ func Map[T any, A any](parser Parser[T], mapper func(T) A) Parser[A] {
 	}
 }
 
+// MapErr[T, A] is like Map, but the mapper function can fail, as a conversion such as
+// strconv.Atoi can, and then the parser fails with the error it returned.  As with Verify, the
+// error decides how the failure is treated: one wrapping ErrNoMatch lets a OneOf go on to its
+// next alternative, while any other is reported straight away, and can be matched by the caller
+// of Parse with errors.Is and errors.As.
+func MapErr[T any, A any](parser Parser[T], mapper func(T) (A, error)) Parser[A] {
+	return func(initial State) (A, State, error) {
+		var zero A
+		t, next, err := parser(initial)
+		if err != nil {
+			return zero, initial, err
+		}
+		a, err := mapper(t)
+		if err != nil {
+			return zero, initial, err
+		}
+		return a, next, nil
+	}
+}
+
 // AndThen[T, U] returns a Parser[U] which first parses using the parser argument,
 // and then on success, produces another Parser by calling the handler argument on the
 // result; finally it returns the value of calling the second Parser.
 func AndThen[T any, U any](parser Parser[T], handler func(T) Parser[U]) Parser[U] {
-	return func(initial state) (U, state, error) {
+	return func(initial State) (U, State, error) {
 		t, next, err := parser(initial)
 		if err != nil {
 			var zero U
 			return zero, initial, err
 		}
 		nextParser := handler(t)
-		return nextParser(next)
+		u, final, err := nextParser(next)
+		if err != nil {
+			var zero U
+			return zero, initial, err
+		}
+		return u, final, nil
+	}
+}
+
+// AndThenErr[T, U] is like AndThen, but the handler function can fail instead of returning a
+// parser, and then the parser fails with the error it returned, which MapErr describes.
+func AndThenErr[T any, U any](parser Parser[T], handler func(T) (Parser[U], error)) Parser[U] {
+	return func(initial State) (U, State, error) {
+		var zero U
+		t, next, err := parser(initial)
+		if err != nil {
+			return zero, initial, err
+		}
+		nextParser, err := handler(t)
+		if err != nil {
+			return zero, initial, err
+		}
+		u, final, err := nextParser(next)
+		if err != nil {
+			return zero, initial, err
+		}
+		return u, final, nil
+	}
+}
+
+// Atomic[T] returns a Parser[T] which runs the parser argument all or nothing: if it fails, it
+// fails from the State it was given, as if it had never run, however the parser is built.  So
+// a sequence of AndThens which fails midway doesn't leave behind the diagnostics Recover
+// recorded or the syntax nodes built for its earlier steps, and a hand-written parser which
+// returns a half-consumed State with its error can't confuse the parsers around it.
+func Atomic[T any](parser Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		checkpoint := initial.Save()
+		t, next, err := parser(initial)
+		if err != nil {
+			var zero T
+			return zero, initial.Restore(checkpoint), err
+		}
+		return t, next, nil
 	}
 }
 
 // OneOf[T] returns a Parser[T] which will try each Parser in parsers in turn.
 // The value of the first Parser to succeed is returned.  If no Parser succeeds,
-// the last Parser's error is returned, or ErrNoMatch if there were no Parsers at all.
+// the last Parser's error is returned, or ErrNoMatch if there were no Parsers at all.  But if
+// any of them failed with an *ExpectedError, from Label, the labels of those that failed
+// furthest into the input are merged into one *ExpectedError, which is returned instead, with
+// the more specific errors any of them failed with, such as those from FailWith, as its Causes.
+// Without labels, the last such error is returned rather than a bare ErrNoMatch.
+//
+// Only a Parser failing with ErrNoMatch or ErrUnconsumedInput, or an error wrapping one of
+// them, is taken to mean "try the next one".  Any other error, such as ErrBudgetExceeded or an
+// error a grammar reports about malformed input, is returned from OneOf straight away, without
+// trying the alternatives after it; a grammar whose own errors should let OneOf go on must wrap
+// ErrNoMatch in them, as FailWith and ExpectedError do.
 func OneOf[T any](parsers ...Parser[T]) Parser[T] {
-	return func(initial state) (T, state, error) {
-		err := ErrNoMatch
+	return func(initial State) (T, State, error) {
+		var zero T
+		failures := alternatives{err: ErrNoMatch}
+		checkpoint := initial.Save()
 		for _, parser := range parsers {
-			var result T
-			var next state
-			result, next, err = parser(initial)
+			result, next, err := parser(initial)
 			if err == nil {
 				return result, next, nil
 			}
+			initial = initial.Restore(checkpoint)
+			if !isNoMatch(err) {
+				return zero, initial, err
+			}
+			failures.failed(err)
+			initial.tick()
+			if initial.overBudget() {
+				return zero, initial, ErrBudgetExceeded
+			}
 		}
+		return zero, initial, failures.error()
+	}
+}
+
+// OneOfLongest[T] returns a Parser[T] which tries every Parser in parsers, and returns the
+// value of the one which consumed the most input, or of the first of those if several consumed
+// as much; so alternatives which share a prefix, such as "<" and "<=", needn't be ordered with
+// care.  Only the winner's diagnostics and syntax nodes are kept.  If no Parser succeeds, the
+// error is made as OneOf makes it, and as with OneOf, an error other than not matching is
+// returned straight away.  Every alternative is run each time, so prefer OneOf where the order
+// is easy to get right.
+func OneOfLongest[T any](parsers ...Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
 		var zero T
-		return zero, initial, err
+		failures := alternatives{err: ErrNoMatch}
+		checkpoint := initial.Save()
+		var best T
+		var bestNext State
+		var bestRecovered []diagnostic
+		var bestNodes []*SyntaxNode
+		found := false
+		for _, parser := range parsers {
+			result, next, err := parser(initial)
+			switch {
+			case err == nil && (!found || next.offset > bestNext.offset):
+				best, bestNext, found = result, next, true
+				if initial.run != nil {
+					bestRecovered = append([]diagnostic(nil), initial.run.recovered[checkpoint.recovered:]...)
+					bestNodes = initial.run.tree.since(checkpoint.children)
+				}
+			case err != nil && !isNoMatch(err):
+				return zero, initial.Restore(checkpoint), err
+			case err != nil:
+				failures.failed(err)
+			}
+			initial = initial.Restore(checkpoint)
+			initial.tick()
+			if initial.overBudget() {
+				return zero, initial, ErrBudgetExceeded
+			}
+		}
+		if !found {
+			return zero, initial, failures.error()
+		}
+		if initial.run != nil {
+			initial.run.recovered = append(initial.run.recovered, bestRecovered...)
+			initial.run.tree.adopt(bestNodes)
+		}
+		return best, bestNext, nil
+	}
+}
+
+// alternatives gathers the errors of the alternatives of a OneOf which didn't match, to make
+// the error it returns if none does.
+type alternatives struct {
+	err      error          // The last of the errors.
+	expected *ExpectedError // The merged ExpectedErrors, if any.
+	causes   []error        // The more specific of the other errors.
+}
+
+// failed records err, the error of an alternative which didn't match.
+func (a *alternatives) failed(err error) {
+	a.err = err
+	if e, ok := err.(*ExpectedError); ok {
+		a.expected = a.expected.merge(e)
+	} else {
+		a.causes = append(a.causes, causesOf(err)...)
 	}
 }
 
+// error returns the error for none of the alternatives matching.
+func (a *alternatives) error() error {
+	switch {
+	case a.expected != nil && len(a.causes) > 0:
+		merged := *a.expected
+		merged.Causes = append(append([]error(nil), a.expected.Causes...), a.causes...)
+		return &merged
+	case a.expected != nil:
+		return a.expected
+	case len(a.causes) > 0:
+		return a.causes[len(a.causes)-1]
+	}
+	return a.err
+}
+
 // ConsumeIf returns a Parser which tests the next rune in the input with
 // the condition function.  If the condition is met, the rune is consumed from
-// the input and the parser succeeds.  Otherwise the parser fails.
+// the input and the parser succeeds.  Otherwise, or at the end of the input, the parser fails.
+// The end of the input fails even if the condition would be true of utf8.RuneError, so a
+// condition such as "anything but a newline" doesn't match nothing there.
 func ConsumeIf(condition func(rune) bool) Parser[Empty] {
-	return func(initial state) (Empty, state, error) {
+	return func(initial State) (Empty, State, error) {
+		if initial.overBudget() {
+			return Empty{}, initial, ErrBudgetExceeded
+		}
 		r, next := initial.nextRune()
-		if !condition(r) {
+		if next.offset == initial.offset || !condition(r) {
 			return Empty{}, initial, ErrNoMatch
 		}
-		return Empty{}, next, nil
+		return Empty{}, next.reached(), nil
 	}
 }
 
 // ConsumeWhile returns a Parser which tests each successive in the input with
 // the condition function.  For each rune for which the condition is met, the rune is consumed from
 // the input.  The parser finishes when some rune does not meet the condition.
-// The parser always succeeds, even if no runes are met.
+// The parser always succeeds, even if no runes are met, and stops at the end of the input,
+// whatever the condition would say of utf8.RuneError, so it never loops there without
+// consuming anything.
 func ConsumeWhile(condition func(r rune) bool) Parser[Empty] {
-	return func(initial state) (Empty, state, error) {
+	return func(initial State) (Empty, State, error) {
 		current := initial
 		for {
+			if current.overBudget() {
+				return Empty{}, initial, ErrBudgetExceeded
+			}
 			r, next := current.nextRune()
-			if !condition(r) {
-				return Empty{}, current, nil
+			if next.offset == current.offset || !condition(r) {
+				return Empty{}, current.reached(), nil
 			}
 			current = next
 		}
@@ -147,21 +464,36 @@ func ConsumeSome(condition func(rune) bool) Parser[Empty] {
 
 // Exactly returns a Parser which compares the beginning of the remaining
 // input to the token argument.  If they match, the corresponding amount of input
-// is consumed and the parser succeeds, otherwise the parser fails.
+// is consumed and the parser succeeds, otherwise the parser fails with an *ExpectedError
+// naming the token, quoted, so that when the alternatives of a OneOf are tokens such as "true"
+// and "false" its error lists them all.
+//
+// When the parse uses WithNormalization, the comparison is made between normalized forms
+// instead, and the amount of input consumed is however much normalizes to the token.
 func Exactly(token string) Parser[Empty] {
-	return func(initial state) (Empty, state, error) {
-		if strings.HasPrefix(initial.remaining(), token) {
-			next := initial.consume(len(token))
+	expected := []string{fmt.Sprintf("%q", token)}
+	return func(initial State) (Empty, State, error) {
+		if initial.overBudget() {
+			return Empty{}, initial, ErrBudgetExceeded
+		}
+		if normalize := initial.normalizer(); normalize != nil {
+			if n, ok := hasPrefixNormalized(initial.Remaining(), token, normalize); ok {
+				return Empty{}, initial.Consume(n), nil
+			}
+			return Empty{}, initial, initial.expect(&ExpectedError{Offset: initial.Offset(), Expected: expected})
+		}
+		if strings.HasPrefix(initial.Remaining(), token) {
+			next := initial.Consume(len(token))
 			return Empty{}, next, nil
 		}
-		return Empty{}, initial, ErrNoMatch
+		return Empty{}, initial, initial.expect(&ExpectedError{Offset: initial.Offset(), Expected: expected})
 	}
 }
 
 // GetString[T] generates a Parser[string] which succeeds exactly when the parser argument
 // succeeds; on success it returns the slice of the input string matched by parser.
 func GetString[T any](parser Parser[T]) Parser[string] {
-	return func(initial state) (string, state, error) {
+	return func(initial State) (string, State, error) {
 		start := initial.offset
 		_, next, err := parser(initial)
 		if err != nil {
@@ -171,3 +503,85 @@ func GetString[T any](parser Parser[T]) Parser[string] {
 		return next.data[start:end], next, nil
 	}
 }
+
+// TakeWhile returns a Parser[string] which consumes runes from the input for as long as they
+// meet the condition, and returns the text it consumed, which may be empty.  It is
+// GetString(ConsumeWhile(condition)), done in one pass, and costs the same against WithBudget.
+func TakeWhile(condition func(rune) bool) Parser[string] {
+	return func(initial State) (string, State, error) {
+		if initial.overBudget() {
+			return "", initial, ErrBudgetExceeded
+		}
+		n, err := initial.prefixLen(condition)
+		if err != nil {
+			return "", initial, err
+		}
+		next := initial.advance(n).reached()
+		return initial.data[initial.offset:next.offset], next, nil
+	}
+}
+
+// TakeSome is like TakeWhile, but fails unless at least one rune meets the condition, like
+// GetString(ConsumeSome(condition)).
+func TakeSome(condition func(rune) bool) Parser[string] {
+	return func(initial State) (string, State, error) {
+		if initial.overBudget() {
+			return "", initial, ErrBudgetExceeded
+		}
+		n, err := initial.prefixLen(condition)
+		if err != nil {
+			return "", initial, err
+		}
+		if n == 0 {
+			return "", initial, ErrNoMatch
+		}
+		next := initial.advance(n).reached()
+		return initial.data[initial.offset:next.offset], next, nil
+	}
+}
+
+// prefixLen returns the length in bytes of the longest prefix of the remaining input whose
+// runes all meet the condition.  It charges the budget one operation for each of them, as
+// ConsumeWhile does, and fails with ErrBudgetExceeded if the budget runs out first.
+func (s State) prefixLen(condition func(rune) bool) (int, error) {
+	rest := s.Remaining()
+	for i, r := range rest {
+		if !condition(r) {
+			return i, nil
+		}
+		s.tick()
+		if s.overBudget() {
+			return 0, ErrBudgetExceeded
+		}
+	}
+	return len(rest), nil
+}
+
+// TakeUntil[E] returns a Parser[string] which scans forward through the input a rune at a time
+// until the end parser would succeed, and returns the text scanned over.  The input matched by end
+// is not consumed, so it can be parsed as the next element of a sequence.  If end never succeeds,
+// even at the end of the input, the parser fails.  The returned text may be empty.
+func TakeUntil[E any](end Parser[E]) Parser[string] {
+	return func(initial State) (string, State, error) {
+		current := initial
+		for {
+			if current.overBudget() {
+				return "", initial, ErrBudgetExceeded
+			}
+			checkpoint := current.Save()
+			expected := current.expectation()
+			_, _, err := end(current)
+			current = current.Restore(checkpoint)
+			current.forgetExpected(expected)
+			if err == nil {
+				return initial.data[initial.offset:current.offset], current.reached(), nil
+			} else if !isNoMatch(err) {
+				return "", initial, err
+			}
+			if current.offset >= len(current.data) {
+				return "", initial, ErrNoMatch
+			}
+			_, current = current.nextRune()
+		}
+	}
+}
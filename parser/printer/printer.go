@@ -0,0 +1,214 @@
+// Package printer lays out text for people to read: the inverse of parsing, for grammars built
+// with the parser package which want to offer a formatter as well.  A value is printed by
+// building a Doc for it from Text, line breaks and the combinators which arrange them, and then
+// Rendering the Doc for a page width.  Each Group is laid out on one line if it fits, and
+// otherwise has its line breaks broken, so that a list prints as
+//
+//	[1, 2, 3]
+//
+// when it fits, and as
+//
+//	[
+//	  1,
+//	  2,
+//	  3,
+//	]
+//
+// when it doesn't, from the single Doc
+//
+//	Group(Concat(Text("["), Nest(2, Concat(SoftLine, Join(Concat(Text(","), Line), items...), IfBreak(Text(","), Empty))), SoftLine, Text("]")))
+//
+// This is the algebra of Wadler's "A prettier printer", laid out the way Lindig's "Strictly
+// Pretty" describes, so Render takes time in proportion to the size of the Doc.
+package printer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// A Doc is a document to be laid out by Render.  Docs are values, and may be shared between
+// documents and reused.
+type Doc interface {
+	isDoc()
+}
+
+type text string
+
+type line struct {
+	flat string // What the line break is when its group isn't broken.
+	hard bool   // Whether it is always broken.
+}
+
+type concat []Doc
+
+type nest struct {
+	indent int
+	doc    Doc
+}
+
+type group struct {
+	doc Doc
+}
+
+type ifBreak struct {
+	broken, flat Doc
+}
+
+func (text) isDoc()    {}
+func (line) isDoc()    {}
+func (concat) isDoc()  {}
+func (nest) isDoc()    {}
+func (group) isDoc()   {}
+func (ifBreak) isDoc() {}
+
+// The line breaks and Empty.  A Line is a space if its group is laid out on one line and a line
+// break otherwise, and a SoftLine is nothing or a line break.  A HardLine is always a line break,
+// and breaks every group around it.  After a line break, the next line is indented by the
+// total of the Nests around it.
+var (
+	Line     Doc = line{flat: " "}
+	SoftLine Doc = line{}
+	HardLine Doc = line{hard: true}
+	Empty    Doc = text("")
+)
+
+// Text returns the Doc for s, printed as it is.  s should not contain line breaks; use the line
+// break Docs between lines instead, so that they can be indented.
+func Text(s string) Doc {
+	return text(s)
+}
+
+// Concat returns the Doc for docs, one after another.
+func Concat(docs ...Doc) Doc {
+	return concat(docs)
+}
+
+// Join returns the Doc for docs, one after another, with separator between each of them.
+func Join(separator Doc, docs ...Doc) Doc {
+	joined := make(concat, 0, 2*len(docs))
+	for i, d := range docs {
+		if i > 0 {
+			joined = append(joined, separator)
+		}
+		joined = append(joined, d)
+	}
+	return joined
+}
+
+// Nest returns doc with indent more spaces at the start of each line which begins inside it.
+func Nest(indent int, doc Doc) Doc {
+	return nest{indent: indent, doc: doc}
+}
+
+// Group returns doc laid out on one line, its line breaks leaving it as spaces or nothing, if
+// that fits in what is left of the page width; otherwise its line breaks are broken, though the
+// Groups inside it are again laid out on one line if they fit.
+func Group(doc Doc) Doc {
+	return group{doc: doc}
+}
+
+// IfBreak returns the Doc which is broken if the Group around it is broken, and flat if it
+// isn't, for such things as a trailing comma which is only wanted after the last of a list
+// of lines.
+func IfBreak(broken, flat Doc) Doc {
+	return ifBreak{broken: broken, flat: flat}
+}
+
+// Render returns doc laid out for a page width runes wide.  Text which is wider than the page
+// by itself goes over it; nothing is broken but the line breaks.
+func Render(width int, doc Doc) string {
+	var b strings.Builder
+	column := 0
+	stack := []frame{{doc: doc, broken: true}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch d := f.doc.(type) {
+		case text:
+			b.WriteString(string(d))
+			column += utf8.RuneCountInString(string(d))
+		case line:
+			if f.broken || d.hard {
+				b.WriteByte('\n')
+				b.WriteString(strings.Repeat(" ", f.indent))
+				column = f.indent
+			} else {
+				b.WriteString(d.flat)
+				column += len(d.flat)
+			}
+		case concat:
+			for i := len(d) - 1; i >= 0; i-- {
+				stack = append(stack, frame{indent: f.indent, broken: f.broken, doc: d[i]})
+			}
+		case nest:
+			stack = append(stack, frame{indent: f.indent + d.indent, broken: f.broken, doc: d.doc})
+		case group:
+			flat := frame{indent: f.indent, doc: d.doc}
+			stack = append(stack, frame{indent: f.indent, broken: !fits(width-column, flat, stack), doc: d.doc})
+		case ifBreak:
+			if f.broken {
+				stack = append(stack, frame{indent: f.indent, broken: true, doc: d.broken})
+			} else {
+				stack = append(stack, frame{indent: f.indent, doc: d.flat})
+			}
+		}
+	}
+	return b.String()
+}
+
+// A frame is a Doc waiting to be laid out by Render, with the indent for its line breaks and
+// whether the group it is in is broken.
+type frame struct {
+	indent int
+	broken bool
+	doc    Doc
+}
+
+// fits reports whether next, laid out flat, and then the rest of the line after it, as laid out
+// by the frames of rest, fit in the remaining width.  The rest of the line ends at the first line
+// break in a broken group, or the first HardLine; a HardLine in next itself means it can't be
+// laid out flat at all.
+func fits(remaining int, next frame, rest []frame) bool {
+	pending := []frame{next}
+	inRest := false
+	for remaining >= 0 {
+		if len(pending) == 0 {
+			if len(rest) == 0 {
+				return true
+			}
+			pending = append(pending, rest[len(rest)-1])
+			rest = rest[:len(rest)-1]
+			inRest = true
+		}
+		f := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		switch d := f.doc.(type) {
+		case text:
+			remaining -= utf8.RuneCountInString(string(d))
+		case line:
+			if d.hard {
+				return inRest
+			}
+			if f.broken {
+				return true
+			}
+			remaining -= len(d.flat)
+		case concat:
+			for i := len(d) - 1; i >= 0; i-- {
+				pending = append(pending, frame{indent: f.indent, broken: f.broken, doc: d[i]})
+			}
+		case nest:
+			pending = append(pending, frame{indent: f.indent + d.indent, broken: f.broken, doc: d.doc})
+		case group:
+			pending = append(pending, frame{indent: f.indent, broken: f.broken, doc: d.doc})
+		case ifBreak:
+			if f.broken {
+				pending = append(pending, frame{indent: f.indent, doc: d.broken, broken: true})
+			} else {
+				pending = append(pending, frame{indent: f.indent, doc: d.flat})
+			}
+		}
+	}
+	return false
+}
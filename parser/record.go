@@ -0,0 +1,115 @@
+package parser
+
+import "errors"
+
+// Errors returned by Record parsers.
+var (
+	ErrUnknownField = errors.New("unknown field") // When a field name has no value parser and the policy is RejectUnknown.
+
+	ErrDuplicateField = errors.New("duplicate field") // When a field name repeats and the policy is RejectDuplicates.
+)
+
+// UnknownPolicy tells a Record parser what to do with a field name that has no entry in RecordSpec.Fields.
+type UnknownPolicy int
+
+const (
+	RejectUnknown UnknownPolicy = iota // Fail with ErrUnknownField.
+	KeepUnknown                        // Parse the value with RecordSpec.Fallback and keep the field.
+	IgnoreUnknown                      // Parse the value with RecordSpec.Fallback and discard the field.
+)
+
+// DuplicatePolicy tells a Record parser what to do with a field name that has already been seen.
+type DuplicatePolicy int
+
+const (
+	RejectDuplicates DuplicatePolicy = iota // Fail with ErrDuplicateField.
+	KeepFirst                               // Parse the value but keep the earlier field.
+	KeepLast                                // Parse the value and replace the earlier field's value with it.
+	KeepAll                                 // Keep every field in input order.
+)
+
+// Field is a single name and value produced by a Record parser.
+type Field[V any] struct {
+	Name  string
+	Value V
+}
+
+// RecordSpec describes the shape of a record for use with Record.
+type RecordSpec[V any] struct {
+	Name       Parser[string]       // Parses a field name.
+	Assign     Parser[Empty]        // Parses what sits between a name and its value, e.g. Exactly("=").
+	Separator  Parser[Empty]        // Parses what sits between fields, e.g. Exactly(",").
+	Fields     map[string]Parser[V] // Value parsers keyed by field name.
+	Fallback   Parser[V]            // Value parser for unknown names under KeepUnknown and IgnoreUnknown.
+	Unknown    UnknownPolicy
+	Duplicates DuplicatePolicy
+}
+
+// Record[V] returns a Parser which parses zero or more `name = value` fields, with the
+// value parser for each field chosen from spec.Fields by the field's name.  Fields
+// are returned in input order, subject to spec.Duplicates.
+//
+// The record ends when no further separator and name can be parsed; that final attempt
+// consumes no input.  Once a name has been parsed, though, the field is committed:
+// a failure in the assignment or value, or a violation of the unknown-name or
+// duplicate-name policy, fails the whole Record.
+func Record[V any](spec RecordSpec[V]) Parser[[]Field[V]] {
+	return func(initial State) ([]Field[V], State, error) {
+		var fields []Field[V]
+		seen := make(map[string]int)
+		current := initial
+		for {
+			if current.overBudget() {
+				return nil, initial, ErrBudgetExceeded
+			}
+			start := current.Save()
+			if len(seen) > 0 {
+				_, next, err := spec.Separator(current)
+				if err != nil {
+					if !isNoMatch(err) {
+						return nil, initial, err
+					}
+					return fields, current.Restore(start), nil
+				}
+				current = next
+			}
+			name, next, err := spec.Name(current)
+			if err != nil {
+				if !isNoMatch(err) {
+					return nil, initial, err
+				}
+				return fields, current.Restore(start), nil
+			}
+			value, ok := spec.Fields[name]
+			if !ok {
+				if spec.Unknown == RejectUnknown || spec.Fallback == nil {
+					return nil, initial, ErrUnknownField
+				}
+				value = spec.Fallback
+			}
+			index, dup := seen[name]
+			if dup && spec.Duplicates == RejectDuplicates {
+				return nil, initial, ErrDuplicateField
+			}
+			_, next, err = spec.Assign(next)
+			if err != nil {
+				return nil, initial, err
+			}
+			v, next, err := value(next)
+			if err != nil {
+				return nil, initial, err
+			}
+			current = next
+			switch {
+			case !ok && spec.Unknown == IgnoreUnknown:
+				seen[name] = -1
+			case dup && spec.Duplicates == KeepFirst:
+			case dup && spec.Duplicates == KeepLast && index >= 0:
+				fields[index].Value = v
+			default:
+				seen[name] = len(fields)
+				fields = append(fields, Field[V]{Name: name, Value: v})
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// diagnostic is an error Recover recovered from, and the offset where it was found.
+type diagnostic struct {
+	offset int
+	err    error
+}
+
+// Diagnostics is the error returned by Parse[T] when parsers made with Recover recovered from
+// errors in the input, so that tools such as linters and editors can report all of them at once
+// rather than just the first.  The errors are in input order; if the parse failed after all,
+// its own *ParseError comes last.  Use errors.As to get at the list:
+//
+//	var diags Diagnostics
+//	if errors.As(err, &diags) {
+//		for _, d := range diags { ... }
+//	}
+type Diagnostics []*ParseError
+
+func (d Diagnostics) Error() string {
+	switch len(d) {
+	case 0:
+		return "no errors"
+	case 1:
+		return d[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", d[0], len(d)-1)
+}
+
+// diagnose returns err, the error Parse is about to return, along with the errors recovered
+// from during the parse as Diagnostics; or just err if there weren't any.
+func (r *parseRun) diagnose(err error) error {
+	if len(r.recovered) == 0 {
+		return err
+	}
+	d := make(Diagnostics, 0, len(r.recovered)+1)
+	for _, rec := range r.recovered {
+		d = append(d, newParseError(r.input, rec.offset, rec.err))
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		d = append(d, parseErr)
+	}
+	return d
+}
+
+// Recover[T] returns a Parser[T] which parses with the parser argument, but which recovers if
+// it fails: it records the error, skips ahead to the next rune for which sync returns true, or to
+// the end of the input, and succeeds there with the zero value of T.  The rune sync stops at is
+// not consumed, so that the enclosing grammar can go on from it; to keep going through a list
+// after a bad element, for instance, recover each element and sync on the ",".  Parse reports
+// the recorded errors as Diagnostics; to have them suggest corrections, use WithFix inside.
+//
+// An error recorded inside a OneOf alternative which goes on to fail is forgotten, as is one
+// recorded by a hand-written parser between a Save and its Restore, so only errors on the path
+// the parse finally took are reported.  ErrBudgetExceeded is never recovered from.  A Memo
+// parser which replays an outcome records the errors recovered from in it again.
+func Recover[T any](parser Parser[T], sync func(rune) bool) Parser[T] {
+	return RecoverWith(parser, sync, func(ErrorNode) T {
+		var zero T
+		return zero
+	})
+}
+
+// An ErrorNode describes input which a parser made with RecoverWith skipped: where it is, and
+// the error which made the parser skip it.  A grammar whose results are syntax trees can give
+// them a kind of node holding an ErrorNode, and build one wherever it recovers, so that tools
+// such as editors still have a tree to walk for input with mistakes in it, with the mistakes
+// marked where they were:
+//
+//	type BadStatement struct{ ErrorNode }
+//
+//	func (BadStatement) statement() {}
+//
+//	RecoverWith(statement, isSemicolon, func(e ErrorNode) Statement { return BadStatement{e} })
+type ErrorNode struct {
+	Span   Span  // The input skipped, which may be empty.
+	Offset int   // Where in Span the error was found, as in the ParseError Parse reports for it.
+	Err    error // The error recovered from.
+}
+
+// RecoverWith[T] is like Recover, but instead of the zero value of T, it succeeds with the value
+// node returns for the input it skipped, such as an error node in a syntax tree.
+func RecoverWith[T any](parser Parser[T], sync func(rune) bool, node func(ErrorNode) T) Parser[T] {
+	return func(initial State) (T, State, error) {
+		checkpoint := initial.Save()
+		expected := initial.expectation()
+		t, next, err := parser(initial)
+		if err == nil || errors.Is(err, ErrBudgetExceeded) {
+			return t, next, err
+		}
+		initial = initial.Restore(checkpoint)
+		initial.forgetExpected(expected) // The error is reported as a diagnostic instead.
+		current := initial
+		for current.offset < len(current.data) {
+			if current.overBudget() {
+				var zero T
+				return zero, initial, ErrBudgetExceeded
+			}
+			r, after := current.nextRune()
+			if sync(r) {
+				break
+			}
+			current = after
+		}
+		offset := errorOffset(err, initial, current)
+		if initial.run != nil {
+			initial.run.recovered = append(initial.run.recovered, diagnostic{offset: offset, err: err})
+		}
+		skipped := Span{Start: initial.Offset(), End: current.Offset()}
+		return node(ErrorNode{Span: skipped, Offset: offset, Err: err}), current.reached(), nil
+	}
+}
+
+// errorOffset returns where between the states from and to the error err was found: where a
+// Label or a ParseError says it was, or else the furthest point reached, if that is in between.
+func errorOffset(err error, from, to State) int {
+	var expected *ExpectedError
+	var parseErr *ParseError
+	offset := from.Offset()
+	switch {
+	case errors.As(err, &expected):
+		offset = expected.Offset
+	case errors.As(err, &parseErr):
+		offset = parseErr.Offset
+	case from.run != nil && from.run.furthest > offset:
+		offset = from.run.furthest
+	}
+	if offset < from.Offset() || offset > to.Offset() {
+		return from.Offset()
+	}
+	return offset
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrUndefinedRef is the error returned by the parser of a ParserRef which hasn't been defined.
+var ErrUndefinedRef = errors.New("undefined parser reference")
+
+// A ParserRef[T] is a forward declaration of a Parser[T], for grammars whose rules refer to one
+// another: declare the rule with Ref, use its Parser in the rules which refer to it, and then
+// Define it once they exist.  A ParserRef is safe for concurrent use.
+//
+//	expr := Ref[Expr]()
+//	term := OneOf(number, parenthesized(expr.Parser()))
+//	expr.Define(sum(term))
+type ParserRef[T any] struct {
+	defined atomic.Pointer[Parser[T]]
+}
+
+// Ref[T] returns a new ParserRef[T], to be defined later.
+func Ref[T any]() *ParserRef[T] {
+	return &ParserRef[T]{}
+}
+
+// Define makes parser the definition of the ParserRef.  It panics if the ParserRef has already
+// been defined.
+func (r *ParserRef[T]) Define(parser Parser[T]) {
+	if !r.defined.CompareAndSwap(nil, &parser) {
+		panic("parser: ParserRef defined twice")
+	}
+}
+
+// Parser returns a Parser[T] which runs the ParserRef's definition.  It may be called, and the
+// parser used in other rules, before Define is; only running the parser must wait until then,
+// and until then it fails with ErrUndefinedRef.
+func (r *ParserRef[T]) Parser() Parser[T] {
+	return func(initial State) (T, State, error) {
+		parser := r.defined.Load()
+		if parser == nil {
+			var zero T
+			return zero, initial, ErrUndefinedRef
+		}
+		return (*parser)(initial)
+	}
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Errors returned by Lookup, and by Rule parsers.
+var (
+	ErrUnknownRule = errors.New("unknown rule") // When no parser is registered under the name.
+
+	ErrRuleType = errors.New("rule type mismatch") // When the registered parser produces a different type.
+)
+
+// registry holds the parsers registered with Register, keyed by name.  Values are Parser[T]
+// for whichever T they were registered with.
+var registry = struct {
+	sync.RWMutex
+	rules map[string]any
+}{rules: make(map[string]any)}
+
+// Register records parser under name, so that grammars in other packages can use it through
+// Lookup or Rule without importing the package that defines it.  Register is meant to be
+// called from init functions, and panics if name is already registered.
+//
+// Register, Lookup and Rule are safe for concurrent use.  For rules which other grammars
+// extend and override, rather than just use, see Grammar.
+func Register[T any](name string, parser Parser[T]) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.rules[name]; ok {
+		panic(fmt.Sprintf("parser: rule %q registered twice", name))
+	}
+	registry.rules[name] = parser
+}
+
+// Lookup[T] returns the Parser[T] registered under name.  It returns ErrUnknownRule if
+// nothing is registered under name, and ErrRuleType if the registered parser isn't a Parser[T].
+func Lookup[T any](name string) (Parser[T], error) {
+	registry.RLock()
+	rule, ok := registry.rules[name]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRule, name)
+	}
+	parser, ok := rule.(Parser[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is a %T", ErrRuleType, name, rule)
+	}
+	return parser, nil
+}
+
+// Rule[T] returns a Parser[T] which runs the parser registered under name.  The name is looked up
+// the first time the parser runs rather than when Rule is called, so a grammar can refer to rules
+// registered by packages initialized after its own.  Until the lookup succeeds, the parser fails with
+// the error from Lookup.
+func Rule[T any](name string) Parser[T] {
+	var resolved atomic.Pointer[Parser[T]]
+	return func(initial State) (T, State, error) {
+		parser := resolved.Load()
+		if parser == nil {
+			p, err := Lookup[T](name)
+			if err != nil {
+				var zero T
+				return zero, initial, err
+			}
+			resolved.Store(&p)
+			parser = &p
+		}
+		return named(name, *parser, initial)
+	}
+}
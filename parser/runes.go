@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The functions here make and combine rune predicates, the conditions ConsumeIf, ConsumeWhile
+// and ConsumeSome test runes with, so that a character class can be written as what it is made
+// of rather than as a closure of its own:
+//
+//	identStart := AnyOf(unicode.IsLetter, RuneIn("_$"))
+//	identRest := AnyOf(identStart, InRangeTable(unicode.Nd, unicode.Mn))
+//	hexDigit := AnyOf(RuneRange('0', '9'), RuneRange('a', 'f'), RuneRange('A', 'F'))
+//	text := NoneOf(RuneIn(`"\`), unicode.IsControl)
+
+// InRangeTable returns the predicate for the runes in any of the Unicode tables, such as
+// unicode.Greek or unicode.Nd, as unicode.In tests them.
+func InRangeTable(tables ...*unicode.RangeTable) func(rune) bool {
+	return func(r rune) bool {
+		return unicode.In(r, tables...)
+	}
+}
+
+// RuneIn returns the predicate for the runes in chars.
+func RuneIn(chars string) func(rune) bool {
+	return func(r rune) bool {
+		return strings.ContainsRune(chars, r)
+	}
+}
+
+// RuneRange returns the predicate for the runes from lo to hi, inclusive.
+func RuneRange(lo, hi rune) func(rune) bool {
+	return func(r rune) bool {
+		return lo <= r && r <= hi
+	}
+}
+
+// AllOf returns the predicate for the runes all of the predicates are true for: their
+// intersection.  With no predicates it is true for every rune.
+func AllOf(predicates ...func(rune) bool) func(rune) bool {
+	return func(r rune) bool {
+		for _, p := range predicates {
+			if !p(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf returns the predicate for the runes any of the predicates is true for: their union.
+// With no predicates it is false for every rune.
+func AnyOf(predicates ...func(rune) bool) func(rune) bool {
+	return func(r rune) bool {
+		for _, p := range predicates {
+			if p(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NoneOf returns the predicate for the runes none of the predicates is true for: the
+// complement of their union, so NoneOf(p) is the negation of p, and AllOf(p, NoneOf(q)) is the
+// runes of p without those of q.
+func NoneOf(predicates ...func(rune) bool) func(rune) bool {
+	union := AnyOf(predicates...)
+	return func(r rune) bool {
+		return !union(r)
+	}
+}
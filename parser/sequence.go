@@ -1,5 +1,8 @@
 package parser
 
+// Apply4 and up, their spanned forms, ValuesN and KeepN are in apply.go, written by genapply.
+//go:generate go run ../cmd/genapply -max 9 -o apply.go
+
 // Seq[T,U] is used to represent the kept values in parser sequences built using StartKeeping
 // and AppendKeeping.  They are principally passed as arguments to Apply, Apply2, and so on.
 // Users usually won't need to write out signatures involving Seq explicitly.
@@ -8,6 +11,13 @@ type Seq[T any, U any] struct {
 	second U
 }
 
+// Split returns the values of the sequence: the sequence of all but the last of them, and the
+// last.  It is for taking apart sequences longer than ValuesN does, as the functions genapply
+// writes into a grammar's own package do.
+func (s Seq[T, U]) Split() (T, U) {
+	return s.first, s.second
+}
+
 // StartKeeping[T] returns a sequence Parser which, on success, produces a single-element sequence that
 // contains result of the argument parser.  A single-element sequence is modeled as Seq[Empty, T], but this
 // is a detail that users should be able to ignore most of the time.
@@ -32,7 +42,7 @@ func StartSkipping[T any](parser Parser[T]) Parser[Empty] {
 // A user could pass a parserT argument which doesn't produce a sequence, but the result would
 // not work with ApplyN functions so it's hard to imagine the use case.
 func AppendKeeping[T any, U any](parserT Parser[T], parserU Parser[U]) Parser[Seq[T, U]] {
-	return func(initial state) (Seq[T, U], state, error) {
+	return func(initial State) (Seq[T, U], State, error) {
 		t, next, err := parserT(initial)
 		if err != nil {
 			var zero Seq[T, U]
@@ -53,7 +63,7 @@ func AppendKeeping[T any, U any](parserT Parser[T], parserU Parser[U]) Parser[Se
 // A user could pass a parserT argument which doesn't produce a sequence, but the result would
 // not work with ApplyN functions so it's hard to imagine the use case.
 func AppendSkipping[T any, U any](parserT Parser[T], parserU Parser[U]) Parser[T] {
-	return func(initial state) (T, state, error) {
+	return func(initial State) (T, State, error) {
 		t, next, err := parserT(initial)
 		if err != nil {
 			var zero T
@@ -72,7 +82,7 @@ func AppendSkipping[T any, U any](parserT Parser[T], parserU Parser[U]) Parser[T
 // a single-element sequence.  The resulting parser transforms the single value from the sequence
 // using the argument mapper function.
 func Apply[T any, A any](parser Parser[Seq[Empty, T]], mapper func(T) A) Parser[A] {
-	return func(initial state) (A, state, error) {
+	return func(initial State) (A, State, error) {
 		seq, next, err := parser(initial)
 		if err != nil {
 			var zero A
@@ -86,7 +96,7 @@ func Apply[T any, A any](parser Parser[Seq[Empty, T]], mapper func(T) A) Parser[
 // a two-element sequence.  The resulting parser transforms the two values from the sequence
 // into the final result value using the argument mapper function.
 func Apply2[T any, U any, A any](parser Parser[Seq[Seq[Empty, T], U]], mapper func(T, U) A) Parser[A] {
-	return func(initial state) (A, state, error) {
+	return func(initial State) (A, State, error) {
 		seq, next, err := parser(initial)
 		if err != nil {
 			var zero A
@@ -100,7 +110,7 @@ func Apply2[T any, U any, A any](parser Parser[Seq[Seq[Empty, T], U]], mapper fu
 // a three-element sequence.  The resulting parser transforms the three values from the sequence
 // into the final result value using the argument mapper function.
 func Apply3[T any, U any, V any, A any](parser Parser[Seq[Seq[Seq[Empty, T], U], V]], mapper func(T, U, V) A) Parser[A] {
-	return func(initial state) (A, state, error) {
+	return func(initial State) (A, State, error) {
 		seq, next, err := parser(initial)
 		if err != nil {
 			var zero A
@@ -109,3 +119,45 @@ func Apply3[T any, U any, V any, A any](parser Parser[Seq[Seq[Seq[Empty, T], U],
 		return mapper(seq.first.first.second, seq.first.second, seq.second), next, nil
 	}
 }
+
+// ApplySpanned is like Apply, but the mapper function also receives the Span of input
+// matched by the whole sequence, so that AST nodes can record where they came from.
+func ApplySpanned[T any, A any](parser Parser[Seq[Empty, T]], mapper func(Span, T) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.second), next, nil
+	}
+}
+
+// ApplySpanned2 is like Apply2, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned2[T any, U any, A any](parser Parser[Seq[Seq[Empty, T], U]], mapper func(Span, T, U) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.second, seq.second), next, nil
+	}
+}
+
+// ApplySpanned3 is like Apply3, but the mapper function also receives the Span of input
+// matched by the whole sequence.
+func ApplySpanned3[T any, U any, V any, A any](parser Parser[Seq[Seq[Seq[Empty, T], U], V]], mapper func(Span, T, U, V) A) Parser[A] {
+	return func(initial State) (A, State, error) {
+		seq, next, err := parser(initial)
+		if err != nil {
+			var zero A
+			return zero, initial, err
+		}
+		span := Span{Start: initial.Offset(), End: next.Offset()}
+		return mapper(span, seq.first.first.second, seq.first.second, seq.second), next, nil
+	}
+}
@@ -0,0 +1,27 @@
+package parser
+
+// Span is the region of the input matched by a parser, as byte offsets from the start of the input.
+// The matched text is input[Start:End].
+type Span struct {
+	Start int
+	End   int
+}
+
+// Spanned[T] is a value together with the Span of input it was parsed from.
+type Spanned[T any] struct {
+	Value T
+	Span  Span
+}
+
+// WithSpan[T] returns a Parser which succeeds exactly when the parser argument succeeds,
+// returning its value along with the Span of input it consumed.
+func WithSpan[T any](parser Parser[T]) Parser[Spanned[T]] {
+	return func(initial State) (Spanned[T], State, error) {
+		t, next, err := parser(initial)
+		if err != nil {
+			var zero Spanned[T]
+			return zero, initial, err
+		}
+		return Spanned[T]{Value: t, Span: Span{Start: initial.Offset(), End: next.Offset()}}, next, nil
+	}
+}
@@ -1,30 +1,233 @@
 package parser
 
 import (
+	"strings"
 	"unicode/utf8"
 )
 
-// state is the internal representation of parsing state.
-type state struct {
-	data   string // The input string
-	offset int    // The current parsing offset into the input string.
+// State is the parsing state passed into and returned from every Parser: the input, and how
+// much of it has been consumed.  States are values; a parser never changes the State it is
+// given, it returns a new one.  Package users only need State when writing their own
+// Parser functions directly, rather than building them with the functions in this package.
+type State struct {
+	data   string        // The input string
+	offset int           // The current parsing offset into the input string.
+	base   int           // Where data begins in the input positions are reported against; see Nested.
+	origin func(int) int // If not nil, maps offsets in data to the input instead of base; see Unfolded.
+	run    *parseRun     // Settings and bookkeeping shared by every state in a single call to Parse.
 }
 
-// remaining returns the a string which is just the unconsumed input
-func (s state) remaining() string {
+// parseRun holds whatever a single call to Parse shares across all of its states.  States
+// are copied freely, so anything in here is visible to every parser in the run.
+type parseRun struct {
+	input      string              // The whole input string passed to Parse.
+	budget     int                 // Operations allowed, from WithBudget; 0 means unlimited.
+	spent      int                 // Operations performed so far.
+	memoPolicy MemoPolicy          // From WithMemo; nil means PerParse.
+	memo       memoTable           // Outcomes of Memo parsers, created on first use.
+	features   map[string]bool     // Dialect features enabled with WithFeatures.
+	normalize  func(string) string // From WithNormalization, or nil.
+	furthest   int                 // The furthest offset any state has reached, for ParseError.
+	recovered  []diagnostic        // Errors Recover has recovered from, in input order.
+	tree       *syntaxTree         // The syntax tree being recorded, from WithSyntaxTree, or nil.
+	grammar    *Grammar            // The Grammar Use looks rules up in, set by Start, or nil.
+	checks     bool                // Whether named parsers check the State contract; see WithInvariantChecks.
+	arena      *Arena              // From WithArena, or nil.
+	expected   *ExpectedError      // What was expected furthest into the input, merged; see expect.
+}
+
+// Remaining returns the a string which is just the unconsumed input
+func (s State) Remaining() string {
 	return s.data[s.offset:]
 }
 
-// consume returns a new state in which the offset pointer is advanced
-// by n bytes
-func (s state) consume(n int) state {
+// Offset returns the number of bytes of input consumed so far.  Inside Nested or Unfolded, it
+// is the corresponding position in the outer input, so errors and spans built from offsets
+// refer to the text the user actually wrote.
+func (s State) Offset() int {
+	if s.origin != nil {
+		return s.origin(s.offset)
+	}
+	return s.base + s.offset
+}
+
+// Consume returns a new state in which the offset pointer is advanced
+// by n bytes.  n must not be more than len(s.Remaining()).
+func (s State) Consume(n int) State {
+	return s.advance(n).reached()
+}
+
+// advance is like Consume, but doesn't count the new offset as reached, for looking ahead.
+func (s State) advance(n int) State {
+	s.tick()
 	s.offset += n
 	return s
 }
 
+// reached records that the parse has got as far as s, for ParseError, and returns s.
+func (s State) reached() State {
+	if s.run != nil && s.Offset() > s.run.furthest {
+		s.run.furthest = s.Offset()
+	}
+	return s
+}
+
+// tick records one operation against the parse's budget.  Consuming input
+// and backtracking are both operations.
+func (s State) tick() {
+	if s.run != nil {
+		s.run.spent++
+	}
+}
+
+// overBudget reports whether the parse has performed more operations than its budget allows.
+func (s State) overBudget() bool {
+	return s.run != nil && s.run.budget > 0 && s.run.spent > s.run.budget
+}
+
 // nextRune returns the next rune in the input, as well as a new
-// state in which the rune has been consumed.
-func (s state) nextRune() (rune, state) {
-	r, w := utf8.DecodeRuneInString(s.remaining())
-	return r, s.consume(w)
+// state in which the rune has been consumed.  The new state doesn't count as reached until
+// a parser returns it, and calls reached.
+func (s State) nextRune() (rune, State) {
+	r, w := utf8.DecodeRuneInString(s.Remaining())
+	return r, s.advance(w)
+}
+
+// atLineStart reports whether the offset is at the start of the input or just after a newline.
+func (s State) atLineStart() bool {
+	return s.offset == 0 || s.data[s.offset-1] == '\n'
+}
+
+// atLineEnd reports whether the offset is at the end of the input or just before a
+// newline, where a newline is either "\n" or "\r\n".
+func (s State) atLineEnd() bool {
+	rest := s.Remaining()
+	return rest == "" || rest[0] == '\n' || strings.HasPrefix(rest, "\r\n")
+}
+
+// lineEnd returns the offset of the end of the current line, not counting its terminator,
+// and the offset just past the terminator.  On the last line of the input both are len(data).
+func (s State) lineEnd() (int, int) {
+	i := strings.IndexByte(s.Remaining(), '\n')
+	if i < 0 {
+		return len(s.data), len(s.data)
+	}
+	end := s.offset + i
+	if i > 0 && s.data[end-1] == '\r' {
+		return end - 1, end + 1
+	}
+	return end, end + 1
+}
+
+// truncate returns a new state in which the input ends at byte offset end, so that
+// parsers run on it can't see anything past end.
+func (s State) truncate(end int) State {
+	s.data = s.data[:end]
+	return s
+}
+
+// A Checkpoint is a saved State, to be returned to by Restore.
+type Checkpoint struct {
+	state     State
+	recovered int // How many errors had been recovered from.
+	children  int // How many syntax tree nodes the innermost named parser had recorded.
+}
+
+// Save returns a Checkpoint recording s.  A custom parser which wants to try something
+// speculatively should Save before trying it, and Restore if it doesn't work out.
+func (s State) Save() Checkpoint {
+	c := Checkpoint{state: s}
+	if s.run != nil {
+		c.recovered = len(s.run.recovered)
+		c.children = s.run.tree.mark()
+	}
+	return c
+}
+
+// Restore returns the State saved in the checkpoint, first rolling back any bookkeeping
+// the parse has done since then.  The one thing not rolled back is the operation count
+// used by WithBudget, since work done speculatively was still done.
+func (s State) Restore(c Checkpoint) State {
+	if s.run != nil && len(s.run.recovered) > c.recovered {
+		s.run.recovered = s.run.recovered[:c.recovered]
+	}
+	if s.run != nil {
+		s.run.tree.truncate(c.children)
+	}
+	return c.state
+}
+
+// normalizer returns the normalization function set with WithNormalization, or nil.
+func (s State) normalizer() func(string) string {
+	if s.run == nil {
+		return nil
+	}
+	return s.run.normalize
+}
+
+// hasPrefixNormalized reports whether s begins with text that normalizes to the same thing as
+// prefix, and if so how many bytes of s that text covers.  A match must not end just before a
+// rune that would combine with the matched text, so "e" is not a prefix of "e\u0301" under NFC.
+func hasPrefixNormalized(s, prefix string, normalize func(string) string) (int, bool) {
+	want := normalize(prefix)
+	// Normal forms can expand text several times over, but not without bound.
+	limit := 4*len(want) + utf8.UTFMax
+	for end := 0; end <= len(s) && end <= limit; {
+		if normalize(s[:end]) == want {
+			if end == len(s) {
+				return end, true
+			}
+			_, w := utf8.DecodeRuneInString(s[end:])
+			if strings.HasPrefix(normalize(s[:end+w]), want) {
+				return end, true
+			}
+		}
+		if end == len(s) {
+			break
+		}
+		_, w := utf8.DecodeRuneInString(s[end:])
+		end += w
+	}
+	return 0, false
+}
+
+// reparse runs parser over text as a separate input, sharing the parse's settings and
+// operation budget but not its Memo outcomes, which belong to the original input.  Offsets
+// in the new input are reported as if text began at offset base of the outer one.  The
+// parser must consume all of text, or reparse fails with ErrUnconsumedInput.
+func reparse[T any](s State, text string, base int, parser Parser[T]) (T, error) {
+	t, end, err := rerun(s, State{data: text, base: base}, parser)
+	if err == nil && end != len(text) {
+		var zero T
+		return zero, ErrUnconsumedInput
+	}
+	return t, err
+}
+
+// rerun runs parser from inner, a State at the start of a separate input, sharing s's settings
+// and operation budget as reparse does.  It returns the parser's value and the offset in the
+// separate input where the parser stopped.
+func rerun[T any](s State, inner State, parser Parser[T]) (T, int, error) {
+	if s.run != nil {
+		run := *s.run
+		run.input, run.memo = inner.data, nil
+		inner.run = &run
+		defer func() {
+			s.run.spent = run.spent
+			s.run.recovered = run.recovered
+			s.run.expected = run.expected
+			if run.furthest > s.run.furthest {
+				s.run.furthest = run.furthest
+			}
+		}()
+	}
+	t, next, err := parser(inner)
+	if inner.overBudget() {
+		err = ErrBudgetExceeded
+	}
+	if err != nil {
+		var zero T
+		return zero, 0, err
+	}
+	return t, next.offset, nil
 }
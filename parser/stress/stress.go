@@ -0,0 +1,136 @@
+// Package stress runs parsers from many goroutines at once, to check that parsers built with
+// the parser package, and the tables they share between calls to Parse -- SharedMemo, the rule
+// registry, Grammars and ParserRefs -- are safe for concurrent use, as their documentation
+// says.  Each input is first parsed once on its own, and every concurrent parse of it must then
+// give the same value and error.  Run it with the race detector, which finds the data races
+// that don't happen to change a result:
+//
+//	go run -race ./cmd/stress
+//
+// The inputs each goroutine parses, and their order, are chosen by a random source seeded from
+// Config.Seed, so a failing run can be repeated, as far as the scheduler allows.
+package stress
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/jhbrown-veradept/gophercon22-parser-combnators/parser"
+)
+
+// A Case is a parser, with the options to parse with and the inputs to give it, for Run.
+type Case struct {
+	name   string
+	inputs []string
+	parse  func(input string) string // Returns the outcome of parsing input, for comparison.
+}
+
+// NewCase[T] returns the Case called name which parses each of the inputs with parser and
+// options.  The options are shared by every goroutine, so they must be safe for concurrent use:
+// WithMemo(NewSharedMemo(n)) is, while WithSyntaxTree, which returns its tree through a
+// pointer, and WithArena, which each parse needs its own of, are not.  Values are compared as
+// formatted by fmt's %#v.
+func NewCase[T any](name string, p parser.Parser[T], inputs []string, options ...parser.Option) Case {
+	return Case{
+		name:   name,
+		inputs: inputs,
+		parse: func(input string) string {
+			v, err := parser.Parse(p, input, options...)
+			return fmt.Sprintf("%#v, %v", v, err)
+		},
+	}
+}
+
+// Config says how hard Run works.  The zero value uses the defaults.
+type Config struct {
+	Goroutines int   // The number of goroutines parsing at once; 0 means 16.
+	Iterations int   // The number of parses each goroutine makes; 0 means 1000.
+	Seed       int64 // Seeds the choice of inputs.
+}
+
+// A Mismatch is a concurrent parse whose outcome differed from the input's first parse.
+type Mismatch struct {
+	Case      string
+	Input     string
+	Got, Want string // The outcomes, as value and error.
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("case %s, input %q: got %s, want %s", m.Case, m.Input, m.Got, m.Want)
+}
+
+// Error is returned by Run when there is at least one Mismatch.
+type Error struct {
+	Mismatches []Mismatch // At most one per input, in the order they were found.
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		lines[i] = m.String()
+	}
+	return fmt.Sprintf("stress: %d mismatched parses:\n\t%s", len(e.Mismatches), strings.Join(lines, "\n\t"))
+}
+
+// A job is one input of one case, with its first outcome.
+type job struct {
+	c     *Case
+	input string
+	want  string
+}
+
+// Run parses every input of the cases once, one after another, and then parses them again from
+// config.Goroutines goroutines at once, each parsing config.Iterations inputs chosen at random,
+// and returns an *Error if any of those parses gave a different outcome than the first.  A
+// parser which panics panics Run.
+func Run(config Config, cases ...Case) error {
+	if config.Goroutines <= 0 {
+		config.Goroutines = 16
+	}
+	if config.Iterations <= 0 {
+		config.Iterations = 1000
+	}
+	var jobs []job
+	for i := range cases {
+		c := &cases[i]
+		for _, input := range c.inputs {
+			jobs = append(jobs, job{c: c, input: input, want: c.parse(input)})
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var mismatches []Mismatch
+	reported := make(map[int]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < config.Goroutines; g++ {
+		random := rand.New(rand.NewSource(config.Seed + int64(g)))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < config.Iterations; i++ {
+				n := random.Intn(len(jobs))
+				j := jobs[n]
+				got := j.c.parse(j.input)
+				if got == j.want {
+					continue
+				}
+				mu.Lock()
+				if !reported[n] {
+					reported[n] = true
+					mismatches = append(mismatches, Mismatch{Case: j.c.name, Input: j.input, Got: got, Want: j.want})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(mismatches) > 0 {
+		return &Error{Mismatches: mismatches}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package parser
+
+import "strings"
+
+// Table is a Parser which parses the rest of the input as a column-aligned table, as printed by
+// many command line tools:
+//
+//	CONTAINER ID   IMAGE     STATUS
+//	4c01db0b339c   ubuntu    Up 2 hours
+//
+// The first line is the header.  Each header name starts a column, and header names are separated
+// by at least two spaces or a tab, so a name may contain single spaces.  A column spans from the
+// start of its header name to the start of the next one, counted in runes, and the last column runs
+// to the end of the line.  Every following non-blank line becomes a map from header name to the trimmed
+// text in that column.
+func Table(initial State) ([]map[string]string, State, error) {
+	return AndThen(NonEmptyLine, func(header string) Parser[[]map[string]string] {
+		names, starts := tableColumns(header)
+		return tableRows(func(row string) ([]string, bool) {
+			runes := []rune(row)
+			cells := make([]string, len(names))
+			for i, start := range starts {
+				end := len(runes)
+				if i+1 < len(starts) && starts[i+1] < end {
+					end = starts[i+1]
+				}
+				if start < end {
+					cells[i] = strings.TrimSpace(string(runes[start:end]))
+				}
+			}
+			return cells, true
+		}, names)
+	})(initial)
+}
+
+// DelimitedTable returns a Parser which parses the rest of the input as a table whose header and rows
+// are split into cells by the delimiter, e.g. "|" or "\t".  Cells are trimmed of surrounding whitespace,
+// and every following non-blank line becomes a map from header name to cell.  A row with fewer cells than
+// the header maps the missing names to ""; a row with more cells than the header fails the parse.
+func DelimitedTable(delimiter string) Parser[[]map[string]string] {
+	split := func(line string) []string {
+		cells := strings.Split(line, delimiter)
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		return cells
+	}
+	return AndThen(NonEmptyLine, func(header string) Parser[[]map[string]string] {
+		names := split(header)
+		return tableRows(func(row string) ([]string, bool) {
+			cells := split(row)
+			return cells, len(cells) <= len(names)
+		}, names)
+	})
+}
+
+// tableRows returns a Parser for the lines of a table following its header, using cells to
+// split each row into values for names.  Blank lines are skipped.
+func tableRows(cells func(string) ([]string, bool), names []string) Parser[[]map[string]string] {
+	row := func(initial State) (map[string]string, State, error) {
+		line, next, _ := RestOfLine(initial)
+		if strings.TrimSpace(line) == "" {
+			return nil, next, nil
+		}
+		values, ok := cells(line)
+		if !ok {
+			return nil, initial, ErrNoMatch
+		}
+		m := make(map[string]string, len(names))
+		for i, name := range names {
+			m[name] = ""
+			if i < len(values) {
+				m[name] = values[i]
+			}
+		}
+		return m, next, nil
+	}
+	return Map(LinesOf[map[string]string](row), func(rows []map[string]string) []map[string]string {
+		var table []map[string]string
+		for _, m := range rows {
+			if m != nil {
+				table = append(table, m)
+			}
+		}
+		return table
+	})
+}
+
+// tableColumns splits a column-aligned header line into its names and the rune offset at which
+// each name starts.
+func tableColumns(header string) ([]string, []int) {
+	var names []string
+	var starts []int
+	runes := []rune(header)
+	isGap := func(i int) bool {
+		return runes[i] == '\t' || runes[i] == ' ' && (i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\t')
+	}
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' || runes[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && !isGap(i) {
+			i++
+		}
+		names = append(names, string(runes[start:i]))
+		starts = append(starts, start)
+	}
+	return names, starts
+}
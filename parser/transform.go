@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// A TextMap builds text which is a transformation of some original text, such as the original
+// with its escapes decoded or its folded lines joined, while keeping track of where each part of
+// it came from, so that positions in the new text can be mapped back to the original.  The new
+// text is built from the start of the original onwards, with Keep for stretches copied as they
+// are and Replace for stretches which are rewritten.  The zero value is an empty TextMap.
+type TextMap struct {
+	b        strings.Builder
+	segments []segment
+	original int // How much of the original the text built so far covers.
+}
+
+// segment records that the new text from offset at comes from the original at offset orig:
+// byte for byte, or if replaced, as a whole.
+type segment struct {
+	at, orig int
+	replaced bool
+}
+
+// Keep appends s, the next stretch of the original text, to the new text unchanged.
+func (m *TextMap) Keep(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(m.segments); n == 0 || m.segments[n-1].replaced {
+		m.segments = append(m.segments, segment{at: m.b.Len(), orig: m.original})
+	}
+	m.b.WriteString(s)
+	m.original += len(s)
+}
+
+// Replace appends s to the new text in place of the next n bytes of the original text.  Either
+// may be empty, so Replace can remove text as well as rewrite it.
+func (m *TextMap) Replace(n int, s string) {
+	m.segments = append(m.segments, segment{at: m.b.Len(), orig: m.original, replaced: true})
+	m.b.WriteString(s)
+	m.original += n
+}
+
+// String returns the new text built so far.
+func (m *TextMap) String() string {
+	return m.b.String()
+}
+
+// Original returns the offset in the original text corresponding to offset in the new text.
+// Offsets within a replacement all map to the start of the stretch it replaced, and an offset
+// at the end of the new text maps to the end of the original text it covers.
+func (m *TextMap) Original(offset int) int {
+	if offset >= m.b.Len() {
+		return m.original + offset - m.b.Len()
+	}
+	i := sort.Search(len(m.segments), func(i int) bool { return m.segments[i].at > offset }) - 1
+	if i < 0 {
+		return offset
+	}
+	seg := m.segments[i]
+	if seg.replaced {
+		return seg.orig
+	}
+	return seg.orig + offset - seg.at
+}
+
+// Transformed[T] returns a Parser[T] for input which is easier to parse once it has been
+// rewritten: decoded, unfolded, normalized and so on.  The transform function is given the rest
+// of the input and writes into a TextMap the new text for as much of it as it wants to rewrite,
+// which is often all of it; then the parser argument parses the new text.  The Transformed
+// parser consumes as much of the original input as the parser argument consumed of the new
+// text.  If transform returns an error, the Transformed parser fails with it.
+//
+// Positions are mapped back through the TextMap: offsets, Spans and errors inside the parser
+// argument all refer to the input as it was written, so a grammar can preprocess its input
+// without its error messages pointing at text the user never saw.
+func Transformed[T any](transform func(input string, m *TextMap) error, parser Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		var zero T
+		m := &TextMap{}
+		if err := transform(initial.Remaining(), m); err != nil {
+			return zero, initial, err
+		}
+		origin := func(offset int) int {
+			s := initial
+			s.offset += m.Original(offset)
+			return s.Offset()
+		}
+		t, end, err := rerun(initial, State{data: m.String(), origin: origin}, parser)
+		if err != nil {
+			return zero, initial, err
+		}
+		return t, initial.Consume(m.Original(end)), nil
+	}
+}
@@ -0,0 +1,66 @@
+package parser
+
+import "strings"
+
+// Folding is a way of breaking long lines, for Unfolded to undo.  Both ways continue a line on
+// the next by starting it with a space or a tab.
+type Folding int
+
+const (
+	// DropFoldSpace is the folding of iCalendar and vCard, where a fold is a line break with one
+	// space or tab after it, all of which unfolding removes.
+	DropFoldSpace Folding = iota
+
+	// KeepFoldSpace is the folding of mail and MIME headers, as in RFC 5322, where unfolding
+	// removes only the line break, and keeps the whitespace which follows it.
+	KeepFoldSpace
+)
+
+// unfold returns a transform for Transformed which removes folds in the way given by folding.
+func unfold(folding Folding) func(string, *TextMap) error {
+	return func(text string, m *TextMap) error {
+		for {
+			i := strings.IndexByte(text, '\n')
+			if i < 0 {
+				break
+			}
+			if i+1 == len(text) || text[i+1] != ' ' && text[i+1] != '\t' {
+				m.Keep(text[:i+1])
+				text = text[i+1:]
+				continue
+			}
+			end, skip := i, i+1
+			if end > 0 && text[end-1] == '\r' {
+				end--
+			}
+			if folding == DropFoldSpace {
+				skip++
+			}
+			m.Keep(text[:end])
+			m.Replace(skip-end, "")
+			text = text[skip:]
+		}
+		m.Keep(text)
+		return nil
+	}
+}
+
+// Unfolded[T] returns a Parser[T] which runs the parser argument on the rest of the input with
+// its folded lines joined back together, as folding says, so the parser argument needn't know
+// about folds at all.  The Unfolded parser consumes as much of the input as the parser argument
+// consumed of the unfolded text.
+//
+// Positions are mapped back through the unfolding, as Transformed does: offsets, Spans and errors
+// inside the parser argument all refer to the input as it was written, folds and all.  An offset
+// at the very place a fold was removed maps to just after the fold, so a Span which ends there
+// takes in the fold.
+func Unfolded[T any](parser Parser[T], folding Folding) Parser[T] {
+	transformed := Transformed(unfold(folding), parser)
+	return func(initial State) (T, State, error) {
+		rest := initial.Remaining()
+		if !strings.Contains(rest, "\n ") && !strings.Contains(rest, "\n\t") {
+			return parser(initial)
+		}
+		return transformed(initial)
+	}
+}
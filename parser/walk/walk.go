@@ -0,0 +1,69 @@
+// Package walk traverses and rewrites the trees which grammars built with the parser package
+// return as their results, so that each consumer of a grammar doesn't write its own traversal
+// to find the nodes it cares about, or to make transformations such as folding constants or
+// expanding interpolations.
+//
+// The trees are of any type whose nodes implement Node, typically an interface type which each
+// kind of node in the tree implements:
+//
+//	type Expr interface {
+//		Children() []Expr
+//		WithChildren(children []Expr) Expr
+//	}
+//
+//	func (b Binary) Children() []Expr { return []Expr{b.Left, b.Right} }
+//
+//	func (b Binary) WithChildren(c []Expr) Expr { return Binary{Op: b.Op, Left: c[0], Right: c[1]} }
+package walk
+
+// A Node[N] is a node of a tree whose nodes are Ns.  Children returns the nodes directly below
+// it, in order, and WithChildren returns a copy of it with those replaced by the ones given, of
+// which there are as many; it must not change the node it is called on, since Rewrite leaves
+// the original tree as it was.  A node with no children returns nil from Children, and itself
+// from WithChildren.
+type Node[N any] interface {
+	Children() []N
+	WithChildren(children []N) N
+}
+
+// Walk[N] calls visit for each node of the tree below root, and root itself, parents before
+// their children and children in order.  If visit returns false, the children of that node are
+// skipped.
+func Walk[N Node[N]](root N, visit func(N) bool) {
+	if !visit(root) {
+		return
+	}
+	for _, child := range root.Children() {
+		Walk(child, visit)
+	}
+}
+
+// Find[N] returns the nodes of the tree below root, and root itself, for which match returns
+// true, in the order Walk visits them.
+func Find[N Node[N]](root N, match func(N) bool) []N {
+	var found []N
+	Walk(root, func(n N) bool {
+		if match(n) {
+			found = append(found, n)
+		}
+		return true
+	})
+	return found
+}
+
+// Rewrite[N] returns a copy of the tree below root in which each node has been replaced with
+// what rewrite returns for it.  Nodes are rewritten children first, so rewrite is given a node
+// whose children have already been rewritten, and what it returns is not rewritten again: to
+// fold constants, for instance, rewrite needs only to replace an operator whose operands are
+// both constants with a constant.  The original tree is left as it was.
+func Rewrite[N Node[N]](root N, rewrite func(N) N) N {
+	children := root.Children()
+	if len(children) == 0 {
+		return rewrite(root)
+	}
+	rewritten := make([]N, len(children))
+	for i, child := range children {
+		rewritten[i] = Rewrite(child, rewrite)
+	}
+	return rewrite(root.WithChildren(rewritten))
+}
@@ -0,0 +1,48 @@
+package parser
+
+import "unicode/utf8"
+
+// Within[T] returns a Parser[T] which runs the parser argument on just the next n bytes of
+// input, as if the input ended there, and requires it to consume all n.  It is the building
+// block for length-prefixed protocols and fixed-width layouts, where a field's extent is known
+// before its contents are parsed.  The parser fails with ErrNoMatch if fewer than n bytes remain,
+// and with ErrUnconsumedInput if the parser argument stops short of the end of the window.
+// Anchors such as EndOfInput and EndOfLine match at the end of the window.
+func Within[T any](n int, parser Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		return within(initial, initial.offset+n, parser)
+	}
+}
+
+// WithinRunes[T] is like Within, but the window is the next n runes rather than bytes.
+func WithinRunes[T any](n int, parser Parser[T]) Parser[T] {
+	return func(initial State) (T, State, error) {
+		end := initial.offset
+		for i := 0; i < n; i++ {
+			if end >= len(initial.data) {
+				var zero T
+				return zero, initial, ErrNoMatch
+			}
+			_, w := utf8.DecodeRuneInString(initial.data[end:])
+			end += w
+		}
+		return within(initial, end, parser)
+	}
+}
+
+// within runs parser on the input from initial up to byte offset end, which it must consume entirely.
+func within[T any](initial State, end int, parser Parser[T]) (T, State, error) {
+	var zero T
+	if end > len(initial.data) {
+		return zero, initial, ErrNoMatch
+	}
+	t, next, err := parser(initial.truncate(end))
+	if err == nil && next.offset != end {
+		err = ErrUnconsumedInput
+	}
+	if err != nil {
+		return zero, initial, err
+	}
+	next.data = initial.data
+	return t, next, nil
+}